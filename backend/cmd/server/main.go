@@ -1,35 +1,122 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/claude-api-gateway/backend/internal/api/router"
 	"github.com/claude-api-gateway/backend/internal/config"
+	"github.com/claude-api-gateway/backend/internal/proxy"
+	"github.com/claude-api-gateway/backend/internal/service"
 	"github.com/claude-api-gateway/backend/pkg/database"
 	"github.com/claude-api-gateway/backend/pkg/logger"
+	"github.com/claude-api-gateway/backend/pkg/secret"
 )
 
 func runMigrations() error {
-	// Read migration file from filesystem
-	migrationPath := "migrations/001_init.sql"
-	migrations, err := os.ReadFile(migrationPath)
+	// Track applied migrations so that non-idempotent statements (ALTER TABLE) only run once
+	_, err := database.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(100) PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
 
-	// Execute migration
-	_, err = database.DB.Exec(string(migrations))
+	// Read migration files from filesystem, applied in lexical order
+	migrationFiles, err := filepath.Glob("migrations/*.sql")
 	if err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
+		return fmt.Errorf("failed to list migration files: %w", err)
+	}
+	sort.Strings(migrationFiles)
+
+	for _, migrationPath := range migrationFiles {
+		version := filepath.Base(migrationPath)
+
+		var applied int
+		if err := database.DB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		migrations, err := os.ReadFile(migrationPath)
+		if err != nil {
+			return fmt.Errorf("failed to read migration file %s: %w", version, err)
+		}
+
+		// Execute migration and record it atomically
+		tx, err := database.DB.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec(string(migrations)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to execute migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", version, err)
+		}
+		logger.Info("Applied migration %s", version)
 	}
 
 	logger.Info("Database migrations completed successfully")
 	return nil
 }
 
+// setupEncryption installs the master key for channel API keys, if one is configured
+func setupEncryption(cfg *config.Config) error {
+	masterKey, err := secret.LoadMasterKey(cfg.EncryptionKey, cfg.EncryptionKeyFile)
+	if err != nil {
+		return err
+	}
+	if masterKey != nil {
+		envelope, err := secret.NewEnvelope(masterKey)
+		if err != nil {
+			return err
+		}
+		secret.SetEnvelope(envelope)
+		logger.Info("Channel API keys are encrypted at rest with master key %s", envelope.KeyID())
+	}
+	return nil
+}
+
+// checkStoredKeys refuses to start when stored channel API keys cannot be decrypted,
+// and warns about keys left in plaintext
+func checkStoredKeys() error {
+	status, err := service.NewKeyEncryptionService().Status()
+	if err != nil {
+		return err
+	}
+	switch {
+	case status.Unreadable > 0 && !secret.Encrypting():
+		return fmt.Errorf("%d channel API keys are encrypted but ENCRYPTION_KEY is not set", status.Unreadable)
+	case status.Unreadable > 0:
+		return fmt.Errorf("%d channel API keys cannot be decrypted with the configured master key", status.Unreadable)
+	case !secret.Encrypting():
+		logger.Error("ENCRYPTION_KEY is not set: channel API keys are stored in plaintext")
+	case status.Plaintext > 0:
+		logger.Error("%d channel API keys are stored in plaintext, run the server with -encrypt-keys to encrypt them", status.Plaintext)
+	}
+	return nil
+}
+
 func main() {
+	encryptKeys := flag.Bool("encrypt-keys", false, "encrypt channel API keys stored in plaintext with ENCRYPTION_KEY, then exit")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -38,6 +125,18 @@ func main() {
 
 	logger.Info("Starting Claude API Gateway...")
 	logger.Info("Configuration: Port=%d, DataDir=%s, Debug=%v", cfg.ServerPort, cfg.DataDir, cfg.Debug)
+	switch {
+	case cfg.AdminAPIKey != "" && cfg.AdminAPIKey == cfg.APIKey:
+		log.Fatalf("ADMIN_API_KEY must differ from API_KEY")
+	case cfg.AdminAPIKey == "" && cfg.AdminUsername == "" && !cfg.OIDCEnabled() && cfg.APIKey != "":
+		logger.Error("ADMIN_API_KEY is not set: only existing admin users can sign in to the management API")
+	case cfg.ManagementOpen():
+		logger.Error("Neither ADMIN_API_KEY, ADMIN_USERNAME, OIDC_ISSUER nor API_KEY is set: the management API is open to anyone")
+	}
+
+	if err := service.ValidateOIDCConfig(cfg); err != nil {
+		log.Fatalf("Invalid OpenID Connect configuration: %v", err)
+	}
 
 	// Initialize database
 	if err := database.Initialize(cfg.DataDir); err != nil {
@@ -51,6 +150,34 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Load the master key for channel API keys
+	if err := setupEncryption(cfg); err != nil {
+		log.Fatalf("Failed to set up channel API key encryption: %v", err)
+	}
+	if *encryptKeys {
+		encrypted, err := service.NewKeyEncryptionService().EncryptAll()
+		if err != nil {
+			log.Fatalf("Failed to encrypt channel API keys: %v", err)
+		}
+		logger.Info("Encrypted %d channel API keys", encrypted)
+		return
+	}
+	if err := checkStoredKeys(); err != nil {
+		log.Fatalf("Failed to read channel API keys: %v", err)
+	}
+
+	// Create the first admin user from ADMIN_USERNAME / ADMIN_PASSWORD
+	created, err := service.NewAdminUserService().Bootstrap(cfg.AdminUsername, cfg.AdminPassword)
+	if err != nil {
+		log.Fatalf("Failed to create admin user: %v", err)
+	}
+	if created {
+		logger.Info("Created admin user %s", cfg.AdminUsername)
+	}
+
+	// Start background channel health checks
+	proxy.NewHealthChecker(cfg).Start(context.Background())
+
 	// Setup router
 	r := router.Setup(cfg)
 
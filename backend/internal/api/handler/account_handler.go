@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claude-api-gateway/backend/internal/api/middleware"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler handles requests of a signed-in admin user about their own account
+type AccountHandler struct {
+	userService *service.AdminUserService
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler() *AccountHandler {
+	return &AccountHandler{
+		userService: service.NewAdminUserService(),
+	}
+}
+
+// Get returns the signed-in admin user
+func (h *AccountHandler) Get(c *gin.Context) {
+	session, ok := accountSession(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetByID(session.AdminUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword changes the signed-in user's password, signing out their other sessions
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	session, ok := accountSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.PasswordChange
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ChangePassword(session.AdminUserID, session.ID, &req); err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+// SetupTOTP generates a TOTP secret for the signed-in user to add to their authenticator app
+func (h *AccountHandler) SetupTOTP(c *gin.Context) {
+	session, ok := accountSession(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.userService.SetupTOTP(session.AdminUserID)
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTOTP turns on two-factor authentication after checking a code from the new secret
+func (h *AccountHandler) EnableTOTP(c *gin.Context) {
+	session, ok := accountSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.EnableTOTP(session.AdminUserID, req.Code); err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled"})
+}
+
+// DisableTOTP turns off two-factor authentication, confirmed by a current code
+func (h *AccountHandler) DisableTOTP(c *gin.Context) {
+	session, ok := accountSession(c)
+	if !ok {
+		return
+	}
+
+	var req model.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.DisableTOTP(session.AdminUserID, req.Code); err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// accountSession returns the session of the signed-in admin user. Requests authenticated with
+// the admin key have no account.
+func accountSession(c *gin.Context) (*model.AdminSession, bool) {
+	session := middleware.AdminSession(c)
+	if session == nil || session.AdminUserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sign in as an admin user to manage your account"})
+		return nil, false
+	}
+	return session, true
+}
+
+// writeAccountError rejects wrong passwords and TOTP codes as bad requests
+func writeAccountError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrWrongPassword) || errors.Is(err, service.ErrInvalidTOTP) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-api-gateway/backend/internal/api/middleware"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSessionHandler handles admin web UI session management requests
+type AdminSessionHandler struct {
+	sessionService *service.AdminSessionService
+}
+
+// NewAdminSessionHandler creates a new admin session handler
+func NewAdminSessionHandler(sessionService *service.AdminSessionService) *AdminSessionHandler {
+	return &AdminSessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List returns the active sessions, marking the one making the request
+func (h *AdminSessionHandler) List(c *gin.Context) {
+	var currentID int64
+	if session := middleware.AdminSession(c); session != nil {
+		currentID = session.ID
+	}
+
+	sessions, err := h.sessionService.List(currentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  sessions,
+		"total": len(sessions),
+	})
+}
+
+// Revoke ends a session, signing its browser out on the next request
+func (h *AdminSessionHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	if err := h.sessionService.Revoke(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserHandler handles admin user account management requests
+type AdminUserHandler struct {
+	userService *service.AdminUserService
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler() *AdminUserHandler {
+	return &AdminUserHandler{
+		userService: service.NewAdminUserService(),
+	}
+}
+
+// List returns all admin users
+func (h *AdminUserHandler) List(c *gin.Context) {
+	users, err := h.userService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": len(users),
+	})
+}
+
+// Create creates an admin user
+func (h *AdminUserHandler) Create(c *gin.Context) {
+	var req model.AdminUserCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.Create(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// Get returns an admin user by ID
+func (h *AdminUserHandler) Get(c *gin.Context) {
+	id, ok := parseAdminUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Update changes an admin user's role, password or status, or resets their TOTP
+func (h *AdminUserHandler) Update(c *gin.Context) {
+	id, ok := parseAdminUserID(c)
+	if !ok {
+		return
+	}
+
+	var req model.AdminUserUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.Update(id, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, _ := h.userService.GetByID(id)
+	c.JSON(http.StatusOK, user)
+}
+
+// Delete deletes an admin user
+func (h *AdminUserHandler) Delete(c *gin.Context) {
+	id, ok := parseAdminUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "admin user deleted"})
+}
+
+func parseAdminUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid admin user id"})
+		return 0, false
+	}
+	return id, true
+}
@@ -1,39 +1,67 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/claude-api-gateway/backend/internal/api/middleware"
+	"github.com/claude-api-gateway/backend/internal/config"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthHandler handles authentication
+// legacyAuthCookie is the cookie earlier versions stored the admin key itself in
+const legacyAuthCookie = "auth_token"
+
+// oidcStateCookie binds an OpenID Connect sign-in to the browser that started it
+const oidcStateCookie = "gateway_oidc_state"
+
+// AuthHandler handles sign-in to the web UI with an admin user account or the admin key
 type AuthHandler struct {
-	apiKey string
+	cfg            *config.Config
+	sessionService *service.AdminSessionService
+	userService    *service.AdminUserService
+	oidcService    *service.OIDCService
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(apiKey string) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, sessionService *service.AdminSessionService) *AuthHandler {
 	return &AuthHandler{
-		apiKey: apiKey,
+		cfg:            cfg,
+		sessionService: sessionService,
+		userService:    service.NewAdminUserService(),
+		oidcService:    service.NewOIDCService(cfg),
 	}
 }
 
-// LoginRequest represents the login request
+// LoginRequest represents the login request, with either a username and password, plus a
+// TOTP code for users with two-factor authentication, or the admin key
 type LoginRequest struct {
-	APIKey string `json:"api_key" binding:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	TOTPCode string `json:"totp_code"`
+	APIKey   string `json:"api_key"`
 }
 
 // LoginResponse represents the login response
 type LoginResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Token   string `json:"token,omitempty"`
+	Success      bool       `json:"success"`
+	Message      string     `json:"message"`
+	TOTPRequired bool       `json:"totp_required,omitempty"` // sign in again with a TOTP code
+	Role         string     `json:"role,omitempty"`
+	CSRFToken    string     `json:"csrf_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
 }
 
-// Login handles login requests
+// Login starts a session for the web UI, sent as an HttpOnly cookie
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Username == "" && req.APIKey == "") {
 		c.JSON(http.StatusBadRequest, LoginResponse{
 			Success: false,
 			Message: "Invalid request",
@@ -41,44 +69,158 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Validate API key
-	if req.APIKey != h.apiKey {
+	var userID int64
+	role := model.RoleAdmin
+	if req.Username != "" {
+		user, err := h.userService.Authenticate(req.Username, req.Password, req.TOTPCode)
+		switch {
+		case errors.Is(err, service.ErrTOTPRequired):
+			c.JSON(http.StatusUnauthorized, LoginResponse{
+				Success:      false,
+				Message:      err.Error(),
+				TOTPRequired: true,
+			})
+			return
+		case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidTOTP):
+			c.JSON(http.StatusUnauthorized, LoginResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, LoginResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		userID, role = user.ID, user.Role
+	} else if !middleware.ValidAdminKey(h.cfg.AdminAPIKey, req.APIKey) {
 		c.JSON(http.StatusUnauthorized, LoginResponse{
 			Success: false,
-			Message: "Invalid API key",
+			Message: "Invalid admin key",
 		})
 		return
 	}
 
-	// Set cookie for web UI
-	c.SetCookie(
-		"auth_token",
-		h.apiKey,
-		3600*24*30, // 30 days
-		"/",
-		"",
-		false,
-		true,
-	)
+	session, err := h.startSession(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, LoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, LoginResponse{
-		Success: true,
-		Message: "Login successful",
-		Token:   h.apiKey,
+		Success:   true,
+		Message:   "Login successful",
+		Role:      role,
+		CSRFToken: session.CSRFToken,
+		ExpiresAt: &session.ExpiresAt,
 	})
 }
 
-// Logout handles logout requests
+// startSession creates a session and sends its cookie
+func (h *AuthHandler) startSession(c *gin.Context, userID int64) (*model.AdminSessionSecret, error) {
+	session, err := h.sessionService.Create(userID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+
+	h.setCookie(c, legacyAuthCookie, "", -1)
+	h.setCookie(c, model.SessionCookieName, session.Token, int(h.sessionService.MaxAge().Seconds()))
+	return session, nil
+}
+
+// Providers tells the login page which sign-in methods are available
+func (h *AuthHandler) Providers(c *gin.Context) {
+	response := gin.H{"oidc": h.oidcService.Enabled()}
+	if h.oidcService.Enabled() {
+		response["oidc_name"] = h.cfg.OIDCDisplayName
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// OIDCLogin sends the browser to the identity provider to sign in
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.oidcService.Begin(c.Request.Context(), localRedirect(c.Query("redirect")))
+	if err != nil {
+		logger.Error("Failed to start OpenID Connect sign-in: %v", err)
+		c.Redirect(http.StatusFound, "/login?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	// The identity provider redirects back cross-site, so this cookie must be sent on top-level navigations
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, 600, "/api/auth/oidc", "", h.secureCookie(c), true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes a sign-in the identity provider redirected back with, then sends the
+// browser on to the page it started from. Failures go back to the login page.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	fail := func(message string) {
+		c.Redirect(http.StatusFound, "/login?error="+url.QueryEscape(message))
+	}
+
+	state := c.Query("state")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", h.secureCookie(c), true)
+	if cookie, err := c.Cookie(oidcStateCookie); err != nil || state == "" || cookie != state {
+		fail("sign-in was not started in this browser, please try again")
+		return
+	}
+	if errorCode := c.Query("error"); errorCode != "" {
+		fail(strings.TrimSpace(errorCode + " " + c.Query("error_description")))
+		return
+	}
+
+	user, redirect, err := h.oidcService.Complete(c.Request.Context(), state, c.Query("code"))
+	if err != nil {
+		logger.Error("OpenID Connect sign-in failed: %v", err)
+		fail(err.Error())
+		return
+	}
+
+	if _, err := h.startSession(c, user.ID); err != nil {
+		fail(err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// localRedirect only allows redirects to paths of the web UI itself
+func localRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+// Logout revokes the current session and clears its cookie. Like other state-changing requests
+// made with a session, it must carry the session's CSRF token.
 func (h *AuthHandler) Logout(c *gin.Context) {
-	c.SetCookie(
-		"auth_token",
-		"",
-		-1,
-		"/",
-		"",
-		false,
-		true,
-	)
+	if token, err := c.Cookie(model.SessionCookieName); err == nil {
+		session, err := h.sessionService.Authenticate(token)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if session != nil {
+			if !middleware.ValidCSRFToken(c, session) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+				return
+			}
+			if err := h.sessionService.Revoke(session.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	h.setCookie(c, legacyAuthCookie, "", -1)
+	h.setCookie(c, model.SessionCookieName, "", -1)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -86,9 +228,36 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	})
 }
 
-// Verify verifies the current authentication status
+// Verify verifies the current authentication status. Session-authenticated requests also get
+// the session's CSRF token, so the web UI can recover it after a reload.
 func (h *AuthHandler) Verify(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"authenticated": true,
-	})
+	response := gin.H{"authenticated": true, "role": middleware.Role(c)}
+	if session := middleware.AdminSession(c); session != nil {
+		response["username"] = session.Username
+		response["csrf_token"] = session.CSRFToken
+		response["expires_at"] = session.ExpiresAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// setCookie sets a web UI cookie with the configured Secure and SameSite attributes
+func (h *AuthHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	if h.cfg.SessionCookieSameSite == "lax" {
+		c.SetSameSite(http.SameSiteLaxMode)
+	} else {
+		c.SetSameSite(http.SameSiteStrictMode)
+	}
+	c.SetCookie(name, value, maxAge, "/", "", h.secureCookie(c), true)
+}
+
+// secureCookie reports whether cookies should be limited to HTTPS. In auto mode that is the
+// case when the request reached the gateway, or the reverse proxy in front of it, over HTTPS.
+func (h *AuthHandler) secureCookie(c *gin.Context) bool {
+	switch h.cfg.SessionCookieSecure {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
 }
@@ -5,9 +5,11 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/claude-api-gateway/backend/internal/api/middleware"
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/internal/proxy"
 	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
 )
 
 // ChannelHandler handles channel API requests
@@ -73,6 +75,38 @@ func (h *ChannelHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, channel)
 }
 
+// RevealKey returns the unmasked API key of a channel
+func (h *ChannelHandler) RevealKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	channel, err := h.channelService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+		return
+	}
+
+	logger.Info("API key of channel %d revealed to %s", id, requester(c))
+	respondKeyReveal(c, channel.APIKey)
+}
+
+// respondKeyReveal writes an unmasked API key, keeping it out of caches
+func respondKeyReveal(c *gin.Context, apiKey string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, model.ChannelKeyReveal{APIKey: apiKey})
+}
+
+// requester names who made a management request, for the server log
+func requester(c *gin.Context) string {
+	if session := middleware.AdminSession(c); session != nil && session.Username != "" {
+		return session.Username
+	}
+	return "the admin key"
+}
+
 // Update updates a channel
 func (h *ChannelHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -144,7 +178,7 @@ func (h *ChannelHandler) Deactivate(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "channel deactivated"})
 }
 
-// Test tests a channel connection
+// Test tests an unsaved channel connection
 func (h *ChannelHandler) Test(c *gin.Context) {
 	var req model.ChannelTestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -152,7 +186,8 @@ func (h *ChannelHandler) Test(c *gin.Context) {
 		return
 	}
 
-	if err := h.proxyService.TestChannel(req.BaseURL, req.APIKey); err != nil {
+	result, err := h.proxyService.TestChannel(&req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -160,8 +195,90 @@ func (h *ChannelHandler) Test(c *gin.Context) {
 		return
 	}
 
+	respondTestResult(c, result)
+}
+
+// TestByID tests a saved channel with its stored base URL and keys
+func (h *ChannelHandler) TestByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	// Body is optional: both provider and model have defaults
+	var req model.ChannelTestByIDRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.proxyService.TestChannelByID(id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	respondTestResult(c, result)
+}
+
+// respondTestResult writes a channel test result, using 400 when any probe failed
+func respondTestResult(c *gin.Context, result *model.ChannelTestResult) {
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, result)
+}
+
+// Health returns the health check history of a channel
+func (h *ChannelHandler) Health(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	health, err := h.channelService.GetHealth(id, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
+
+// Models lists the models served by a channel's upstream
+func (h *ChannelHandler) Models(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	if _, err := h.channelService.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+		return
+	}
+
+	models, err := h.proxyService.ListUpstreamModels(id)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Connection successful",
+		"data":  models,
+		"total": len(models),
 	})
 }
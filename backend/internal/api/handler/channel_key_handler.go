@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ChannelKeyHandler handles channel key pool API requests
+type ChannelKeyHandler struct {
+	keyService *service.ChannelKeyService
+}
+
+// NewChannelKeyHandler creates a new channel key handler
+func NewChannelKeyHandler() *ChannelKeyHandler {
+	return &ChannelKeyHandler{
+		keyService: service.NewChannelKeyService(),
+	}
+}
+
+// List returns all pooled keys of a channel
+func (h *ChannelKeyHandler) List(c *gin.Context) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	keys, err := h.keyService.ListByChannel(channelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  keys,
+		"total": len(keys),
+	})
+}
+
+// Create adds a key to a channel's pool
+func (h *ChannelKeyHandler) Create(c *gin.Context) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	var req model.ChannelKeyCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.keyService.Create(channelID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, _ := h.keyService.GetByID(id)
+	c.JSON(http.StatusCreated, key)
+}
+
+// Update updates a pooled key
+func (h *ChannelKeyHandler) Update(c *gin.Context) {
+	key, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	var req model.ChannelKeyUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.keyService.Update(key.ID, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, _ = h.keyService.GetByID(key.ID)
+	c.JSON(http.StatusOK, key)
+}
+
+// RevealKey returns the unmasked API key of a pooled key
+func (h *ChannelKeyHandler) RevealKey(c *gin.Context) {
+	key, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	logger.Info("API key of channel key %d (channel %d) revealed to %s", key.ID, key.ChannelID, requester(c))
+	respondKeyReveal(c, key.APIKey)
+}
+
+// Delete removes a key from a channel's pool
+func (h *ChannelKeyHandler) Delete(c *gin.Context) {
+	key, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	if err := h.keyService.Delete(key.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "channel key deleted"})
+}
+
+// Stats returns per-key usage statistics of a channel
+func (h *ChannelKeyHandler) Stats(c *gin.Context) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	var filter model.StatsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.keyService.GetStats(channelID, &filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// lookup resolves the :keyId path parameter and checks it belongs to the :id channel
+func (h *ChannelKeyHandler) lookup(c *gin.Context) (*model.ChannelKey, bool) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return nil, false
+	}
+	keyID, err := strconv.ParseInt(c.Param("keyId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid key id"})
+		return nil, false
+	}
+
+	key, err := h.keyService.GetByID(keyID)
+	if err != nil || key.ChannelID != channelID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel key not found"})
+		return nil, false
+	}
+	return key, true
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKeyHandler handles client API key management requests
+type ClientKeyHandler struct {
+	keyService *service.ClientKeyService
+}
+
+// NewClientKeyHandler creates a new client key handler
+func NewClientKeyHandler() *ClientKeyHandler {
+	return &ClientKeyHandler{
+		keyService: service.NewClientKeyService(),
+	}
+}
+
+// List returns all client keys
+func (h *ClientKeyHandler) List(c *gin.Context) {
+	keys, err := h.keyService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  keys,
+		"total": len(keys),
+	})
+}
+
+// Create issues a client key, revealing its plaintext once
+func (h *ClientKeyHandler) Create(c *gin.Context) {
+	var req model.ClientKeyCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.keyService.Create(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// Get returns a client key by ID
+func (h *ClientKeyHandler) Get(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.keyService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Update updates a client key
+func (h *ClientKeyHandler) Update(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	var req model.ClientKeyUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.keyService.Update(id, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, _ := h.keyService.GetByID(id)
+	c.JSON(http.StatusOK, key)
+}
+
+// Usage returns the consumption of a client key against its quotas
+func (h *ClientKeyHandler) Usage(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.keyService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	usage, err := h.keyService.GetUsage(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// Rotate replaces a client key, revealing the new plaintext once
+func (h *ClientKeyHandler) Rotate(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.keyService.Rotate(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Revoke permanently disables a client key
+func (h *ClientKeyHandler) Revoke(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.keyService.Revoke(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, _ := h.keyService.GetByID(id)
+	c.JSON(http.StatusOK, key)
+}
+
+// Delete deletes a client key
+func (h *ClientKeyHandler) Delete(c *gin.Context) {
+	id, ok := parseClientKeyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.keyService.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "client key deleted"})
+}
+
+// Denials returns the proxy requests that were denied, for auditing
+func (h *ClientKeyHandler) Denials(c *gin.Context) {
+	var filter model.DeniedRequestFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	denials, total, err := h.keyService.ListDenials(&filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     denials,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// parseClientKeyID resolves the :id path parameter
+func parseClientKeyID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client key id"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+)
+
+// FallbackHandler handles model fallback chain API requests
+type FallbackHandler struct {
+	fallbackService *service.FallbackService
+}
+
+// NewFallbackHandler creates a new fallback handler
+func NewFallbackHandler() *FallbackHandler {
+	return &FallbackHandler{
+		fallbackService: service.NewFallbackService(),
+	}
+}
+
+// Create creates a new fallback chain
+func (h *FallbackHandler) Create(c *gin.Context) {
+	var req model.ModelFallbackCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.fallbackService.Create(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fallback, _ := h.fallbackService.GetByID(id)
+	c.JSON(http.StatusCreated, fallback)
+}
+
+// List returns all fallback chains
+func (h *FallbackHandler) List(c *gin.Context) {
+	fallbacks, err := h.fallbackService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  fallbacks,
+		"total": len(fallbacks),
+	})
+}
+
+// Get returns a single fallback chain by ID
+func (h *FallbackHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fallback id"})
+		return
+	}
+
+	fallback, err := h.fallbackService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fallback not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fallback)
+}
+
+// Update updates a fallback chain
+func (h *FallbackHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fallback id"})
+		return
+	}
+
+	var req model.ModelFallbackUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.fallbackService.Update(id, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fallback, _ := h.fallbackService.GetByID(id)
+	c.JSON(http.StatusOK, fallback)
+}
+
+// Delete deletes a fallback chain
+func (h *FallbackHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fallback id"})
+		return
+	}
+
+	if err := h.fallbackService.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "fallback deleted"})
+}
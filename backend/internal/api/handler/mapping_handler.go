@@ -128,3 +128,26 @@ func (h *MappingHandler) ListByChannel(c *gin.Context) {
 		"total": len(mappings),
 	})
 }
+
+// Import bulk-creates mappings for a channel from selected upstream models
+func (h *MappingHandler) Import(c *gin.Context) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	var req model.MappingImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.mappingService.Import(channelID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
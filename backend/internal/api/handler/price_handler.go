@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PriceHandler handles model price API requests
+type PriceHandler struct {
+	priceService *service.PriceService
+}
+
+// NewPriceHandler creates a new price handler
+func NewPriceHandler() *PriceHandler {
+	return &PriceHandler{
+		priceService: service.NewPriceService(),
+	}
+}
+
+// Create creates a new model price
+func (h *PriceHandler) Create(c *gin.Context) {
+	var req model.ModelPriceCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.priceService.Create(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, _ := h.priceService.GetByID(id)
+	c.JSON(http.StatusCreated, price)
+}
+
+// List returns all model prices
+func (h *PriceHandler) List(c *gin.Context) {
+	prices, err := h.priceService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  prices,
+		"total": len(prices),
+	})
+}
+
+// Get returns a single model price by ID
+func (h *PriceHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid model price id"})
+		return
+	}
+
+	price, err := h.priceService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "model price not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// Update updates a model price
+func (h *PriceHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid model price id"})
+		return
+	}
+
+	var req model.ModelPriceUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.priceService.Update(id, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, _ := h.priceService.GetByID(id)
+	c.JSON(http.StatusOK, price)
+}
+
+// Delete deletes a model price
+func (h *PriceHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid model price id"})
+		return
+	}
+
+	if err := h.priceService.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "model price deleted"})
+}
@@ -1,34 +1,55 @@
 package handler
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/claude-api-gateway/backend/internal/config"
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/internal/proxy"
 	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+	"github.com/claude-api-gateway/backend/pkg/secret"
 )
 
 // ProxyHandler handles proxy API requests
 type ProxyHandler struct {
-	proxyService   *proxy.ProxyService
-	apiKey         string
-	mappingService *service.MappingService
+	proxyService     *proxy.ProxyService
+	apiKey           string
+	sessionHeader    string
+	mappingService   *service.MappingService
+	channelService   *service.ChannelService
+	clientKeyService *service.ClientKeyService
 }
 
 // NewProxyHandler creates a new proxy handler
-func NewProxyHandler(apiKey string) *ProxyHandler {
+func NewProxyHandler(cfg *config.Config) *ProxyHandler {
+	proxyService := proxy.NewProxyService()
+	proxyService.EnableSessionAffinity(time.Duration(cfg.StickySessionTTL) * time.Second)
+	proxyService.EnableResponseCache(time.Duration(cfg.ResponseCacheTTL)*time.Second, cfg.ResponseCacheSize)
+	proxyService.EnableGatewayMessageIDs(cfg.GatewayMessageIDs)
+
 	return &ProxyHandler{
-		proxyService:   proxy.NewProxyService(),
-		apiKey:         apiKey,
-		mappingService: service.NewMappingService(),
+		proxyService:     proxyService,
+		apiKey:           cfg.APIKey,
+		sessionHeader:    cfg.StickySessionHeader,
+		mappingService:   service.NewMappingService(),
+		channelService:   service.NewChannelService(),
+		clientKeyService: service.NewClientKeyService(),
 	}
 }
 
-// validateAPIKey validates the API key from request
-func (h *ProxyHandler) validateAPIKey(c *gin.Context) (string, bool) {
+// errInvalidAPIKey rejects a request whose key is neither an issued client key nor the shared API_KEY
+var errInvalidAPIKey = &model.AccessDeniedError{Reason: "Invalid API key", Unauthenticated: true}
+
+// validateAPIKey validates the API key from request and returns the client key it belongs to.
+// Issued client keys are checked first; the shared API_KEY belongs to no client key.
+// Denied requests are recorded for auditing.
+func (h *ProxyHandler) validateAPIKey(c *gin.Context) (string, *model.ClientKey, error) {
 	// Get API key from header
 	apiKey := c.GetHeader("x-api-key")
 	if apiKey == "" {
@@ -39,38 +60,87 @@ func (h *ProxyHandler) validateAPIKey(c *gin.Context) (string, bool) {
 		}
 	}
 
-	// Try cookie (for web UI)
-	if apiKey == "" {
-		if cookie, err := c.Cookie("auth_token"); err == nil {
-			apiKey = cookie
+	if apiKey != "" {
+		clientKey, err := h.clientKeyService.Authenticate(apiKey)
+		var denied *model.AccessDeniedError
+		if errors.As(err, &denied) {
+			return "", nil, h.deny(c, clientKey, apiKey, err)
+		}
+		if err == nil && clientKey != nil {
+			if err := h.clientKeyService.CheckIP(clientKey, c.ClientIP()); err != nil {
+				return "", nil, h.deny(c, clientKey, apiKey, err)
+			}
+			return apiKey, clientKey, nil
 		}
 	}
 
-	// If no API key configured, allow access
+	// If no API key configured and no client keys issued, allow access
 	if h.apiKey == "" {
-		return apiKey, true
+		if hasKeys, err := h.clientKeyService.HasActive(); err != nil || hasKeys {
+			return "", nil, h.deny(c, nil, apiKey, errInvalidAPIKey)
+		}
+		return apiKey, nil, nil
 	}
 
 	// Validate API key
 	if apiKey != h.apiKey {
-		return "", false
+		return "", nil, h.deny(c, nil, apiKey, errInvalidAPIKey)
+	}
+
+	return apiKey, nil, nil
+}
+
+// deny records a request refused with an AccessDeniedError and returns the error.
+// Other errors are returned as they are.
+func (h *ProxyHandler) deny(c *gin.Context, clientKey *model.ClientKey, apiKey string, err error) error {
+	var denied *model.AccessDeniedError
+	if !errors.As(err, &denied) {
+		return err
+	}
+
+	record := &model.DeniedRequest{
+		ClientKeyID: clientKeyID(clientKey),
+		KeyHint:     secret.Mask(apiKey),
+		IPAddress:   c.ClientIP(),
+		Path:        c.Request.URL.Path,
+		Reason:      denied.Reason,
 	}
+	if recordErr := h.clientKeyService.RecordDenial(record); recordErr != nil {
+		logger.Error("Failed to record denied request: %v", recordErr)
+	}
+	return err
+}
+
+// clientKeyID returns the ID of a client key for logging, 0 for the shared API key
+func clientKeyID(clientKey *model.ClientKey) int64 {
+	if clientKey == nil {
+		return 0
+	}
+	return clientKey.ID
+}
 
-	return apiKey, true
+// clientKeyAccess returns the model and channel group allowlists of a client key,
+// which are empty for the shared API key
+func clientKeyAccess(clientKey *model.ClientKey) ([]string, []string) {
+	if clientKey == nil {
+		return nil, nil
+	}
+	return clientKey.AllowedModels, clientKey.AllowedChannelGroups
 }
 
 // ProxyMessage handles the /v1/messages endpoint
 func (h *ProxyHandler) ProxyMessage(c *gin.Context) {
 	// Validate API key
-	apiKey, valid := h.validateAPIKey(c)
-	if !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"type": "error",
-			"error": gin.H{
-				"type":    "authentication_error",
-				"message": "Invalid API key",
-			},
-		})
+	apiKey, clientKey, err := h.validateAPIKey(c)
+	if err != nil {
+		writeAnthropicError(c, err)
+		return
+	}
+
+	// Reject keys that have used up a quota before forwarding anything
+	quota, err := h.clientKeyService.CheckQuota(clientKey)
+	if err != nil {
+		writeAnthropicError(c, err)
 		return
 	}
 
@@ -86,6 +156,22 @@ func (h *ProxyHandler) ProxyMessage(c *gin.Context) {
 		})
 		return
 	}
+	req.NoCache = c.GetHeader(model.ResponseCacheHeader) == model.ResponseCacheBypass
+	req.ClientKeyID = clientKeyID(clientKey)
+	req.Quota = quota
+	req.AllowedModels, req.ChannelGroups = clientKeyAccess(clientKey)
+	if err := proxy.CheckModelAccess(req.AllowedModels, req.Model); err != nil {
+		writeAnthropicError(c, h.deny(c, clientKey, apiKey, err))
+		return
+	}
+
+	// Hold a slot of the client key's rate limits until the response is complete
+	release, err := h.proxyService.AcquireRateLimit(clientKey, c.Writer.Header())
+	if err != nil {
+		writeAnthropicError(c, err)
+		return
+	}
+	defer release()
 
 	// Get client IP
 	ipAddress := c.ClientIP()
@@ -94,38 +180,16 @@ func (h *ProxyHandler) ProxyMessage(c *gin.Context) {
 	if req.Stream {
 		// Note: Headers are now set inside ProxyMessageStream after upstream validation
 		// This allows proper error response before streaming starts
-		if err := h.proxyService.ProxyMessageStream(&req, apiKey, ipAddress, c.Writer); err != nil {
-			// Only send JSON error if headers haven't been written yet
-			if !c.Writer.Written() {
-				c.JSON(http.StatusBadGateway, gin.H{
-					"type": "error",
-					"error": gin.H{
-						"type":    "api_error",
-						"message": err.Error(),
-					},
-				})
-			} else {
-				// Headers already sent, write error as SSE event
-				errorEvent := fmt.Sprintf("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"api_error\",\"message\":\"%s\"}}\n\n", err.Error())
-				c.Writer.Write([]byte(errorEvent))
-				if flusher, ok := c.Writer.(http.Flusher); ok {
-					flusher.Flush()
-				}
-			}
+		if err := h.proxyService.ProxyMessageStream(&req, apiKey, ipAddress, c.GetHeader(h.sessionHeader), c.Writer); err != nil {
+			writeAnthropicError(c, h.deny(c, clientKey, apiKey, err))
 		}
 		return
 	}
 
 	// Handle non-streaming request
-	resp, err := h.proxyService.ProxyMessage(&req, apiKey, ipAddress)
+	resp, err := h.proxyService.ProxyMessage(&req, apiKey, ipAddress, c.GetHeader(h.sessionHeader), c.Writer.Header())
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{
-			"type": "error",
-			"error": gin.H{
-				"type":    "api_error",
-				"message": err.Error(),
-			},
-		})
+		writeAnthropicError(c, h.deny(c, clientKey, apiKey, err))
 		return
 	}
 
@@ -135,15 +199,16 @@ func (h *ProxyHandler) ProxyMessage(c *gin.Context) {
 // StreamHandler handles SSE streaming
 func (h *ProxyHandler) StreamHandler(c *gin.Context) {
 	// Validate API key
-	apiKey, valid := h.validateAPIKey(c)
-	if !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"type": "error",
-			"error": gin.H{
-				"type":    "authentication_error",
-				"message": "Invalid API key",
-			},
-		})
+	apiKey, clientKey, err := h.validateAPIKey(c)
+	if err != nil {
+		writeAnthropicError(c, err)
+		return
+	}
+
+	// Reject keys that have used up a quota before forwarding anything
+	quota, err := h.clientKeyService.CheckQuota(clientKey)
+	if err != nil {
+		writeAnthropicError(c, err)
 		return
 	}
 
@@ -154,10 +219,26 @@ func (h *ProxyHandler) StreamHandler(c *gin.Context) {
 	}
 
 	req.Stream = true
+	req.NoCache = c.GetHeader(model.ResponseCacheHeader) == model.ResponseCacheBypass
+	req.ClientKeyID = clientKeyID(clientKey)
+	req.Quota = quota
+	req.AllowedModels, req.ChannelGroups = clientKeyAccess(clientKey)
+	if err := proxy.CheckModelAccess(req.AllowedModels, req.Model); err != nil {
+		writeAnthropicError(c, h.deny(c, clientKey, apiKey, err))
+		return
+	}
+
+	// Hold a slot of the client key's rate limits until the response is complete
+	release, err := h.proxyService.AcquireRateLimit(clientKey, c.Writer.Header())
+	if err != nil {
+		writeAnthropicError(c, err)
+		return
+	}
+	defer release()
 	ipAddress := c.ClientIP()
 
-	if err := h.proxyService.ProxyMessageStream(&req, apiKey, ipAddress, c.Writer); err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	if err := h.proxyService.ProxyMessageStream(&req, apiKey, ipAddress, c.GetHeader(h.sessionHeader), c.Writer); err != nil {
+		writeAnthropicError(c, h.deny(c, clientKey, apiKey, err))
 	}
 }
 
@@ -188,14 +269,16 @@ func (h *ProxyHandler) ProxyGet(c *gin.Context) {
 // ProxyChatCompletions handles the /v1/chat/completions endpoint (OpenAI compatible)
 func (h *ProxyHandler) ProxyChatCompletions(c *gin.Context) {
 	// Validate API key
-	apiKey, valid := h.validateAPIKey(c)
-	if !valid {
-		c.JSON(http.StatusUnauthorized, model.OpenAIErrorResponse{
-			Error: model.OpenAIErrorDetail{
-				Type:    "invalid_request_error",
-				Message: "Incorrect API key provided",
-			},
-		})
+	apiKey, clientKey, err := h.validateAPIKey(c)
+	if err != nil {
+		writeOpenAIError(c, err)
+		return
+	}
+
+	// Reject keys that have used up a quota before forwarding anything
+	quota, err := h.clientKeyService.CheckQuota(clientKey)
+	if err != nil {
+		writeOpenAIError(c, err)
 		return
 	}
 
@@ -210,6 +293,22 @@ func (h *ProxyHandler) ProxyChatCompletions(c *gin.Context) {
 		})
 		return
 	}
+	req.NoCache = c.GetHeader(model.ResponseCacheHeader) == model.ResponseCacheBypass
+	req.ClientKeyID = clientKeyID(clientKey)
+	req.Quota = quota
+	req.AllowedModels, req.ChannelGroups = clientKeyAccess(clientKey)
+	if err := proxy.CheckModelAccess(req.AllowedModels, req.Model); err != nil {
+		writeOpenAIError(c, h.deny(c, clientKey, apiKey, err))
+		return
+	}
+
+	// Hold a slot of the client key's rate limits until the response is complete
+	release, err := h.proxyService.AcquireRateLimit(clientKey, c.Writer.Header())
+	if err != nil {
+		writeOpenAIError(c, err)
+		return
+	}
+	defer release()
 
 	// Get client IP
 	ipAddress := c.ClientIP()
@@ -218,55 +317,96 @@ func (h *ProxyHandler) ProxyChatCompletions(c *gin.Context) {
 	if req.Stream {
 		// Note: Headers are now set inside ProxyChatStream after upstream validation
 		// This allows proper error response before streaming starts
-		if err := h.proxyService.ProxyChatStream(&req, apiKey, ipAddress, c.Writer); err != nil {
-			// Only send JSON error if headers haven't been written yet
-			if !c.Writer.Written() {
-				c.JSON(http.StatusBadGateway, model.OpenAIErrorResponse{
-					Error: model.OpenAIErrorDetail{
-						Type:    "api_error",
-						Message: err.Error(),
-					},
-				})
-			} else {
-				// Headers already sent, write error as SSE event
-				errorEvent := fmt.Sprintf("data: {\"error\":{\"type\":\"api_error\",\"message\":\"%s\"}}\n\n", err.Error())
-				c.Writer.Write([]byte(errorEvent))
-				if flusher, ok := c.Writer.(http.Flusher); ok {
-					flusher.Flush()
-				}
-			}
+		if err := h.proxyService.ProxyChatStream(&req, apiKey, ipAddress, c.GetHeader(h.sessionHeader), c.Writer); err != nil {
+			writeOpenAIError(c, h.deny(c, clientKey, apiKey, err))
 		}
 		return
 	}
 
 	// Handle non-streaming request
-	resp, err := h.proxyService.ProxyChat(&req, apiKey, ipAddress)
+	resp, err := h.proxyService.ProxyChat(&req, apiKey, ipAddress, c.GetHeader(h.sessionHeader), c.Writer.Header())
 	if err != nil {
-		c.JSON(http.StatusBadGateway, model.OpenAIErrorResponse{
-			Error: model.OpenAIErrorDetail{
-				Type:    "api_error",
-				Message: err.Error(),
-			},
-		})
+		writeOpenAIError(c, h.deny(c, clientKey, apiKey, err))
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// writeAnthropicError reports a proxy failure in the Anthropic error format, as an SSE
+// error event when the response has already started streaming
+func writeAnthropicError(c *gin.Context, err error) {
+	status, body := proxy.AnthropicError(err)
+	if c.Writer.Written() {
+		data, _ := json.Marshal(body)
+		writeStreamError(c, "event: error\ndata: "+string(data)+"\n\n")
+		return
+	}
+
+	if retryAfter := proxy.RetryAfter(err); retryAfter != "" {
+		c.Header("Retry-After", retryAfter)
+	}
+	c.JSON(status, body)
+}
+
+// writeOpenAIError reports a proxy failure in the OpenAI error format, as an SSE
+// data chunk when the response has already started streaming
+func writeOpenAIError(c *gin.Context, err error) {
+	status, body := proxy.OpenAIError(err)
+	if c.Writer.Written() {
+		data, _ := json.Marshal(body)
+		writeStreamError(c, "data: "+string(data)+"\n\n")
+		return
+	}
+
+	if retryAfter := proxy.RetryAfter(err); retryAfter != "" {
+		c.Header("Retry-After", retryAfter)
+	}
+	c.JSON(status, body)
+}
+
+// writeStreamError writes an error event into a response that is already streaming
+func writeStreamError(c *gin.Context, event string) {
+	c.Writer.Write([]byte(event))
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // ListModels handles GET /v1/models endpoint
-// Returns all enabled display models from the database mappings
+// Returns the enabled display models from the database mappings that the client key may use
 func (h *ProxyHandler) ListModels(c *gin.Context) {
+	_, clientKey, err := h.validateAPIKey(c)
+	if err != nil {
+		writeOpenAIError(c, err)
+		return
+	}
+	allowedModels, channelGroups := clientKeyAccess(clientKey)
+
 	mappings, err := h.mappingService.List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch models"})
 		return
 	}
 
-	// Use a map to deduplicate display_model names
+	channels, err := h.channelService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch models"})
+		return
+	}
+	channelGroup := make(map[int64]string, len(channels))
+	for _, ch := range channels {
+		channelGroup[ch.ID] = ch.Group
+	}
+
+	// Use a map to deduplicate display_model names; pattern mappings are not concrete models
 	modelMap := make(map[string]bool)
 	for _, m := range mappings {
-		if m.IsEnabled {
+		if !m.IsEnabled || m.MatchType != model.MatchTypeExact {
+			continue
+		}
+		group, ok := channelGroup[m.ChannelID]
+		if ok && proxy.GroupAllowed(channelGroups, group) && proxy.ModelAllowed(allowedModels, m.DisplayModel) {
 			modelMap[m.DisplayModel] = true
 		}
 	}
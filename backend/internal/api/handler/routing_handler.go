@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+)
+
+// RoutingHandler handles routing rule API requests
+type RoutingHandler struct {
+	routingService *service.RoutingService
+}
+
+// NewRoutingHandler creates a new routing handler
+func NewRoutingHandler() *RoutingHandler {
+	return &RoutingHandler{
+		routingService: service.NewRoutingService(),
+	}
+}
+
+// Create creates a new routing rule
+func (h *RoutingHandler) Create(c *gin.Context) {
+	var req model.RoutingRuleCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.routingService.Create(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, _ := h.routingService.GetByID(id)
+	c.JSON(http.StatusCreated, rule)
+}
+
+// List returns all routing rules
+func (h *RoutingHandler) List(c *gin.Context) {
+	rules, err := h.routingService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  rules,
+		"total": len(rules),
+	})
+}
+
+// Get returns a single routing rule by ID
+func (h *RoutingHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid routing rule id"})
+		return
+	}
+
+	rule, err := h.routingService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "routing rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// Update updates a routing rule
+func (h *RoutingHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid routing rule id"})
+		return
+	}
+
+	var req model.RoutingRuleUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.routingService.Update(id, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, _ := h.routingService.GetByID(id)
+	c.JSON(http.StatusOK, rule)
+}
+
+// Delete deletes a routing rule
+func (h *RoutingHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid routing rule id"})
+		return
+	}
+
+	if err := h.routingService.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "routing rule deleted"})
+}
@@ -115,6 +115,20 @@ func (h *StatsHandler) GetLogs(c *gin.Context) {
 	})
 }
 
+// GetRequestAttempts returns every channel attempt of one client request
+func (h *StatsHandler) GetRequestAttempts(c *gin.Context) {
+	logs, err := h.statsService.GetRequestAttempts(c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  logs,
+		"total": len(logs),
+	})
+}
+
 // Export exports logs to CSV
 func (h *StatsHandler) Export(c *gin.Context) {
 	var filter model.StatsFilter
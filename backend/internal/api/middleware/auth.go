@@ -1,46 +1,143 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates the API key for management endpoints
-func AuthMiddleware(apiKey string) gin.HandlerFunc {
+// Gin context keys of the admin session and role a request was authenticated with
+const (
+	adminSessionKey = "admin_session"
+	adminRoleKey    = "admin_role"
+)
+
+// AuthMiddleware authenticates management requests, either by the admin key in the x-api-key or
+// Authorization header or by a web UI session cookie of ADMIN_API_KEY or an admin user.
+// Cookie-authenticated requests that change state must also carry the session's CSRF token.
+// Proxy credentials, the shared API_KEY and issued client keys, are rejected. When open is set
+// the management API needs no authentication. The admin key and an open API have the admin role.
+func AuthMiddleware(adminKey, proxyKey string, open bool, sessions *service.AdminSessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip auth if no API key is configured
-		if apiKey == "" {
+		if open {
+			c.Set(adminRoleKey, model.RoleAdmin)
 			c.Next()
 			return
 		}
 
-		// Get API key from header
-		clientKey := c.GetHeader("x-api-key")
-		if clientKey == "" {
-			// Try Authorization header
-			auth := c.GetHeader("Authorization")
-			if strings.HasPrefix(auth, "Bearer ") {
-				clientKey = strings.TrimPrefix(auth, "Bearer ")
+		if key := headerKey(c); key != "" {
+			if ValidAdminKey(adminKey, key) {
+				c.Set(adminRoleKey, model.RoleAdmin)
+				c.Next()
+				return
 			}
-		}
-
-		// Try cookie (for web UI)
-		if clientKey == "" {
-			if cookie, err := c.Cookie("auth_token"); err == nil {
-				clientKey = cookie
+			if key == proxyKey || strings.HasPrefix(key, model.ClientKeyPrefix) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Proxy keys cannot access the management API",
+				})
+				c.Abort()
+				return
+			}
+		} else if token, err := c.Cookie(model.SessionCookieName); err == nil {
+			session, err := sessions.Authenticate(token)
+			if err != nil {
+				logger.Error("Failed to authenticate admin session: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			if session != nil {
+				if !safeMethod(c.Request.Method) && !ValidCSRFToken(c, session) {
+					c.JSON(http.StatusForbidden, gin.H{
+						"error": "Invalid CSRF token",
+					})
+					c.Abort()
+					return
+				}
+				c.Set(adminSessionKey, session)
+				c.Set(adminRoleKey, session.Role)
+				c.Next()
+				return
 			}
 		}
 
-		if clientKey != apiKey {
+		if adminKey == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Unauthorized",
+				"error": "Unauthorized: sign in with an admin user, ADMIN_API_KEY is not configured",
 			})
 			c.Abort()
 			return
 		}
 
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		c.Abort()
+	}
+}
+
+// RequireRole rejects management requests whose role is not allowed what the given role is.
+// It must run after AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.RoleAllows(Role(c), role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This action requires the " + role + " role",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// Role returns the admin role a management request was authenticated with
+func Role(c *gin.Context) string {
+	return c.GetString(adminRoleKey)
+}
+
+// AdminSession returns the web UI session a management request was authenticated with, or nil
+// if it used the admin key header
+func AdminSession(c *gin.Context) *model.AdminSession {
+	if value, ok := c.Get(adminSessionKey); ok {
+		return value.(*model.AdminSession)
+	}
+	return nil
+}
+
+// headerKey returns the key a request presents in its x-api-key or Authorization header
+func headerKey(c *gin.Context) string {
+	key := c.GetHeader("x-api-key")
+	if key == "" {
+		auth := c.GetHeader("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	return key
+}
+
+// safeMethod reports whether an HTTP method only reads, so needs no CSRF protection
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ValidCSRFToken reports whether a request carries the CSRF token of the session it was made with
+func ValidCSRFToken(c *gin.Context, session *model.AdminSession) bool {
+	return validToken(session.CSRFToken, c.GetHeader(model.CSRFHeader))
+}
+
+// ValidAdminKey compares a presented key with the admin key in constant time
+func ValidAdminKey(adminKey, key string) bool {
+	return adminKey != "" && validToken(adminKey, key)
+}
+
+// validToken compares a presented secret with the expected one in constant time
+func validToken(expected, presented string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(presented)) == 1
+}
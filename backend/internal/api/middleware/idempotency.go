@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader carries the client-chosen key that deduplicates retried requests
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks responses served from an earlier request with the same key
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentResponseSize bounds the response kept for one key. Larger responses, typically
+// long streams, are not stored: duplicates get a conflict instead of a replay.
+const maxIdempotentResponseSize = 1 << 20
+
+// idempotencySweepInterval is how often expired entries are dropped
+const idempotencySweepInterval = time.Minute
+
+// idempotencyEntry is the shared outcome of the first request sent with a key
+type idempotencyEntry struct {
+	fingerprint string
+	done        chan struct{}
+	status      int
+	header      http.Header
+	body        []byte
+	tooLarge    bool // completed, but the response exceeded maxIdempotentResponseSize
+	expiresAt   time.Time
+}
+
+// idempotencyStore tracks in-flight and completed requests by key in an LRU bounded
+// by entry count and by the total size of stored responses
+type idempotencyStore struct {
+	window     time.Duration
+	maxEntries int
+	maxBytes   int
+	mu         sync.Mutex
+	order      *list.List
+	entries    map[string]*list.Element
+	bytes      int
+	lastSweep  time.Time
+}
+
+// storedEntry is an LRU element: the key and its entry
+type storedEntry struct {
+	key   string
+	entry *idempotencyEntry
+}
+
+// begin returns the entry of a key and whether the caller owns it and must run the request
+func (s *idempotencyStore) begin(key, fingerprint string) (*idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > idempotencySweepInterval {
+		for elem := s.order.Back(); elem != nil; {
+			prev := elem.Prev()
+			if entry := elem.Value.(*storedEntry).entry; !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				s.remove(elem)
+			}
+			elem = prev
+		}
+		s.lastSweep = now
+	}
+
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*storedEntry).entry
+		if entry.expiresAt.IsZero() || now.Before(entry.expiresAt) {
+			s.order.MoveToFront(elem)
+			return entry, false
+		}
+		s.remove(elem)
+	}
+
+	entry := &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	s.entries[key] = s.order.PushFront(&storedEntry{key: key, entry: entry})
+	s.evict()
+	return entry, true
+}
+
+// finish publishes the outcome of a request to waiting duplicates. Only successful
+// responses are kept for the window, so a failed request can be retried under the same key.
+func (s *idempotencyStore) finish(key string, entry *idempotencyEntry, status int, header http.Header, body []byte, tooLarge bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.status = status
+	entry.header = header
+	entry.body = body
+	entry.tooLarge = tooLarge
+	close(entry.done)
+
+	// The entry may have been evicted while the request ran
+	elem, ok := s.entries[key]
+	if !ok || elem.Value.(*storedEntry).entry != entry {
+		return
+	}
+	if status < 200 || status >= 300 {
+		s.remove(elem)
+		return
+	}
+	entry.expiresAt = time.Now().Add(s.window)
+	s.bytes += len(entry.body)
+	s.evict()
+}
+
+// evict drops the least recently used entries until the store is within its limits. Callers hold mu.
+func (s *idempotencyStore) evict() {
+	for s.order.Len() > 1 && (s.order.Len() > s.maxEntries || s.bytes > s.maxBytes) {
+		s.remove(s.order.Back())
+	}
+}
+
+// remove drops an entry. Callers hold mu.
+func (s *idempotencyStore) remove(elem *list.Element) {
+	stored := s.order.Remove(elem).(*storedEntry)
+	delete(s.entries, stored.key)
+	if !stored.entry.expiresAt.IsZero() {
+		s.bytes -= len(stored.entry.body)
+	}
+}
+
+// responseRecorder copies what is written to the client so it can be replayed, up to
+// maxIdempotentResponseSize
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) record(data []byte) {
+	if w.tooLarge {
+		return
+	}
+	if w.body.Len()+len(data) > maxIdempotentResponseSize {
+		w.tooLarge = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(data)
+}
+
+// Idempotency deduplicates proxy requests that carry an Idempotency-Key header. A duplicate of an
+// in-flight request waits for and shares its result; a duplicate of a completed one gets the stored
+// response for the window. Reusing a key with a different body is rejected. At most maxEntries keys and
+// maxBytes of responses are kept, evicting the least recently used. A zero window disables it.
+func Idempotency(window time.Duration, maxEntries, maxBytes int) gin.HandlerFunc {
+	if window <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	store := &idempotencyStore{
+		window:     window,
+		maxEntries: max(maxEntries, 1),
+		maxBytes:   max(maxBytes, maxIdempotentResponseSize),
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		lastSweep:  time.Now(),
+	}
+
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			idempotencyError(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			idempotencyError(c, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the caller's credential and the endpoint
+		key := hashString(clientCredential(c) + "\x00" + c.FullPath() + "\x00" + idempotencyKey)
+		fingerprint := hashString(string(body))
+
+		entry, owner := store.begin(key, fingerprint)
+		if !owner {
+			if entry.fingerprint != fingerprint {
+				idempotencyError(c, http.StatusUnprocessableEntity, "Idempotency-Key has already been used with a different request body")
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+			if entry.tooLarge {
+				idempotencyError(c, http.StatusConflict, "The response to the original request with this Idempotency-Key is too large to replay")
+				return
+			}
+			for name, values := range entry.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Status(entry.status)
+			c.Writer.Write(entry.body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			// Release waiting duplicates even when the handler panics
+			if err := recover(); err != nil {
+				store.finish(key, entry, http.StatusInternalServerError, nil, []byte(`{"error":"Internal server error"}`), false)
+				panic(err)
+			}
+			store.finish(key, entry, recorder.Status(), recorder.Header().Clone(), recorder.body.Bytes(), recorder.tooLarge)
+		}()
+		c.Next()
+	}
+}
+
+// clientCredential returns the API key a request authenticates with
+func clientCredential(c *gin.Context) string {
+	if apiKey := c.GetHeader("x-api-key"); apiKey != "" {
+		return apiKey
+	}
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// idempotencyError rejects a request in the error format of the endpoint's API
+func idempotencyError(c *gin.Context, status int, message string) {
+	if strings.HasPrefix(c.FullPath(), "/v1/chat/") {
+		c.AbortWithStatusJSON(status, gin.H{
+			"error": gin.H{
+				"message": message,
+				"type":    "invalid_request_error",
+			},
+		})
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    "invalid_request_error",
+			"message": message,
+		},
+	})
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,16 +1,27 @@
 package router
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/claude-api-gateway/backend/internal/api/handler"
 	"github.com/claude-api-gateway/backend/internal/api/middleware"
 	"github.com/claude-api-gateway/backend/internal/config"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/service"
+	"github.com/claude-api-gateway/backend/pkg/logger"
 )
 
 // Setup configures all routes
 func Setup(cfg *config.Config) *gin.Engine {
 	r := gin.New()
 
+	// Only trusted reverse proxies may report the client IP that IP allowlists and logs use
+	if err := r.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		logger.Error("Invalid TRUSTED_PROXIES, trusting no proxy: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+
 	// Middleware
 	r.Use(middleware.Recovery())
 	if cfg.EnableCORS {
@@ -19,60 +30,152 @@ func Setup(cfg *config.Config) *gin.Engine {
 	r.Use(middleware.RequestLogger())
 
 	// Initialize handlers
-	proxyHandler := handler.NewProxyHandler(cfg.APIKey)
-	authHandler := handler.NewAuthHandler(cfg.APIKey)
+	proxyHandler := handler.NewProxyHandler(cfg)
+	sessionService := service.NewAdminSessionService(
+		cfg.AdminAPIKey,
+		time.Duration(cfg.SessionIdleTimeout)*time.Second,
+		time.Duration(cfg.SessionMaxAge)*time.Second,
+	)
+	authHandler := handler.NewAuthHandler(cfg, sessionService)
+	adminSessionHandler := handler.NewAdminSessionHandler(sessionService)
+	adminUserHandler := handler.NewAdminUserHandler()
+	accountHandler := handler.NewAccountHandler()
 	channelHandler := handler.NewChannelHandler()
+	channelKeyHandler := handler.NewChannelKeyHandler()
+	clientKeyHandler := handler.NewClientKeyHandler()
 	mappingHandler := handler.NewMappingHandler()
+	fallbackHandler := handler.NewFallbackHandler()
+	routingHandler := handler.NewRoutingHandler()
+	priceHandler := handler.NewPriceHandler()
 	statsHandler := handler.NewStatsHandler()
 
 	// Root and health
 	r.GET("/", proxyHandler.ProxyGet)
 	r.GET("/api/health", proxyHandler.HealthCheck)
 
+	// Management requests authenticate with the admin key, never with proxy credentials
+	adminAuth := middleware.AuthMiddleware(cfg.AdminAPIKey, cfg.APIKey, cfg.ManagementOpen(), sessionService)
+
 	// Auth API (no middleware required)
 	api := r.Group("/api")
 	{
 		api.POST("/auth/login", authHandler.Login)
+		api.GET("/auth/providers", authHandler.Providers)
+		api.GET("/auth/oidc/login", authHandler.OIDCLogin)
+		api.GET("/auth/oidc/callback", authHandler.OIDCCallback)
 		api.POST("/auth/logout", authHandler.Logout)
-		api.GET("/auth/verify", middleware.AuthMiddleware(cfg.APIKey), authHandler.Verify)
+		api.GET("/auth/verify", adminAuth, authHandler.Verify)
 	}
 
+	// Retried proxy requests with the same Idempotency-Key share one upstream call
+	idempotency := middleware.Idempotency(time.Duration(cfg.IdempotencyWindow)*time.Second, cfg.IdempotencyMaxEntries, cfg.IdempotencyMaxBytes)
+
 	// Anthropic API compatible endpoint
-	r.POST("/v1/messages", proxyHandler.ProxyMessage)
+	r.POST("/v1/messages", idempotency, proxyHandler.ProxyMessage)
 
 	// OpenAI API compatible endpoints
 	r.GET("/v1/models", proxyHandler.ListModels)
-	r.POST("/v1/chat/completions", proxyHandler.ProxyChatCompletions)
+	r.POST("/v1/chat/completions", idempotency, proxyHandler.ProxyChatCompletions)
+
+	// Management API (protected by auth middleware). Every route requires a role: viewers see
+	// stats, logs and routing configuration, operators also toggle channels and edit mappings,
+	// fallbacks, routing rules and prices, admins also manage credentials and accounts.
+	viewer := middleware.RequireRole(model.RoleViewer)
+	operator := middleware.RequireRole(model.RoleOperator)
+	admin := middleware.RequireRole(model.RoleAdmin)
 
-	// Management API (protected by auth middleware)
 	managementAPI := r.Group("/api")
-	managementAPI.Use(middleware.AuthMiddleware(cfg.APIKey))
+	managementAPI.Use(adminAuth)
 	{
 		// Channels
-		managementAPI.GET("/channels", channelHandler.List)
-		managementAPI.POST("/channels", channelHandler.Create)
-		managementAPI.GET("/channels/:id", channelHandler.Get)
-		managementAPI.PUT("/channels/:id", channelHandler.Update)
-		managementAPI.DELETE("/channels/:id", channelHandler.Delete)
-		managementAPI.PUT("/channels/:id/activate", channelHandler.Activate)
-		managementAPI.PUT("/channels/:id/deactivate", channelHandler.Deactivate)
-		managementAPI.POST("/channels/test", channelHandler.Test)
-		managementAPI.GET("/channels/:id/mappings", mappingHandler.ListByChannel)
+		managementAPI.GET("/channels", operator, channelHandler.List)
+		managementAPI.POST("/channels", admin, channelHandler.Create)
+		managementAPI.GET("/channels/:id", operator, channelHandler.Get)
+		managementAPI.PUT("/channels/:id", admin, channelHandler.Update)
+		managementAPI.DELETE("/channels/:id", admin, channelHandler.Delete)
+		managementAPI.POST("/channels/:id/reveal", admin, channelHandler.RevealKey)
+		managementAPI.PUT("/channels/:id/activate", operator, channelHandler.Activate)
+		managementAPI.PUT("/channels/:id/deactivate", operator, channelHandler.Deactivate)
+		managementAPI.POST("/channels/test", operator, channelHandler.Test)
+		managementAPI.POST("/channels/:id/test", operator, channelHandler.TestByID)
+		managementAPI.GET("/channels/:id/mappings", viewer, mappingHandler.ListByChannel)
+		managementAPI.POST("/channels/:id/mappings/import", operator, mappingHandler.Import)
+		managementAPI.GET("/channels/:id/models", operator, channelHandler.Models)
+		managementAPI.GET("/channels/:id/health", viewer, channelHandler.Health)
+
+		// Channel key pools
+		managementAPI.GET("/channels/:id/keys", admin, channelKeyHandler.List)
+		managementAPI.POST("/channels/:id/keys", admin, channelKeyHandler.Create)
+		managementAPI.GET("/channels/:id/keys/stats", operator, channelKeyHandler.Stats)
+		managementAPI.PUT("/channels/:id/keys/:keyId", admin, channelKeyHandler.Update)
+		managementAPI.DELETE("/channels/:id/keys/:keyId", admin, channelKeyHandler.Delete)
+		managementAPI.POST("/channels/:id/keys/:keyId/reveal", admin, channelKeyHandler.RevealKey)
+
+		// Client API keys
+		managementAPI.GET("/keys", admin, clientKeyHandler.List)
+		managementAPI.POST("/keys", admin, clientKeyHandler.Create)
+		managementAPI.GET("/keys/:id", admin, clientKeyHandler.Get)
+		managementAPI.PUT("/keys/:id", admin, clientKeyHandler.Update)
+		managementAPI.DELETE("/keys/:id", admin, clientKeyHandler.Delete)
+		managementAPI.POST("/keys/:id/rotate", admin, clientKeyHandler.Rotate)
+		managementAPI.POST("/keys/:id/revoke", admin, clientKeyHandler.Revoke)
+		managementAPI.GET("/keys/:id/usage", admin, clientKeyHandler.Usage)
+		managementAPI.GET("/denied-requests", admin, clientKeyHandler.Denials)
+
+		// Admin web UI sessions
+		managementAPI.GET("/sessions", admin, adminSessionHandler.List)
+		managementAPI.DELETE("/sessions/:id", admin, adminSessionHandler.Revoke)
+
+		// Admin users
+		managementAPI.GET("/admin-users", admin, adminUserHandler.List)
+		managementAPI.POST("/admin-users", admin, adminUserHandler.Create)
+		managementAPI.GET("/admin-users/:id", admin, adminUserHandler.Get)
+		managementAPI.PUT("/admin-users/:id", admin, adminUserHandler.Update)
+		managementAPI.DELETE("/admin-users/:id", admin, adminUserHandler.Delete)
+
+		// The signed-in admin user's own account
+		managementAPI.GET("/account", viewer, accountHandler.Get)
+		managementAPI.PUT("/account/password", viewer, accountHandler.ChangePassword)
+		managementAPI.POST("/account/totp", viewer, accountHandler.SetupTOTP)
+		managementAPI.POST("/account/totp/enable", viewer, accountHandler.EnableTOTP)
+		managementAPI.POST("/account/totp/disable", viewer, accountHandler.DisableTOTP)
 
 		// Model Mappings
-		managementAPI.GET("/mappings", mappingHandler.List)
-		managementAPI.POST("/mappings", mappingHandler.Create)
-		managementAPI.GET("/mappings/:id", mappingHandler.Get)
-		managementAPI.PUT("/mappings/:id", mappingHandler.Update)
-		managementAPI.DELETE("/mappings/:id", mappingHandler.Delete)
+		managementAPI.GET("/mappings", viewer, mappingHandler.List)
+		managementAPI.POST("/mappings", operator, mappingHandler.Create)
+		managementAPI.GET("/mappings/:id", viewer, mappingHandler.Get)
+		managementAPI.PUT("/mappings/:id", operator, mappingHandler.Update)
+		managementAPI.DELETE("/mappings/:id", operator, mappingHandler.Delete)
+
+		// Model Fallback Chains
+		managementAPI.GET("/fallbacks", viewer, fallbackHandler.List)
+		managementAPI.POST("/fallbacks", operator, fallbackHandler.Create)
+		managementAPI.GET("/fallbacks/:id", viewer, fallbackHandler.Get)
+		managementAPI.PUT("/fallbacks/:id", operator, fallbackHandler.Update)
+		managementAPI.DELETE("/fallbacks/:id", operator, fallbackHandler.Delete)
+
+		// Content-aware Routing Rules
+		managementAPI.GET("/routing-rules", viewer, routingHandler.List)
+		managementAPI.POST("/routing-rules", operator, routingHandler.Create)
+		managementAPI.GET("/routing-rules/:id", viewer, routingHandler.Get)
+		managementAPI.PUT("/routing-rules/:id", operator, routingHandler.Update)
+		managementAPI.DELETE("/routing-rules/:id", operator, routingHandler.Delete)
+
+		// Model Prices
+		managementAPI.GET("/prices", viewer, priceHandler.List)
+		managementAPI.POST("/prices", operator, priceHandler.Create)
+		managementAPI.GET("/prices/:id", viewer, priceHandler.Get)
+		managementAPI.PUT("/prices/:id", operator, priceHandler.Update)
+		managementAPI.DELETE("/prices/:id", operator, priceHandler.Delete)
 
 		// Statistics
-		managementAPI.GET("/stats", statsHandler.GetOverall)
-		managementAPI.GET("/stats/channels", statsHandler.GetChannelStats)
-		managementAPI.GET("/stats/daily", statsHandler.GetDailyStats)
-		managementAPI.GET("/stats/models", statsHandler.GetModelStats)
-		managementAPI.GET("/stats/logs", statsHandler.GetLogs)
-		managementAPI.GET("/stats/export", statsHandler.Export)
+		managementAPI.GET("/stats", viewer, statsHandler.GetOverall)
+		managementAPI.GET("/stats/channels", viewer, statsHandler.GetChannelStats)
+		managementAPI.GET("/stats/daily", viewer, statsHandler.GetDailyStats)
+		managementAPI.GET("/stats/models", viewer, statsHandler.GetModelStats)
+		managementAPI.GET("/stats/logs", viewer, statsHandler.GetLogs)
+		managementAPI.GET("/stats/requests/:request_id", viewer, statsHandler.GetRequestAttempts)
+		managementAPI.GET("/stats/export", viewer, statsHandler.Export)
 	}
 
 	return r
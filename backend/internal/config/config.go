@@ -3,16 +3,68 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config represents the application configuration
 type Config struct {
 	ServerPort     int
 	DataDir        string
-	APIKey         string
+	APIKey         string // shared key for the proxy endpoints
+	AdminAPIKey    string // key for the management API, never accepted by the proxy endpoints
+	AdminUsername  string // first admin user, created when there are no admin users yet
+	AdminPassword  string
 	Debug          bool
 	EnableCORS     bool
 	AllowedOrigins string
+
+	// Master key for encrypting upstream API keys at rest, 32 bytes as base64 or hex
+	EncryptionKey     string
+	EncryptionKeyFile string // file holding the master key, an alternative to EncryptionKey
+
+	// Reverse proxies whose X-Forwarded-For / X-Real-IP headers are trusted to give the client IP
+	TrustedProxies string // comma-separated IPs or CIDRs, "none" trusts no proxy
+
+	// Admin web UI sessions
+	SessionIdleTimeout    int    // seconds without a request after which a session expires
+	SessionMaxAge         int    // seconds a session lasts at most, however active
+	SessionCookieSecure   string // "auto" marks the cookie Secure on HTTPS requests, or "true" / "false"
+	SessionCookieSameSite string // "strict" or "lax"
+
+	// OpenID Connect sign-in to the web UI, enabled when an issuer is set
+	OIDCIssuer        string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string // the gateway's /api/auth/oidc/callback URL as registered with the provider
+	OIDCScopes        string // space-separated
+	OIDCUsernameClaim string
+	OIDCRoleClaim     string // claim holding a string or list of strings, such as groups
+	OIDCRoleMapping   string // comma-separated claim=role pairs, such as gateway-admins=admin
+	OIDCDefaultRole   string // role for users matching no mapping, empty denies them
+	OIDCDisplayName   string // name of the sign-in button
+
+	// Background channel health checks
+	HealthCheckInterval         int // seconds, 0 disables the checker
+	HealthCheckModel            string
+	HealthCheckAutoToggle       bool
+	HealthCheckFailureThreshold int
+	HealthCheckRetentionDays    int
+
+	// Sticky session routing
+	StickySessionTTL    int // seconds, 0 disables session affinity
+	StickySessionHeader string
+
+	// Response cache for deterministic requests
+	ResponseCacheTTL  int // seconds, 0 disables the cache
+	ResponseCacheSize int // entries kept in memory
+
+	// Response rewriting
+	GatewayMessageIDs bool // replace upstream message IDs with gateway-generated ones
+
+	// Idempotency-Key support
+	IdempotencyWindow     int // seconds a completed response is kept, 0 disables Idempotency-Key handling
+	IdempotencyMaxEntries int // keys kept in memory, least recently used are evicted
+	IdempotencyMaxBytes   int // total size of stored responses
 }
 
 // Load loads configuration from environment variables with defaults
@@ -21,10 +73,87 @@ func Load() *Config {
 		ServerPort:     getEnvInt("SERVER_PORT", 8080),
 		DataDir:        getEnv("DATA_DIR", "./data"),
 		APIKey:         getEnv("API_KEY", ""),
+		AdminAPIKey:    getEnv("ADMIN_API_KEY", ""),
+		AdminUsername:  getEnv("ADMIN_USERNAME", ""),
+		AdminPassword:  getEnv("ADMIN_PASSWORD", ""),
 		Debug:          getEnvBool("DEBUG", false),
 		EnableCORS:     getEnvBool("ENABLE_CORS", true),
 		AllowedOrigins: getEnv("ALLOWED_ORIGINS", "*"),
+
+		EncryptionKey:     getEnv("ENCRYPTION_KEY", ""),
+		EncryptionKeyFile: getEnv("ENCRYPTION_KEY_FILE", ""),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", "127.0.0.1,::1"),
+
+		SessionIdleTimeout:    getEnvInt("SESSION_IDLE_TIMEOUT", 3600),
+		SessionMaxAge:         getEnvInt("SESSION_MAX_AGE", 86400*7),
+		SessionCookieSecure:   getEnv("SESSION_COOKIE_SECURE", "auto"),
+		SessionCookieSameSite: getEnv("SESSION_COOKIE_SAMESITE", "strict"),
+
+		OIDCIssuer:        getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:      getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:        getEnv("OIDC_SCOPES", "openid profile email"),
+		OIDCUsernameClaim: getEnv("OIDC_USERNAME_CLAIM", "email"),
+		OIDCRoleClaim:     getEnv("OIDC_ROLE_CLAIM", "groups"),
+		OIDCRoleMapping:   getEnv("OIDC_ROLE_MAPPING", ""),
+		OIDCDefaultRole:   getEnv("OIDC_DEFAULT_ROLE", ""),
+		OIDCDisplayName:   getEnv("OIDC_DISPLAY_NAME", "SSO"),
+
+		HealthCheckInterval:         getEnvInt("HEALTH_CHECK_INTERVAL", 0),
+		HealthCheckModel:            getEnv("HEALTH_CHECK_MODEL", "claude-3-5-haiku-latest"),
+		HealthCheckAutoToggle:       getEnvBool("HEALTH_CHECK_AUTO_TOGGLE", false),
+		HealthCheckFailureThreshold: getEnvInt("HEALTH_CHECK_FAILURE_THRESHOLD", 3),
+		HealthCheckRetentionDays:    getEnvInt("HEALTH_CHECK_RETENTION_DAYS", 7),
+
+		StickySessionTTL:    getEnvInt("STICKY_SESSION_TTL", 0),
+		StickySessionHeader: getEnv("STICKY_SESSION_HEADER", "X-Session-ID"),
+
+		ResponseCacheTTL:  getEnvInt("RESPONSE_CACHE_TTL", 0),
+		ResponseCacheSize: getEnvInt("RESPONSE_CACHE_SIZE", 1000),
+
+		GatewayMessageIDs: getEnvBool("GATEWAY_MESSAGE_IDS", false),
+
+		IdempotencyWindow:     getEnvInt("IDEMPOTENCY_WINDOW", 600),
+		IdempotencyMaxEntries: getEnvInt("IDEMPOTENCY_MAX_ENTRIES", 10000),
+		IdempotencyMaxBytes:   getEnvInt("IDEMPOTENCY_MAX_BYTES", 64<<20),
+	}
+}
+
+// ManagementOpen reports whether the management API is left unauthenticated, which is only
+// the case when no admin key, first admin user, OpenID provider or proxy key is configured
+// (local development)
+func (c *Config) ManagementOpen() bool {
+	return c.AdminAPIKey == "" && c.AdminUsername == "" && !c.OIDCEnabled() && c.APIKey == ""
+}
+
+// OIDCEnabled reports whether OpenID Connect sign-in is configured
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuer != "" && c.OIDCClientID != ""
+}
+
+// OIDCRoles returns the configured mapping of role claim values to admin roles
+func (c *Config) OIDCRoles() map[string]string {
+	roles := make(map[string]string)
+	for _, pair := range strings.Split(c.OIDCRoleMapping, ",") {
+		value, role, ok := strings.Cut(pair, "=")
+		if value, role = strings.TrimSpace(value), strings.TrimSpace(role); ok && value != "" {
+			roles[value] = role
+		}
+	}
+	return roles
+}
+
+// TrustedProxyList returns the configured trusted proxies, nil when no proxy is trusted
+func (c *Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" && proxy != "none" {
+			proxies = append(proxies, proxy)
+		}
 	}
+	return proxies
 }
 
 func getEnv(key, defaultValue string) string {
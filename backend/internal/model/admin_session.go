@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// SessionCookieName is the cookie carrying the admin web UI's session token
+const SessionCookieName = "gateway_session"
+
+// CSRFHeader carries a session's CSRF token on state-changing requests authenticated by cookie
+const CSRFHeader = "X-CSRF-Token"
+
+// AdminSession is a signed-in admin web UI session. Only a hash of its token is stored.
+type AdminSession struct {
+	ID          int64      `json:"id"`
+	AdminUserID int64      `json:"admin_user_id"` // 0 for sessions signed in with ADMIN_API_KEY
+	Username    string     `json:"username"`
+	Role        string     `json:"role"`
+	CSRFToken   string     `json:"-"`
+	IPAddress   string     `json:"ip_address"`
+	UserAgent   string     `json:"user_agent"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	ExpiresAt   time.Time  `json:"expires_at"` // absolute expiry; idle sessions expire earlier
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	Current     bool       `json:"current"` // whether the listing request was made with this session
+
+	// KeyFingerprint ties an ADMIN_API_KEY session to the key it was signed in with
+	KeyFingerprint string `json:"-"`
+}
+
+// AdminSessionSecret is a newly created session with its token, which is only available here
+type AdminSessionSecret struct {
+	*AdminSession
+	Token string
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// Roles of admin users, each allowed everything the previous one is
+const (
+	RoleViewer   = "viewer"   // sees stats, logs and routing configuration
+	RoleOperator = "operator" // also toggles channels and edits mappings, fallbacks, routing rules and prices
+	RoleAdmin    = "admin"    // also manages channel credentials, client keys, sessions and admin users
+)
+
+// roleRanks orders the roles by what they are allowed
+var roleRanks = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidRole reports whether a role is one of the admin user roles
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows reports whether a role is allowed what the required role is
+func RoleAllows(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+// AdminUser is an account that signs in to the management API. Only a bcrypt hash of the
+// password is stored. Accounts created by OpenID Connect sign-in have no password and take
+// their role from the identity provider at each sign-in.
+type AdminUser struct {
+	ID           int64      `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`
+	IsEnabled    bool       `json:"is_enabled"`
+	TOTPSecret   string     `json:"-"` // set while enrolling, before TOTPEnabled
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	TOTPLastStep int64      `json:"-"`                      // last accepted TOTP step, so codes cannot be replayed
+	OIDCSubject  string     `json:"oidc_subject,omitempty"` // issuer and subject, for accounts of OpenID Connect users
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// AdminUserCreate represents the request to create an admin user
+type AdminUserCreate struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// AdminUserUpdate represents the request to update an admin user. ResetTOTP turns off the
+// user's two-factor authentication, for a user who lost their authenticator.
+type AdminUserUpdate struct {
+	Role      *string `json:"role"`
+	Password  *string `json:"password"`
+	IsEnabled *bool   `json:"is_enabled"`
+	ResetTOTP bool    `json:"reset_totp"`
+}
+
+// PasswordChange represents the request of a signed-in admin user to change their password
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// TOTPSetup is a new TOTP secret for a user to add to their authenticator app
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// TOTPCode represents a request carrying a TOTP code
+type TOTPCode struct {
+	Code string `json:"code" binding:"required"`
+}
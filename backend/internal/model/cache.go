@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ResponseCacheHeader lets a client opt a request out of the response cache
+const ResponseCacheHeader = "X-Gateway-Cache"
+
+// ResponseCacheBypass is the ResponseCacheHeader value that skips the response cache
+const ResponseCacheBypass = "bypass"
+
+// ResponseCacheEntry represents a cached upstream response body
+type ResponseCacheEntry struct {
+	CacheKey  string    `json:"cache_key"`
+	Body      []byte    `json:"body"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -4,47 +4,90 @@ import "time"
 
 // Channel represents an upstream API channel
 type Channel struct {
-	ID         int64      `json:"id"`
-	Name       string     `json:"name"`
-	BaseURL    string     `json:"base_url"`
-	APIKey     string     `json:"api_key"`
-	Provider   string     `json:"provider"`
-	IsActive   bool       `json:"is_active"`
-	Priority   int        `json:"priority"`
-	MaxRetries int        `json:"max_retries"`
-	Timeout    int        `json:"timeout"`
-	RateLimit  int        `json:"rate_limit"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	BaseURL      string    `json:"base_url"`
+	APIKey       string    `json:"-"`              // decrypted, only returned by the reveal endpoint
+	APIKeyMasked string    `json:"api_key_masked"` // display form such as sk-ant-...abcd
+	Provider     string    `json:"provider"`
+	KeyStrategy  string    `json:"key_strategy"`
+	Group        string    `json:"group"`
+	IsActive     bool      `json:"is_active"`
+	AutoDisabled bool      `json:"auto_disabled"`
+	Priority     int       `json:"priority"`
+	MaxRetries   int       `json:"max_retries"`
+	Timeout      int       `json:"timeout"`
+	RateLimit    int       `json:"rate_limit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // ChannelCreate represents the request to create a channel
 type ChannelCreate struct {
-	Name       string `json:"name" binding:"required"`
-	BaseURL    string `json:"base_url" binding:"required"`
-	APIKey     string `json:"api_key" binding:"required"`
-	Provider   string `json:"provider" binding:"required"`
-	Priority   int    `json:"priority"`
-	MaxRetries int    `json:"max_retries"`
-	Timeout    int    `json:"timeout"`
-	RateLimit  int    `json:"rate_limit"`
+	Name        string `json:"name" binding:"required"`
+	BaseURL     string `json:"base_url" binding:"required"`
+	APIKey      string `json:"api_key" binding:"required"`
+	Provider    string `json:"provider" binding:"required"`
+	KeyStrategy string `json:"key_strategy"`
+	Group       string `json:"group"`
+	Priority    int    `json:"priority"`
+	MaxRetries  int    `json:"max_retries"`
+	Timeout     int    `json:"timeout"`
+	RateLimit   int    `json:"rate_limit"`
 }
 
 // ChannelUpdate represents the request to update a channel
 type ChannelUpdate struct {
-	Name       *string `json:"name"`
-	BaseURL    *string `json:"base_url"`
-	APIKey     *string `json:"api_key"`
-	Provider   *string `json:"provider"`
-	IsActive   *bool   `json:"is_active"`
-	Priority   *int    `json:"priority"`
-	MaxRetries *int    `json:"max_retries"`
-	Timeout    *int    `json:"timeout"`
-	RateLimit  *int    `json:"rate_limit"`
+	Name        *string `json:"name"`
+	BaseURL     *string `json:"base_url"`
+	APIKey      *string `json:"api_key"`
+	Provider    *string `json:"provider"`
+	KeyStrategy *string `json:"key_strategy"`
+	Group       *string `json:"group"`
+	IsActive    *bool   `json:"is_active"`
+	Priority    *int    `json:"priority"`
+	MaxRetries  *int    `json:"max_retries"`
+	Timeout     *int    `json:"timeout"`
+	RateLimit   *int    `json:"rate_limit"`
+}
+
+// ChannelKeyReveal represents the unmasked API key of a channel or pooled key
+type ChannelKeyReveal struct {
+	APIKey string `json:"api_key"`
 }
 
 // ChannelTestRequest represents the request to test a channel
 type ChannelTestRequest struct {
-	BaseURL string `json:"base_url" binding:"required"`
-	APIKey  string `json:"api_key" binding:"required"`
+	BaseURL  string `json:"base_url" binding:"required"`
+	APIKey   string `json:"api_key" binding:"required"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// ChannelTestByIDRequest represents the request to test a saved channel
+type ChannelTestByIDRequest struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// ChannelTestResult represents the outcome of testing a channel
+type ChannelTestResult struct {
+	Success      bool                `json:"success"`
+	Provider     string              `json:"provider"`
+	Model        string              `json:"model"`
+	NonStreaming *ChannelProbeResult `json:"non_streaming"`
+	Streaming    *ChannelProbeResult `json:"streaming"`
+}
+
+// ChannelProbeResult represents a single probe request made while testing a channel
+type ChannelProbeResult struct {
+	Success       bool   `json:"success"`
+	StatusCode    int    `json:"status_code"`
+	LatencyMs     int    `json:"latency_ms"`
+	TTFTMs        int    `json:"ttft_ms"`
+	InputTokens   int    `json:"input_tokens"`
+	OutputTokens  int    `json:"output_tokens"`
+	Content       string `json:"content"`
+	Error         string `json:"error,omitempty"`
+	UpstreamError string `json:"upstream_error,omitempty"`
 }
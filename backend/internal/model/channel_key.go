@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Key selection strategies for channels with an API key pool
+const (
+	KeyStrategyRoundRobin = "round_robin"
+	KeyStrategyLeastUsed  = "least_used"
+)
+
+// ChannelKey represents an upstream API key in a channel's key pool
+type ChannelKey struct {
+	ID             int64      `json:"id"`
+	ChannelID      int64      `json:"channel_id"`
+	Name           string     `json:"name"`
+	APIKey         string     `json:"-"`
+	APIKeyMasked   string     `json:"api_key_masked"`
+	IsEnabled      bool       `json:"is_enabled"`
+	UsageCount     int64      `json:"usage_count"`
+	FailureCount   int64      `json:"failure_count"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	LastError      string     `json:"last_error"`
+	DisabledReason string     `json:"disabled_reason"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Tables that store upstream API keys, and the column they are stored in
+const (
+	StoredKeyTableChannels    = "channels"
+	StoredKeyTableChannelKeys = "channel_keys"
+	StoredKeyColumn           = "api_key"
+)
+
+// StoredAPIKey is an upstream API key as stored in the database, encrypted or not
+type StoredAPIKey struct {
+	Table string
+	ID    int64
+	Value string
+}
+
+// KeyEncryptionStatus counts stored upstream API keys by encryption state
+type KeyEncryptionStatus struct {
+	Plaintext  int // stored before encryption at rest was enabled
+	Encrypted  int
+	Unreadable int // encrypted, but not with the configured master key
+}
+
+// ChannelKeyCreate represents the request to add a key to a channel's pool
+type ChannelKeyCreate struct {
+	Name   string `json:"name"`
+	APIKey string `json:"api_key" binding:"required"`
+}
+
+// ChannelKeyUpdate represents the request to update a pooled key
+type ChannelKeyUpdate struct {
+	Name      *string `json:"name"`
+	APIKey    *string `json:"api_key"`
+	IsEnabled *bool   `json:"is_enabled"`
+}
+
+// ChannelKeyStats represents usage statistics for a pooled key
+type ChannelKeyStats struct {
+	ChannelKeyID    int64  `json:"channel_key_id"`
+	APIKeyMasked    string `json:"api_key_masked"`
+	TotalRequests   int64  `json:"total_requests"`
+	SuccessRequests int64  `json:"success_requests"`
+	FailedRequests  int64  `json:"failed_requests"`
+	InputTokens     int64  `json:"input_tokens"`
+	OutputTokens    int64  `json:"output_tokens"`
+	TotalTokens     int64  `json:"total_tokens"`
+}
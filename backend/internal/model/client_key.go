@@ -0,0 +1,278 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// ClientKeyPrefix starts every client API key the gateway issues
+const ClientKeyPrefix = "sk-gw-"
+
+// Quota periods of a client key. Periods follow the server's calendar; weeks start on Monday.
+const (
+	QuotaPeriodDaily    = "daily"
+	QuotaPeriodWeekly   = "weekly"
+	QuotaPeriodMonthly  = "monthly"
+	QuotaPeriodLifetime = "lifetime"
+)
+
+// ClientKey represents an API key issued to a gateway client. Only a hash of the key is stored.
+// Zero limits are unlimited; costs are estimated from model prices in USD. Empty allowlists
+// allow every display model and channel group.
+type ClientKey struct {
+	ID                   int64      `json:"id"`
+	Name                 string     `json:"name"`
+	Owner                string     `json:"owner"`
+	KeyHint              string     `json:"key_hint"`
+	IsEnabled            bool       `json:"is_enabled"`
+	DailyTokenLimit      int64      `json:"daily_token_limit"`
+	WeeklyTokenLimit     int64      `json:"weekly_token_limit"`
+	MonthlyTokenLimit    int64      `json:"monthly_token_limit"`
+	DailyCostLimit       float64    `json:"daily_cost_limit"`
+	WeeklyCostLimit      float64    `json:"weekly_cost_limit"`
+	MonthlyCostLimit     float64    `json:"monthly_cost_limit"`
+	CreditLimit          float64    `json:"credit_limit"`
+	RPMLimit             int64      `json:"rpm_limit"`
+	TPMLimit             int64      `json:"tpm_limit"`
+	ConcurrencyLimit     int64      `json:"concurrency_limit"`
+	AllowedModels        []string   `json:"allowed_models"`         // display model globs
+	AllowedChannelGroups []string   `json:"allowed_channel_groups"` // channel groups the key is pinned to
+	AllowedIPs           []string   `json:"allowed_ips"`            // client IPs or CIDRs
+	ExpiresAt            *time.Time `json:"expires_at"`
+	RevokedAt            *time.Time `json:"revoked_at"`
+	LastUsedAt           *time.Time `json:"last_used_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the key may authenticate requests
+func (k *ClientKey) IsActive() bool {
+	return k.InactiveReason() == ""
+}
+
+// InactiveReason explains why the key may not authenticate requests, or returns "" if it may
+func (k *ClientKey) InactiveReason() string {
+	switch {
+	case k.RevokedAt != nil:
+		return "client key has been revoked"
+	case !k.IsEnabled:
+		return "client key is disabled"
+	case k.ExpiresAt != nil && !time.Now().Before(*k.ExpiresAt):
+		return fmt.Sprintf("client key expired at %s", k.ExpiresAt.Format(time.RFC3339))
+	}
+	return ""
+}
+
+// AllowsIP reports whether a client IP matches one of the key's allowed IPs or CIDRs.
+// An empty allowlist allows every IP.
+func (k *ClientKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, allowed := range k.AllowedIPs {
+		if prefix, err := ParseIPPrefix(allowed); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIPPrefix parses an IP or CIDR of an IP allowlist; a single IP is a prefix of full length
+func ParseIPPrefix(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP or CIDR: %s", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// HasRateLimit reports whether any rate limit applies to the key
+func (k *ClientKey) HasRateLimit() bool {
+	return k.RPMLimit > 0 || k.TPMLimit > 0 || k.ConcurrencyLimit > 0
+}
+
+// HasQuota reports whether any quota limits the key
+func (k *ClientKey) HasQuota() bool {
+	return k.DailyTokenLimit > 0 || k.WeeklyTokenLimit > 0 || k.MonthlyTokenLimit > 0 ||
+		k.DailyCostLimit > 0 || k.WeeklyCostLimit > 0 || k.MonthlyCostLimit > 0 || k.CreditLimit > 0
+}
+
+// ClientKeySecret is a client key together with its plaintext, returned only when the key is created or rotated
+type ClientKeySecret struct {
+	*ClientKey
+	Key string `json:"key"`
+}
+
+// ClientKeyCreate represents the request to issue a client key
+type ClientKeyCreate struct {
+	Name                 string     `json:"name" binding:"required"`
+	Owner                string     `json:"owner"`
+	DailyTokenLimit      int64      `json:"daily_token_limit"`
+	WeeklyTokenLimit     int64      `json:"weekly_token_limit"`
+	MonthlyTokenLimit    int64      `json:"monthly_token_limit"`
+	DailyCostLimit       float64    `json:"daily_cost_limit"`
+	WeeklyCostLimit      float64    `json:"weekly_cost_limit"`
+	MonthlyCostLimit     float64    `json:"monthly_cost_limit"`
+	CreditLimit          float64    `json:"credit_limit"`
+	RPMLimit             int64      `json:"rpm_limit"`
+	TPMLimit             int64      `json:"tpm_limit"`
+	ConcurrencyLimit     int64      `json:"concurrency_limit"`
+	AllowedModels        []string   `json:"allowed_models"`
+	AllowedChannelGroups []string   `json:"allowed_channel_groups"`
+	AllowedIPs           []string   `json:"allowed_ips"`
+	ExpiresAt            *time.Time `json:"expires_at"`
+}
+
+// ClientKeyUpdate represents the request to update a client key.
+// An empty allowlist lifts the restriction; an omitted one is left unchanged. ClearExpiry
+// makes the key never expire.
+type ClientKeyUpdate struct {
+	Name                 *string    `json:"name"`
+	Owner                *string    `json:"owner"`
+	IsEnabled            *bool      `json:"is_enabled"`
+	DailyTokenLimit      *int64     `json:"daily_token_limit"`
+	WeeklyTokenLimit     *int64     `json:"weekly_token_limit"`
+	MonthlyTokenLimit    *int64     `json:"monthly_token_limit"`
+	DailyCostLimit       *float64   `json:"daily_cost_limit"`
+	WeeklyCostLimit      *float64   `json:"weekly_cost_limit"`
+	MonthlyCostLimit     *float64   `json:"monthly_cost_limit"`
+	CreditLimit          *float64   `json:"credit_limit"`
+	RPMLimit             *int64     `json:"rpm_limit"`
+	TPMLimit             *int64     `json:"tpm_limit"`
+	ConcurrencyLimit     *int64     `json:"concurrency_limit"`
+	AllowedModels        []string   `json:"allowed_models"`
+	AllowedChannelGroups []string   `json:"allowed_channel_groups"`
+	AllowedIPs           []string   `json:"allowed_ips"`
+	ExpiresAt            *time.Time `json:"expires_at"`
+	ClearExpiry          bool       `json:"clear_expiry"`
+}
+
+// AccessDeniedError reports that a client key may not make a request, such as one for a
+// model outside its allowlist
+type AccessDeniedError struct {
+	Reason          string
+	Unauthenticated bool // the presented credentials were rejected, not the request
+}
+
+func (e *AccessDeniedError) Error() string {
+	return e.Reason
+}
+
+// QuotaUsage is a client key's consumption within one quota period and the limits that apply to it
+type QuotaUsage struct {
+	Period     string     `json:"period"`
+	Since      *time.Time `json:"since"`    // nil for the lifetime period
+	ResetAt    *time.Time `json:"reset_at"` // nil for the lifetime period
+	Tokens     int64      `json:"tokens"`
+	Cost       float64    `json:"cost"`
+	TokenLimit int64      `json:"token_limit"`
+	CostLimit  float64    `json:"cost_limit"`
+}
+
+// ClientKeyUsage is the current consumption of a client key against its quotas
+type ClientKeyUsage struct {
+	ClientKeyID int64        `json:"client_key_id"`
+	Periods     []QuotaUsage `json:"periods"`
+}
+
+// Allowance returns what is left of the quotas; the tightest limit of each kind applies
+func (u *ClientKeyUsage) Allowance() *QuotaAllowance {
+	allowance := &QuotaAllowance{Tokens: -1, Cost: -1}
+	for _, p := range u.Periods {
+		if p.TokenLimit > 0 {
+			left := max(p.TokenLimit-p.Tokens, 0)
+			if allowance.Tokens < 0 || left < allowance.Tokens {
+				allowance.Tokens = left
+				allowance.TokenPeriod = p.Period
+			}
+		}
+		if p.CostLimit > 0 {
+			left := max(p.CostLimit-p.Cost, 0)
+			if allowance.Cost < 0 || left < allowance.Cost {
+				allowance.Cost = left
+				allowance.CostPeriod = p.Period
+			}
+		}
+	}
+	return allowance
+}
+
+// QuotaAllowance is what is left of a client key's quotas when a request starts.
+// Negative amounts are unlimited.
+type QuotaAllowance struct {
+	Tokens      int64
+	TokenPeriod string
+	Cost        float64
+	CostPeriod  string
+}
+
+// Check returns a QuotaExceededError once a request's tokens or cost reach the allowance
+func (a *QuotaAllowance) Check(tokens int64, cost float64) error {
+	if a == nil {
+		return nil
+	}
+	if a.Tokens >= 0 && tokens >= a.Tokens {
+		return newQuotaExceededError(a.TokenPeriod, "token")
+	}
+	if a.Cost >= 0 && cost >= a.Cost {
+		return newQuotaExceededError(a.CostPeriod, "cost")
+	}
+	return nil
+}
+
+// QuotaExceededError reports that a client key has used up one of its quotas
+type QuotaExceededError struct {
+	Period  string
+	Kind    string    // "token" or "cost"
+	ResetAt time.Time // zero for the lifetime credit, which never resets
+}
+
+func newQuotaExceededError(period, kind string) *QuotaExceededError {
+	return &QuotaExceededError{Period: period, Kind: kind, ResetAt: QuotaPeriodEnd(period, time.Now())}
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Period == QuotaPeriodLifetime {
+		return "client key has used up its credit"
+	}
+	return fmt.Sprintf("client key has exceeded its %s %s quota, which resets at %s",
+		e.Period, e.Kind, e.ResetAt.Format(time.RFC3339))
+}
+
+// QuotaPeriodStart returns when the quota period containing now began, or the zero time for lifetime
+func QuotaPeriodStart(period string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case QuotaPeriodDaily:
+		return today
+	case QuotaPeriodWeekly:
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case QuotaPeriodMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// QuotaPeriodEnd returns when the quota period containing now resets, or the zero time for lifetime
+func QuotaPeriodEnd(period string, now time.Time) time.Time {
+	start := QuotaPeriodStart(period, now)
+	switch period {
+	case QuotaPeriodDaily:
+		return start.AddDate(0, 0, 1)
+	case QuotaPeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case QuotaPeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return time.Time{}
+}
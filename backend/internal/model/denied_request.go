@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// DeniedRequest records a proxy request the gateway refused, such as one with an invalid,
+// expired or revoked key, from an IP outside the key's allowlist or for a model it may not use
+type DeniedRequest struct {
+	ID            int64     `json:"id"`
+	ClientKeyID   int64     `json:"client_key_id"` // 0 when the key matches no client key
+	ClientKeyName string    `json:"client_key_name"`
+	KeyHint       string    `json:"key_hint"` // masked form of the presented key
+	IPAddress     string    `json:"ip_address"`
+	Path          string    `json:"path"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DeniedRequestFilter represents filter parameters for denied requests
+type DeniedRequestFilter struct {
+	StartDate   string `form:"start_date"`
+	EndDate     string `form:"end_date"`
+	ClientKeyID int64  `form:"client_key_id"`
+	IPAddress   string `form:"ip_address"`
+}
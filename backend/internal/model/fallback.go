@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// FallbackModelHeader tells clients which display model served a downgraded request
+const FallbackModelHeader = "X-Gateway-Fallback-Model"
+
+// ModelFallback represents the fallback chain of a display model
+type ModelFallback struct {
+	ID             int64     `json:"id"`
+	DisplayModel   string    `json:"display_model"`
+	FallbackModels []string  `json:"fallback_models"`
+	IsEnabled      bool      `json:"is_enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ModelFallbackCreate represents the request to create a fallback chain
+type ModelFallbackCreate struct {
+	DisplayModel   string   `json:"display_model" binding:"required"`
+	FallbackModels []string `json:"fallback_models" binding:"required,min=1"`
+}
+
+// ModelFallbackUpdate represents the request to update a fallback chain
+type ModelFallbackUpdate struct {
+	DisplayModel   *string  `json:"display_model"`
+	FallbackModels []string `json:"fallback_models"`
+	IsEnabled      *bool    `json:"is_enabled"`
+}
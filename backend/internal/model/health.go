@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Channel health statuses
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// ChannelHealth represents a single health probe result for a channel
+type ChannelHealth struct {
+	ID           int64     `json:"id"`
+	ChannelID    int64     `json:"channel_id"`
+	Status       string    `json:"status"`
+	LatencyMs    int       `json:"latency_ms"`
+	StatusCode   int       `json:"status_code"`
+	ErrorMessage string    `json:"error_message"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// ChannelHealthSummary summarizes a channel's recent health history
+type ChannelHealthSummary struct {
+	ChannelID     int64            `json:"channel_id"`
+	IsActive      bool             `json:"is_active"`
+	AutoDisabled  bool             `json:"auto_disabled"`
+	LastStatus    string           `json:"last_status"`
+	LastCheckedAt *time.Time       `json:"last_checked_at"`
+	TotalChecks   int              `json:"total_checks"`
+	UptimePercent float64          `json:"uptime_percent"`
+	AvgLatencyMs  float64          `json:"avg_latency_ms"`
+	History       []*ChannelHealth `json:"history"`
+}
@@ -2,39 +2,92 @@ package model
 
 import "time"
 
+// Display model match types for mappings
+const (
+	MatchTypeExact = "exact"
+	MatchTypeGlob  = "glob"
+	MatchTypeRegex = "regex"
+)
+
 // ModelMapping represents a model name mapping
 type ModelMapping struct {
-	ID             int64     `json:"id"`
-	ChannelID      int64     `json:"channel_id"`
-	UpstreamModel  string    `json:"upstream_model"`
-	DisplayModel   string    `json:"display_model"`
-	IsEnabled      bool      `json:"is_enabled"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID            int64         `json:"id"`
+	ChannelID     int64         `json:"channel_id"`
+	UpstreamModel string        `json:"upstream_model"`
+	DisplayModel  string        `json:"display_model"`
+	MatchType     string        `json:"match_type"`
+	RewriteRules  *RewriteRules `json:"rewrite_rules"`
+	HedgeDelayMs  int           `json:"hedge_delay_ms"`
+	IsEnabled     bool          `json:"is_enabled"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
 }
 
 // MappingCreate represents the request to create a mapping
 type MappingCreate struct {
-	ChannelID     int64  `json:"channel_id" binding:"required"`
-	UpstreamModel string `json:"upstream_model" binding:"required"`
-	DisplayModel  string `json:"display_model" binding:"required"`
+	ChannelID     int64         `json:"channel_id" binding:"required"`
+	UpstreamModel string        `json:"upstream_model" binding:"required"`
+	DisplayModel  string        `json:"display_model" binding:"required"`
+	MatchType     string        `json:"match_type"`
+	RewriteRules  *RewriteRules `json:"rewrite_rules"`
+	HedgeDelayMs  int           `json:"hedge_delay_ms"`
 }
 
 // MappingUpdate represents the request to update a mapping
 type MappingUpdate struct {
-	UpstreamModel *string `json:"upstream_model"`
-	DisplayModel  *string `json:"display_model"`
-	IsEnabled     *bool   `json:"is_enabled"`
+	UpstreamModel *string       `json:"upstream_model"`
+	DisplayModel  *string       `json:"display_model"`
+	MatchType     *string       `json:"match_type"`
+	RewriteRules  *RewriteRules `json:"rewrite_rules"`
+	HedgeDelayMs  *int          `json:"hedge_delay_ms"`
+	IsEnabled     *bool         `json:"is_enabled"`
 }
 
 // ModelMappingWithChannel represents a mapping with channel info
 type ModelMappingWithChannel struct {
-	ID             int64     `json:"id"`
-	ChannelID      int64     `json:"channel_id"`
-	ChannelName    string    `json:"channel_name"`
-	UpstreamModel  string    `json:"upstream_model"`
-	DisplayModel   string    `json:"display_model"`
-	IsEnabled      bool      `json:"is_enabled"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID            int64         `json:"id"`
+	ChannelID     int64         `json:"channel_id"`
+	ChannelName   string        `json:"channel_name"`
+	UpstreamModel string        `json:"upstream_model"`
+	DisplayModel  string        `json:"display_model"`
+	MatchType     string        `json:"match_type"`
+	RewriteRules  *RewriteRules `json:"rewrite_rules"`
+	HedgeDelayMs  int           `json:"hedge_delay_ms"`
+	IsEnabled     bool          `json:"is_enabled"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// RewriteRules are declarative rewrites applied to a request before it is forwarded through a mapping.
+// Forced parameters replace the client's values; DropFields removes top-level fields last.
+type RewriteRules struct {
+	SystemPrepend        string   `json:"system_prepend,omitempty"`
+	SystemAppend         string   `json:"system_append,omitempty"`
+	MaxTokensCeiling     int      `json:"max_tokens_ceiling,omitempty"`
+	Temperature          *float64 `json:"temperature,omitempty"`
+	TopP                 *float64 `json:"top_p,omitempty"`
+	TopK                 *int     `json:"top_k,omitempty"`
+	DropFields           []string `json:"drop_fields,omitempty"`
+	DefaultStopSequences []string `json:"default_stop_sequences,omitempty"`
+}
+
+// UpstreamModel represents a model reported by an upstream's models endpoint
+type UpstreamModel struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name,omitempty"`
+	OwnedBy     string `json:"owned_by,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+// MappingImportRequest represents the request to bulk-create mappings for a channel
+type MappingImportRequest struct {
+	Models        []string `json:"models" binding:"required,min=1"`
+	DisplayPrefix string   `json:"display_prefix"`
+	DisplaySuffix string   `json:"display_suffix"`
+}
+
+// MappingImportResult represents the outcome of a bulk mapping import
+type MappingImportResult struct {
+	Created []*ModelMapping `json:"created"`
+	Skipped []string        `json:"skipped"`
 }
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// ModelPrice is the estimated price of upstream models in USD per million tokens.
+// The pattern matches upstream model names and may use the glob wildcards * and ?.
+type ModelPrice struct {
+	ID           int64     `json:"id"`
+	ModelPattern string    `json:"model_pattern"`
+	InputPrice   float64   `json:"input_price"`
+	OutputPrice  float64   `json:"output_price"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Cost estimates the cost of a request in USD; requests to unpriced models cost nothing
+func (p *ModelPrice) Cost(inputTokens, outputTokens int) float64 {
+	if p == nil {
+		return 0
+	}
+	return (float64(inputTokens)*p.InputPrice + float64(outputTokens)*p.OutputPrice) / 1e6
+}
+
+// ModelPriceCreate represents the request to create a model price
+type ModelPriceCreate struct {
+	ModelPattern string  `json:"model_pattern" binding:"required"`
+	InputPrice   float64 `json:"input_price"`
+	OutputPrice  float64 `json:"output_price"`
+}
+
+// ModelPriceUpdate represents the request to update a model price
+type ModelPriceUpdate struct {
+	ModelPattern *string  `json:"model_pattern"`
+	InputPrice   *float64 `json:"input_price"`
+	OutputPrice  *float64 `json:"output_price"`
+}
@@ -3,7 +3,7 @@ package model
 // Message represents a message in the conversation
 type Message struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"` // Can be string or array of content blocks
 }
 
 // StreamEvent represents a streaming event
@@ -26,6 +26,12 @@ type AnthropicMessageRequest struct {
 	Tools            []Tool    `json:"tools,omitempty"`
 	ToolChoice       any       `json:"tool_choice,omitempty"`
 	Metadata         any       `json:"metadata,omitempty"`
+	Thinking         any       `json:"thinking,omitempty"`
+	NoCache          bool      `json:"-"` // set from ResponseCacheHeader
+	ClientKeyID      int64     `json:"-"` // client key that authenticated the request
+	Quota            *QuotaAllowance `json:"-"` // what is left of the client key's quotas
+	AllowedModels    []string        `json:"-"` // display model globs the client key may use
+	ChannelGroups    []string        `json:"-"` // channel groups the client key is pinned to
 }
 
 // Tool represents a tool definition
@@ -105,6 +111,13 @@ type OpenAIChatRequest struct {
 	Functions        any             `json:"functions,omitempty"`
 	Tools            any             `json:"tools,omitempty"`
 	ToolChoice       any             `json:"tool_choice,omitempty"`
+	ReasoningEffort  string          `json:"reasoning_effort,omitempty"`
+	User             string          `json:"user,omitempty"`
+	NoCache          bool            `json:"-"` // set from ResponseCacheHeader
+	ClientKeyID      int64           `json:"-"` // client key that authenticated the request
+	Quota            *QuotaAllowance `json:"-"` // what is left of the client key's quotas
+	AllowedModels    []string        `json:"-"` // display model globs the client key may use
+	ChannelGroups    []string        `json:"-"` // channel groups the client key is pinned to
 }
 
 // OpenAIChatResponse represents OpenAI chat completion response
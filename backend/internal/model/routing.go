@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// RoutingRule routes requests whose content matches its conditions to a channel group.
+// Unset conditions match any request.
+type RoutingRule struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Priority       int       `json:"priority"`
+	ModelPattern   string    `json:"model_pattern"`
+	MinInputTokens int       `json:"min_input_tokens"`
+	HasImages      *bool     `json:"has_images"`
+	HasTools       *bool     `json:"has_tools"`
+	HasThinking    *bool     `json:"has_thinking"`
+	TargetGroup    string    `json:"target_group"`
+	IsEnabled      bool      `json:"is_enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// RoutingRuleCreate represents the request to create a routing rule
+type RoutingRuleCreate struct {
+	Name           string `json:"name" binding:"required"`
+	Priority       int    `json:"priority"`
+	ModelPattern   string `json:"model_pattern"`
+	MinInputTokens int    `json:"min_input_tokens"`
+	HasImages      *bool  `json:"has_images"`
+	HasTools       *bool  `json:"has_tools"`
+	HasThinking    *bool  `json:"has_thinking"`
+	TargetGroup    string `json:"target_group" binding:"required"`
+}
+
+// RoutingRuleUpdate represents the request to update a routing rule
+type RoutingRuleUpdate struct {
+	Name           *string `json:"name"`
+	Priority       *int    `json:"priority"`
+	ModelPattern   *string `json:"model_pattern"`
+	MinInputTokens *int    `json:"min_input_tokens"`
+	HasImages      *bool   `json:"has_images"`
+	HasTools       *bool   `json:"has_tools"`
+	HasThinking    *bool   `json:"has_thinking"`
+	TargetGroup    *string `json:"target_group"`
+	IsEnabled      *bool   `json:"is_enabled"`
+}
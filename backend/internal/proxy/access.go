@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// ModelAllowed reports whether a display model matches one of a client key's allowed model
+// globs. An empty allowlist allows every model.
+func ModelAllowed(patterns []string, displayModel string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		re, err := repository.CompileMappingPattern(model.MatchTypeGlob, pattern)
+		if err == nil && re.MatchString(displayModel) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckModelAccess rejects a request for a display model outside a client key's allowlist
+func CheckModelAccess(patterns []string, displayModel string) error {
+	if ModelAllowed(patterns, displayModel) {
+		return nil
+	}
+	return &model.AccessDeniedError{Reason: fmt.Sprintf("client key may not use model %s", displayModel)}
+}
+
+// GroupAllowed reports whether a channel group is one a client key is pinned to.
+// An empty list allows every group.
+func GroupAllowed(groups []string, group string) bool {
+	return len(groups) == 0 || slices.Contains(groups, group)
+}
+
+// ModelNotFoundError rejects a request for a model that no mapping serves
+type ModelNotFoundError struct {
+	Model string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("model: %s", e.Model)
+}
+
+// checkPassthrough decides whether a request for an unmapped model may be passed through to the
+// default upstream with the caller's own key. Only the shared API_KEY passes through: issued client
+// keys are gateway secrets that must not be sent to a third party, and their usage must be metered
+// by a channel.
+func checkPassthrough(clientKeyID int64, displayModel string) error {
+	if clientKeyID != 0 {
+		return &ModelNotFoundError{Model: displayModel}
+	}
+	return nil
+}
+
+// restrictChannelGroups narrows mappings to the channel groups a client key is pinned to.
+// A pinned key may not reach a model that no channel in its groups serves, nor pass a
+// request through to the default upstream when the model isn't mapped at all.
+func (s *ProxyService) restrictChannelGroups(mappings []*model.ModelMappingWithChannel, groups []string, displayModel string) ([]*model.ModelMappingWithChannel, error) {
+	if len(groups) == 0 {
+		return mappings, nil
+	}
+
+	var allowed []*model.ModelMappingWithChannel
+	for _, mapping := range mappings {
+		channel, err := s.channelRepo.GetByID(mapping.ChannelID)
+		if err == nil && GroupAllowed(groups, channel.Group) {
+			allowed = append(allowed, mapping)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, &model.AccessDeniedError{Reason: fmt.Sprintf("no channel the client key may use serves model %s", displayModel)}
+	}
+	return allowed, nil
+}
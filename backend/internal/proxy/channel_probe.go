@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// defaultProbeModels are used to test a channel when neither the request nor its mappings name a model
+var defaultProbeModels = map[string]string{
+	"anthropic": "claude-3-5-haiku-latest",
+	"openai":    "gpt-4o-mini",
+}
+
+// mappedProbeModel returns the upstream model of a channel's first enabled mapping, if any
+func mappedProbeModel(mappingRepo *repository.MappingRepository, channelID int64) string {
+	mappings, err := mappingRepo.ListByChannel(channelID)
+	if err != nil {
+		return ""
+	}
+	for _, mapping := range mappings {
+		if mapping.IsEnabled {
+			return mapping.UpstreamModel
+		}
+	}
+	return ""
+}
+
+// probeTarget describes where and how a probe request is sent
+type probeTarget struct {
+	BaseURL  string
+	APIKey   string
+	Provider string
+	Model    string
+}
+
+// maxProbeContent caps how much generated text is echoed back in a probe result
+const maxProbeContent = 200
+
+// runProbe sends a minimal request to an upstream in its native format and measures it
+func runProbe(client *http.Client, target probeTarget, stream bool, maxTokens int) *model.ChannelProbeResult {
+	result := &model.ChannelProbeResult{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	httpReq, err := newProbeRequest(ctx, target, stream, maxTokens)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	startTime := time.Now()
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		result.LatencyMs = int(time.Since(startTime).Milliseconds())
+		result.Error = err.Error()
+		return result
+	}
+	defer httpResp.Body.Close()
+	result.StatusCode = httpResp.StatusCode
+
+	if httpResp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 8192))
+		result.LatencyMs = int(time.Since(startTime).Milliseconds())
+		result.Error = fmt.Sprintf("upstream returned status %d", httpResp.StatusCode)
+		result.UpstreamError = string(respBody)
+		return result
+	}
+
+	if stream {
+		readProbeStream(httpResp.Body, target.Provider, startTime, result)
+	} else {
+		readProbeResponse(httpResp.Body, target.Provider, result)
+		result.LatencyMs = int(time.Since(startTime).Milliseconds())
+		result.TTFTMs = result.LatencyMs
+	}
+
+	result.Success = result.Error == ""
+	if len(result.Content) > maxProbeContent {
+		result.Content = result.Content[:maxProbeContent]
+	}
+	return result
+}
+
+// newProbeRequest builds the upstream HTTP request for a probe
+func newProbeRequest(ctx context.Context, target probeTarget, stream bool, maxTokens int) (*http.Request, error) {
+	var httpReq *http.Request
+	var err error
+
+	if target.Provider == "anthropic" {
+		bodyBytes, _ := json.Marshal(&model.AnthropicMessageRequest{
+			Model:     target.Model,
+			MaxTokens: maxTokens,
+			Messages:  []model.Message{{Role: "user", Content: "Hi"}},
+			Stream:    stream,
+		})
+		httpReq, err = http.NewRequestWithContext(ctx, "POST", target.BaseURL+"/v1/messages", bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		httpReq.Header.Set("x-api-key", target.APIKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+	} else {
+		body := map[string]interface{}{
+			"model":      target.Model,
+			"max_tokens": maxTokens,
+			"messages":   []model.OpenAIMessage{{Role: "user", Content: "Hi"}},
+			"stream":     stream,
+		}
+		if stream {
+			// Ask for a final usage chunk so token counts can be reported
+			body["stream_options"] = map[string]bool{"include_usage": true}
+		}
+		bodyBytes, _ := json.Marshal(body)
+		httpReq, err = http.NewRequestWithContext(ctx, "POST", target.BaseURL+"/v1/chat/completions", bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+target.APIKey)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	return httpReq, nil
+}
+
+// readProbeResponse extracts content and usage from a non-streaming probe response
+func readProbeResponse(body io.Reader, provider string, result *model.ChannelProbeResult) {
+	respBody, err := io.ReadAll(body)
+	if err != nil {
+		result.Error = "failed to read response: " + err.Error()
+		return
+	}
+
+	if provider == "anthropic" {
+		var resp model.AnthropicMessageResponse
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			result.Error = "failed to parse response: " + err.Error()
+			result.UpstreamError = string(respBody)
+			return
+		}
+		for _, c := range resp.Content {
+			result.Content += c.Text
+		}
+		result.InputTokens = resp.Usage.InputTokens
+		result.OutputTokens = resp.Usage.OutputTokens
+		return
+	}
+
+	var resp model.OpenAIChatResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		result.Error = "failed to parse response: " + err.Error()
+		result.UpstreamError = string(respBody)
+		return
+	}
+	if len(resp.Choices) > 0 {
+		if content, ok := resp.Choices[0].Message.Content.(string); ok {
+			result.Content = content
+		}
+	}
+	result.InputTokens = resp.Usage.PromptTokens
+	result.OutputTokens = resp.Usage.CompletionTokens
+}
+
+// readProbeStream consumes a streaming probe response, recording time to first token and usage
+func readProbeStream(body io.Reader, provider string, startTime time.Time, result *model.ChannelProbeResult) {
+	var content strings.Builder
+	completed := false
+
+	scanner := newLineScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		dataStr := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if dataStr == "[DONE]" {
+			completed = true
+			continue
+		}
+
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(dataStr), &event); err != nil {
+			continue
+		}
+
+		// Both protocols report mid-stream failures as an "error" object
+		if _, ok := event["error"]; ok {
+			result.Error = "upstream stream error"
+			result.UpstreamError = dataStr
+			break
+		}
+
+		text := ""
+		if provider == "anthropic" {
+			switch event["type"] {
+			case "message_start":
+				if msg, ok := event["message"].(map[string]interface{}); ok {
+					if u, ok := msg["usage"].(map[string]interface{}); ok {
+						result.InputTokens = intFromJSON(u["input_tokens"])
+					}
+				}
+			case "content_block_delta":
+				if delta, ok := event["delta"].(map[string]interface{}); ok {
+					text, _ = delta["text"].(string)
+				}
+			case "message_delta":
+				if u, ok := event["usage"].(map[string]interface{}); ok {
+					result.OutputTokens = intFromJSON(u["output_tokens"])
+				}
+			case "message_stop":
+				completed = true
+			}
+		} else {
+			if choices, ok := event["choices"].([]interface{}); ok && len(choices) > 0 {
+				if choice, ok := choices[0].(map[string]interface{}); ok {
+					if delta, ok := choice["delta"].(map[string]interface{}); ok {
+						text, _ = delta["content"].(string)
+					}
+				}
+			}
+			if u, ok := event["usage"].(map[string]interface{}); ok {
+				result.InputTokens = intFromJSON(u["prompt_tokens"])
+				result.OutputTokens = intFromJSON(u["completion_tokens"])
+			}
+		}
+
+		if text != "" {
+			if content.Len() == 0 {
+				result.TTFTMs = int(time.Since(startTime).Milliseconds())
+			}
+			content.WriteString(text)
+		}
+	}
+
+	result.LatencyMs = int(time.Since(startTime).Milliseconds())
+	result.Content = content.String()
+	if result.Error == "" && !completed {
+		result.Error = "stream ended before completion"
+	}
+}
+
+// intFromJSON converts a decoded JSON number to int
+func intFromJSON(v interface{}) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
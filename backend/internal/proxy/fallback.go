@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// fallbackModels returns the fallback chain configured for a display model, skipping models
+// outside the client key's allowlist. Chains are not followed recursively, so a chain can never loop.
+func (s *ProxyService) fallbackModels(displayModel string, allowedModels []string) []string {
+	fallback, err := s.fallbackRepo.FindByDisplayModel(displayModel)
+	if err != nil {
+		logger.Error("Failed to load fallback chain for model %s: %v", displayModel, err)
+		return nil
+	}
+	if fallback == nil {
+		return nil
+	}
+
+	var models []string
+	for _, fallbackModel := range fallback.FallbackModels {
+		if ModelAllowed(allowedModels, fallbackModel) {
+			models = append(models, fallbackModel)
+		}
+	}
+	return models
+}
+
+// shouldFallback reports whether a failed model may be replaced by its fallback chain: it had no
+// usable channel, or its channels failed in a way another model could avoid. Rejections of the
+// request itself, such as an invalid request or an exceeded quota, would fail the same way on any model.
+func shouldFallback(err error) bool {
+	var notFoundErr *ModelNotFoundError
+	if errors.Is(err, errNoChannel) || errors.As(err, &notFoundErr) {
+		return true
+	}
+	var upstreamErr *UpstreamError
+	return errors.As(err, &upstreamErr) && retryable(upstreamErr)
+}
+
+// fallbackRequestID derives the request ID logged for the n-th fallback attempt
+func fallbackRequestID(requestID string, n int) string {
+	return fmt.Sprintf("%s-fallback-%d", requestID, n+1)
+}
+
+// recordFallback marks the logs of a fallback attempt with the originally requested model
+func (s *ProxyService) recordFallback(requestID string, requestedModel string) {
+	if err := s.logRepo.MarkFallback(requestID, requestedModel); err != nil {
+		logger.Error("Failed to record fallback for request %s: %v", requestID, err)
+	}
+}
+
+// responseStarted reports whether headers have already been sent to the client
+func responseStarted(w http.ResponseWriter) bool {
+	rw, ok := w.(interface{ Written() bool })
+	return ok && rw.Written()
+}
@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/config"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// HealthChecker periodically probes channels and records their health
+type HealthChecker struct {
+	channelRepo      *repository.ChannelRepository
+	mappingRepo      *repository.MappingRepository
+	healthRepo       *repository.HealthRepository
+	keyPool          *keyPool
+	client           *http.Client
+	interval         time.Duration
+	defaultModel     string
+	autoToggle       bool
+	failureThreshold int
+	retention        time.Duration
+}
+
+// NewHealthChecker creates a new health checker from configuration
+func NewHealthChecker(cfg *config.Config) *HealthChecker {
+	threshold := cfg.HealthCheckFailureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &HealthChecker{
+		channelRepo:      repository.NewChannelRepository(),
+		mappingRepo:      repository.NewMappingRepository(),
+		healthRepo:       repository.NewHealthRepository(),
+		keyPool:          newKeyPool(),
+		client:           &http.Client{Timeout: 30 * time.Second},
+		interval:         time.Duration(cfg.HealthCheckInterval) * time.Second,
+		defaultModel:     cfg.HealthCheckModel,
+		autoToggle:       cfg.HealthCheckAutoToggle,
+		failureThreshold: threshold,
+		retention:        time.Duration(cfg.HealthCheckRetentionDays) * 24 * time.Hour,
+	}
+}
+
+// Start runs the checker in the background until ctx is cancelled.
+// It does nothing when the configured interval is zero.
+func (h *HealthChecker) Start(ctx context.Context) {
+	if h.interval <= 0 {
+		return
+	}
+
+	logger.Info("Channel health checker started (interval=%v, auto_toggle=%v)", h.interval, h.autoToggle)
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		h.RunOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.RunOnce()
+			}
+		}
+	}()
+}
+
+// RunOnce probes every active channel, plus channels it previously disabled
+func (h *HealthChecker) RunOnce() {
+	channels, err := h.channelRepo.ListForHealthCheck()
+	if err != nil {
+		logger.Error("Health check failed to list channels: %v", err)
+		return
+	}
+
+	for _, channel := range channels {
+		h.checkChannel(channel)
+	}
+
+	if h.retention > 0 {
+		if _, err := h.healthRepo.DeleteBefore(time.Now().Add(-h.retention)); err != nil {
+			logger.Error("Health check failed to prune history: %v", err)
+		}
+	}
+}
+
+// checkChannel probes a single channel, stores the result and applies auto toggling
+func (h *HealthChecker) checkChannel(channel *model.Channel) {
+	key := h.keyPool.Peek(channel)
+	startTime := time.Now()
+	if key == nil {
+		// Nothing to probe with; the channel is skipped for traffic until a key is re-enabled
+		h.record(channel, &model.ChannelHealth{
+			ChannelID:    channel.ID,
+			Status:       model.HealthStatusUnhealthy,
+			ErrorMessage: errNoUsableKey.Error(),
+			CheckedAt:    startTime,
+		})
+		return
+	}
+
+	probeModel := mappedProbeModel(h.mappingRepo, channel.ID)
+	if probeModel == "" {
+		probeModel = h.defaultModel
+	}
+	probe := runProbe(h.client, probeTarget{
+		BaseURL:  channel.BaseURL,
+		APIKey:   key.APIKey,
+		Provider: channel.Provider,
+		Model:    probeModel,
+	}, false, 1)
+
+	result := &model.ChannelHealth{
+		ChannelID:  channel.ID,
+		LatencyMs:  probe.LatencyMs,
+		StatusCode: probe.StatusCode,
+		CheckedAt:  startTime,
+	}
+
+	switch {
+	case probe.Success:
+		result.Status = model.HealthStatusHealthy
+	case probe.StatusCode == http.StatusTooManyRequests || probe.StatusCode == 529:
+		// Rate limited or overloaded upstreams are reachable; don't count them as down
+		result.Status = model.HealthStatusDegraded
+		result.ErrorMessage = probe.UpstreamError
+	default:
+		result.Status = model.HealthStatusUnhealthy
+		result.ErrorMessage = probe.Error
+		if probe.UpstreamError != "" {
+			result.ErrorMessage = probe.UpstreamError
+			h.keyPool.ReportErrorResponse(key, probe.StatusCode, []byte(probe.UpstreamError))
+		}
+	}
+
+	h.record(channel, result)
+}
+
+// record stores a health check result and applies auto toggling
+func (h *HealthChecker) record(channel *model.Channel, result *model.ChannelHealth) {
+	if _, err := h.healthRepo.Create(result); err != nil {
+		logger.Error("Failed to record health for channel %d: %v", channel.ID, err)
+	}
+
+	if h.autoToggle {
+		h.applyAutoToggle(channel, result)
+	}
+}
+
+// applyAutoToggle deactivates channels after consecutive failures and
+// reactivates channels the checker itself deactivated once they recover
+func (h *HealthChecker) applyAutoToggle(channel *model.Channel, result *model.ChannelHealth) {
+	if result.Status != model.HealthStatusUnhealthy {
+		if channel.AutoDisabled {
+			logger.Info("Health check: channel %d (%s) recovered, reactivating", channel.ID, channel.Name)
+			if err := h.channelRepo.SetAutoDisabled(channel.ID, false); err != nil {
+				logger.Error("Failed to reactivate channel %d: %v", channel.ID, err)
+			}
+		}
+		return
+	}
+
+	if !channel.IsActive {
+		return
+	}
+
+	history, err := h.healthRepo.ListByChannel(channel.ID, h.failureThreshold)
+	if err != nil || len(history) < h.failureThreshold {
+		return
+	}
+	for _, record := range history {
+		if record.Status != model.HealthStatusUnhealthy {
+			return
+		}
+	}
+
+	logger.Info("Health check: channel %d (%s) failed %d consecutive checks, deactivating", channel.ID, channel.Name, h.failureThreshold)
+	if err := h.channelRepo.SetAutoDisabled(channel.ID, true); err != nil {
+		logger.Error("Failed to deactivate channel %d: %v", channel.ID, err)
+	}
+}
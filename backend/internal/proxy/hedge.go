@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// upstreamTarget is a mapped channel resolved for one attempt, together with the key to use
+type upstreamTarget struct {
+	mapping *model.ModelMappingWithChannel
+	channel *model.Channel
+	key     *model.ChannelKey
+}
+
+// upstreamAttempt sends a non-streaming request to a target, logging it under requestID
+type upstreamAttempt[T any] func(ctx context.Context, requestID string, target *upstreamTarget) (T, error)
+
+// resolveTarget loads the channel of a mapping and picks its key, or returns nil when the channel can't serve
+func (s *ProxyService) resolveTarget(mapping *model.ModelMappingWithChannel, pin *sessionPin) *upstreamTarget {
+	if !mapping.IsEnabled {
+		return nil
+	}
+
+	channel, err := s.channelRepo.GetByID(mapping.ChannelID)
+	if err != nil {
+		logger.Error("Failed to get channel %d: %v", mapping.ChannelID, err)
+		return nil
+	}
+
+	if !channel.IsActive {
+		logger.Debug("Channel %d is not active", channel.ID)
+		return nil
+	}
+
+	key := s.selectKey(channel, pin)
+	if key == nil {
+		return nil
+	}
+	return &upstreamTarget{mapping: mapping, channel: channel, key: key}
+}
+
+// nextTarget resolves the first usable mapping of rest and reports how many mappings it used up
+func (s *ProxyService) nextTarget(rest []*model.ModelMappingWithChannel, pin *sessionPin) (*upstreamTarget, int) {
+	for i, mapping := range rest {
+		if target := s.resolveTarget(mapping, pin); target != nil {
+			return target, i + 1
+		}
+	}
+	return nil, len(rest)
+}
+
+// tryChannels tries each mapping in order until one succeeds, pinning the session to the channel that served it.
+// When none does it returns the error of the last attempt; an error no other channel could avoid is returned at once.
+func tryChannels[T any](s *ProxyService, mappings []*model.ModelMappingWithChannel, pin *sessionPin, sessionKey string, requestID string, attempt upstreamAttempt[T]) (T, error) {
+	var zero T
+	lastErr := errNoChannel
+	for i := 0; i < len(mappings); i++ {
+		primary := s.resolveTarget(mappings[i], pin)
+		if primary == nil {
+			continue
+		}
+
+		if primary.mapping.HedgeDelayMs > 0 {
+			resp, winner, used, err := hedgeChannels(s, primary, mappings[i+1:], pin, requestID, attempt)
+			if winner != nil {
+				s.affinity.Pin(sessionKey, winner.channel.ID, winner.key.ID)
+				return resp, nil
+			}
+			if !retryable(err) {
+				return zero, err
+			}
+			lastErr = err
+			i += used
+			continue
+		}
+
+		resp, err := attempt(context.Background(), requestID, primary)
+		if err != nil {
+			logger.Error("Failed to proxy to channel %d: %v", primary.channel.ID, err)
+			if !retryable(err) {
+				return zero, err
+			}
+			lastErr = err
+			continue
+		}
+		s.affinity.Pin(sessionKey, primary.channel.ID, primary.key.ID)
+		return resp, nil
+	}
+	return zero, lastErr
+}
+
+// hedgeChannels sends a request to the primary and, when it hasn't answered within the mapping's
+// hedge delay, to the next usable channel of rest as well. The first success wins and the other
+// attempt is cancelled. It returns the winning target, or nil and the last error, and how many mappings of rest
+// it used up; when the primary fails before the delay the rest are left untouched for regular failover.
+func hedgeChannels[T any](s *ProxyService, primary *upstreamTarget, rest []*model.ModelMappingWithChannel, pin *sessionPin, requestID string, attempt upstreamAttempt[T]) (T, *upstreamTarget, int, error) {
+	type result struct {
+		resp   T
+		target *upstreamTarget
+		err    error
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan result, 2)
+	run := func(target *upstreamTarget, id string) {
+		resp, err := attempt(ctx, id, target)
+		results <- result{resp: resp, target: target, err: err}
+	}
+	go run(primary, requestID)
+
+	delay := time.Duration(primary.mapping.HedgeDelayMs) * time.Millisecond
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var zero T
+	var lastErr error
+	hedgeID := hedgeRequestID(requestID)
+	pending, used, hedged := 1, 0, false
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			var backup *upstreamTarget
+			backup, used = s.nextTarget(rest, pin)
+			if backup != nil {
+				logger.Info("Channel %d has not answered within %v, hedging to channel %d", primary.channel.ID, delay, backup.channel.ID)
+				hedged = true
+				pending++
+				go run(backup, hedgeID)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if hedged {
+					// Mark the logs once the cancelled loser has written its own
+					go func(pending int) {
+						for ; pending > 0; pending-- {
+							<-results
+						}
+						s.recordHedge(requestID, hedgeID)
+					}(pending)
+				}
+				return r.resp, r.target, used, nil
+			}
+			logger.Error("Failed to proxy to channel %d: %v", r.target.channel.ID, r.err)
+			lastErr = r.err
+		}
+	}
+
+	if hedged {
+		s.recordHedge(requestID, hedgeID)
+	}
+	return zero, nil, used, lastErr
+}
+
+// errNoChannel is reported when a model has no mapped channel that could take a request
+var errNoChannel = errors.New("no available channel")
+
+// hedgeRequestID derives the request ID logged for the hedge attempt of a request
+func hedgeRequestID(requestID string) string {
+	return requestID + "-hedge"
+}
+
+// recordHedge marks the logs of both attempts of a hedged request. It runs once both have
+// written their logs, so each is the latest attempt under its request ID.
+func (s *ProxyService) recordHedge(requestID string, hedgeID string) {
+	if err := s.logRepo.MarkHedge(requestID, model.HedgeRolePrimary); err != nil {
+		logger.Error("Failed to record hedge for request %s: %v", requestID, err)
+	}
+	if err := s.logRepo.MarkHedge(hedgeID, model.HedgeRoleHedge); err != nil {
+		logger.Error("Failed to record hedge for request %s: %v", hedgeID, err)
+	}
+}
+
+// transportErrorCode labels a failed upstream exchange, telling an attempt cancelled by a
+// hedge apart from a network failure
+func transportErrorCode(ctx context.Context, code string) string {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return "cancelled"
+	}
+	return code
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// keyPool selects upstream API keys for a channel and tracks their health
+type keyPool struct {
+	keyRepo *repository.ChannelKeyRepository
+	mu      sync.Mutex
+	cursors map[int64]int
+}
+
+func newKeyPool() *keyPool {
+	return &keyPool{
+		keyRepo: repository.NewChannelKeyRepository(),
+		cursors: make(map[int64]int),
+	}
+}
+
+// errNoUsableKey is reported when every pooled key of a channel is disabled
+var errNoUsableKey = errors.New("every pooled key of the channel is disabled")
+
+// usableKeys returns the enabled pooled keys of a channel. Channels without pooled keys
+// fall back to the channel's own API key, returned with ID 0. It returns no keys when
+// every pooled key is disabled.
+func (p *keyPool) usableKeys(channel *model.Channel) []*model.ChannelKey {
+	pooled, err := p.keyRepo.ListByChannel(channel.ID)
+	if err != nil {
+		logger.Error("Failed to list keys for channel %d: %v", channel.ID, err)
+	}
+	if len(pooled) == 0 {
+		return []*model.ChannelKey{{ChannelID: channel.ID, APIKey: channel.APIKey, IsEnabled: true}}
+	}
+
+	var keys []*model.ChannelKey
+	for _, key := range pooled {
+		if key.IsEnabled {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Select picks the key to use for the next request on a channel, from its usable keys.
+// It returns nil when every pooled key is disabled, so the channel is skipped.
+func (p *keyPool) Select(channel *model.Channel) *model.ChannelKey {
+	keys := p.usableKeys(channel)
+	if len(keys) == 0 {
+		logger.Debug("Every pooled key of channel %d is disabled, skipping the channel", channel.ID)
+		return nil
+	}
+	if keys[0].ID == 0 {
+		return keys[0]
+	}
+
+	var key *model.ChannelKey
+	switch channel.KeyStrategy {
+	case model.KeyStrategyLeastUsed:
+		key = keys[0]
+		for _, k := range keys[1:] {
+			if k.UsageCount < key.UsageCount {
+				key = k
+			}
+		}
+	default:
+		p.mu.Lock()
+		idx := p.cursors[channel.ID] % len(keys)
+		p.cursors[channel.ID] = idx + 1
+		p.mu.Unlock()
+		key = keys[idx]
+	}
+
+	if err := p.keyRepo.RecordUsage(key.ID); err != nil {
+		logger.Error("Failed to record usage for channel key %d: %v", key.ID, err)
+	}
+	return key
+}
+
+// SelectByID picks a specific enabled key of a channel, or returns nil if it is no longer usable
+func (p *keyPool) SelectByID(channel *model.Channel, keyID int64) *model.ChannelKey {
+	keys, err := p.keyRepo.ListEnabledByChannel(channel.ID)
+	if err != nil {
+		return nil
+	}
+	for _, key := range keys {
+		if key.ID == keyID {
+			if err := p.keyRepo.RecordUsage(key.ID); err != nil {
+				logger.Error("Failed to record usage for channel key %d: %v", key.ID, err)
+			}
+			return key
+		}
+	}
+	return nil
+}
+
+// Peek returns the key a probe should use without counting it as usage.
+// Like Select, it returns nil when every pooled key is disabled.
+func (p *keyPool) Peek(channel *model.Channel) *model.ChannelKey {
+	keys := p.usableKeys(channel)
+	if len(keys) == 0 {
+		return nil
+	}
+	return keys[0]
+}
+
+// ReportFailure records an upstream failure for a key and disables it
+// when the upstream rejected the key itself (auth or quota errors)
+func (p *keyPool) ReportFailure(key *model.ChannelKey, statusCode int, errorType, message string) {
+	if key == nil || key.ID == 0 {
+		return
+	}
+
+	if err := p.keyRepo.RecordFailure(key.ID, message); err != nil {
+		logger.Error("Failed to record failure for channel key %d: %v", key.ID, err)
+	}
+
+	if reason := keyDisableReason(statusCode, errorType, message); reason != "" {
+		logger.Info("Disabling channel key %d (%s) on channel %d: %s", key.ID, secret.Mask(key.APIKey), key.ChannelID, reason)
+		if err := p.keyRepo.Disable(key.ID, reason); err != nil {
+			logger.Error("Failed to disable channel key %d: %v", key.ID, err)
+			return
+		}
+		if keys, err := p.keyRepo.ListEnabledByChannel(key.ChannelID); err == nil && len(keys) == 0 {
+			logger.Info("Every pooled key of channel %d is disabled, the channel is skipped until one is re-enabled", key.ChannelID)
+		}
+	}
+}
+
+// ReportErrorResponse records a non-200 upstream response against the key that made the request
+func (p *keyPool) ReportErrorResponse(key *model.ChannelKey, statusCode int, body []byte) {
+	// Anthropic and OpenAI both nest the error type under "error"
+	var errResp model.AnthropicErrorResponse
+	json.Unmarshal(body, &errResp)
+	p.ReportFailure(key, statusCode, errResp.Error.Type, string(body))
+}
+
+// keyDisableReason classifies upstream errors that mean a key is unusable.
+// Transient errors (rate limits, overload, 5xx) return an empty reason.
+func keyDisableReason(statusCode int, errorType, message string) string {
+	lowerMessage := strings.ToLower(message)
+
+	switch {
+	case statusCode == http.StatusUnauthorized || errorType == "authentication_error":
+		return "authentication failed"
+	case statusCode == http.StatusForbidden || errorType == "permission_error":
+		return "permission denied"
+	case statusCode == http.StatusPaymentRequired || errorType == "billing_error":
+		return "quota exhausted"
+	case strings.Contains(lowerMessage, "insufficient_quota"),
+		strings.Contains(lowerMessage, "credit balance is too low"),
+		strings.Contains(lowerMessage, "exceeded your current quota"):
+		return "quota exhausted"
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// maxModelPages bounds pagination when listing upstream models
+const maxModelPages = 10
+
+// upstreamModelList covers both the Anthropic and OpenAI /v1/models response shapes
+type upstreamModelList struct {
+	Data []struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+		OwnedBy     string `json:"owned_by"`
+		CreatedAt   string `json:"created_at"`
+		Created     int64  `json:"created"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+// ListUpstreamModels fetches the models served by a channel's upstream
+func (s *ProxyService) ListUpstreamModels(channelID int64) ([]*model.UpstreamModel, error) {
+	channel, err := s.channelRepo.GetByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	key := s.keyPool.Peek(channel)
+	if key == nil {
+		return nil, errNoUsableKey
+	}
+	apiKey := key.APIKey
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var models []*model.UpstreamModel
+	afterID := ""
+	for page := 0; page < maxModelPages; page++ {
+		list, err := s.fetchModelPage(ctx, channel, apiKey, afterID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range list.Data {
+			upstreamModel := &model.UpstreamModel{
+				ID:          m.ID,
+				DisplayName: m.DisplayName,
+				OwnedBy:     m.OwnedBy,
+				CreatedAt:   m.CreatedAt,
+			}
+			if upstreamModel.CreatedAt == "" && m.Created > 0 {
+				upstreamModel.CreatedAt = time.Unix(m.Created, 0).UTC().Format(time.RFC3339)
+			}
+			models = append(models, upstreamModel)
+		}
+
+		// Only the Anthropic API paginates
+		if !list.HasMore || list.LastID == "" {
+			break
+		}
+		afterID = list.LastID
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// fetchModelPage requests one page of the upstream models endpoint
+func (s *ProxyService) fetchModelPage(ctx context.Context, channel *model.Channel, apiKey, afterID string) (*upstreamModelList, error) {
+	endpoint := channel.BaseURL + "/v1/models"
+	if channel.Provider == "anthropic" {
+		query := url.Values{"limit": {"1000"}}
+		if afterID != "" {
+			query.Set("after_id", afterID)
+		}
+		endpoint += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if channel.Provider == "anthropic" {
+		httpReq.Header.Set("x-api-key", apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: status %d, response: %s", httpResp.StatusCode, string(respBody))
+	}
+
+	var list upstreamModelList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse models response: %w", err)
+	}
+	return &list, nil
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,72 +14,123 @@ import (
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/internal/repository"
 	"github.com/claude-api-gateway/backend/pkg/logger"
+	"github.com/claude-api-gateway/backend/pkg/secret"
 	"github.com/google/uuid"
 )
 
 // ProxyService handles API proxy operations
 type ProxyService struct {
-	channelRepo *repository.ChannelRepository
-	mappingRepo *repository.MappingRepository
-	logRepo     *repository.LogRepository
-	client      *http.Client
+	channelRepo  *repository.ChannelRepository
+	mappingRepo  *repository.MappingRepository
+	fallbackRepo *repository.FallbackRepository
+	routingRepo  *repository.RoutingRepository
+	healthRepo   *repository.HealthRepository
+	logRepo      *repository.LogRepository
+	priceRepo    *repository.PriceRepository
+	keyPool      *keyPool
+	affinity     *sessionAffinity
+	cache        *responseCache
+	rateLimits   *rateLimiter
+	gatewayIDs   bool
+	client       *http.Client
 }
 
 // NewProxyService creates a new proxy service
 func NewProxyService() *ProxyService {
 	return &ProxyService{
-		channelRepo: repository.NewChannelRepository(),
-		mappingRepo: repository.NewMappingRepository(),
-		logRepo:     repository.NewLogRepository(),
+		channelRepo:  repository.NewChannelRepository(),
+		mappingRepo:  repository.NewMappingRepository(),
+		fallbackRepo: repository.NewFallbackRepository(),
+		routingRepo:  repository.NewRoutingRepository(),
+		healthRepo:   repository.NewHealthRepository(),
+		logRepo:      repository.NewLogRepository(),
+		priceRepo:    repository.NewPriceRepository(),
+		keyPool:      newKeyPool(),
+		rateLimits:   newRateLimiter(),
 		client: &http.Client{
 			Timeout: 120 * time.Second,
 		},
 	}
 }
 
-// ProxyMessage proxies a request to the Anthropic Messages API
-func (s *ProxyService) ProxyMessage(req *model.AnthropicMessageRequest, apiKey string, ipAddress string) (*model.AnthropicMessageResponse, error) {
-	startTime := time.Now()
+// ProxyMessage proxies a request to the Anthropic Messages API.
+// When every channel of the requested model fails, the model's fallback chain is tried in order
+// and the serving model is reported through the fallback header.
+func (s *ProxyService) ProxyMessage(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, sessionID string, header http.Header) (*model.AnthropicMessageResponse, error) {
 	requestID := uuid.New().String()
 
-	// Find model mapping
-	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
-	if err != nil || len(mappings) == 0 {
-		// No mapping found, use the model name as is and get first active channel
-		logger.Debug("No mapping found for model: %s", req.Model)
-		return s.proxyToChannel(req, apiKey, ipAddress, requestID, startTime, nil, req.Model)
+	resp, err := s.proxyMessageModel(req, apiKey, ipAddress, sessionID, requestID)
+	if err == nil || !shouldFallback(err) {
+		return resp, err
 	}
 
-	// Try each mapped channel in priority order
-	for _, mapping := range mappings {
-		if !mapping.IsEnabled {
-			continue
+	for i, fallbackModel := range s.fallbackModels(req.Model, req.AllowedModels) {
+		fallbackReq := *req
+		fallbackReq.Model = fallbackModel
+		fallbackID := fallbackRequestID(requestID, i)
+
+		logger.Info("Model %s failed, falling back to %s", req.Model, fallbackModel)
+		resp, fallbackErr := s.proxyMessageModel(&fallbackReq, apiKey, ipAddress, sessionID, fallbackID)
+		s.recordFallback(fallbackID, req.Model)
+		if fallbackErr == nil {
+			header.Set(model.FallbackModelHeader, fallbackModel)
+			return resp, nil
 		}
-
-		channel, err := s.channelRepo.GetByID(mapping.ChannelID)
-		if err != nil {
-			logger.Error("Failed to get channel %d: %v", mapping.ChannelID, err)
-			continue
+		err = fallbackErr
+		if !shouldFallback(err) {
+			break
 		}
+	}
 
-		if !channel.IsActive {
-			logger.Debug("Channel %d is not active", channel.ID)
-			continue
-		}
+	return nil, err
+}
 
-		resp, err := s.proxyToChannel(req, apiKey, ipAddress, requestID, startTime, channel, mapping.UpstreamModel)
-		if err != nil {
-			logger.Error("Failed to proxy to channel %d: %v", channel.ID, err)
-			continue
+// proxyMessageModel tries each channel mapped to the request's model
+func (s *ProxyService) proxyMessageModel(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, sessionID string, requestID string) (*model.AnthropicMessageResponse, error) {
+	// Find model mapping
+	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only the channel groups the client key is pinned to
+	mappings, accessErr := s.restrictChannelGroups(mappings, req.ChannelGroups, req.Model)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	if len(mappings) == 0 {
+		if err := checkPassthrough(req.ClientKeyID, req.Model); err != nil {
+			return nil, err
 		}
-		return resp, nil
+		// No mapping found, use the model name as is with the caller's key
+		logger.Debug("No mapping found for model: %s", req.Model)
+		return s.proxyToChannel(context.Background(), req, apiKey, ipAddress, requestID, nil, nil, req.Model, nil)
 	}
 
-	return nil, fmt.Errorf("all channels failed for model: %s", req.Model)
+	// Narrow the candidates with content-aware routing rules
+	mappings = s.routeMappings(mappings, anthropicFeatures(req))
+
+	// Keep sessions on the channel and key that served them last
+	sessionKey := anthropicSessionKey(req, sessionID)
+	pin := s.affinity.Get(sessionKey)
+	mappings = preferPinnedChannel(s.healthRepo, mappings, pin)
+
+	// Try each mapped channel in priority order, hedging where the mapping asks for it
+	resp, err := tryChannels(s, mappings, pin, sessionKey, requestID, func(ctx context.Context, requestID string, target *upstreamTarget) (*model.AnthropicMessageResponse, error) {
+		return s.proxyToChannel(ctx, req, apiKey, ipAddress, requestID, target.channel, target.key, target.mapping.UpstreamModel, target.mapping.RewriteRules)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("all channels failed for model %s: %w", req.Model, err)
+	}
+	return resp, nil
 }
 
 // proxyToChannel proxies a request to a specific channel
-func (s *ProxyService) proxyToChannel(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, requestID string, startTime time.Time, channel *model.Channel, upstreamModel string) (*model.AnthropicMessageResponse, error) {
+func (s *ProxyService) proxyToChannel(ctx context.Context, req *model.AnthropicMessageRequest, apiKey string, ipAddress string, requestID string, channel *model.Channel, key *model.ChannelKey, upstreamModel string, rules *model.RewriteRules) (*model.AnthropicMessageResponse, error) {
+	// Each attempt is logged with its own start time and latency
+	startTime := time.Now()
+
 	var channelID int64
 	var baseURL string
 	var apiKeyToUse string
@@ -87,7 +139,7 @@ func (s *ProxyService) proxyToChannel(req *model.AnthropicMessageRequest, apiKey
 	if channel != nil {
 		channelID = channel.ID
 		baseURL = channel.BaseURL
-		apiKeyToUse = channel.APIKey
+		apiKeyToUse = key.APIKey
 		timeout = time.Duration(channel.Timeout) * time.Second
 	} else {
 		// Use the API key from request to find channel
@@ -111,22 +163,34 @@ func (s *ProxyService) proxyToChannel(req *model.AnthropicMessageRequest, apiKey
 		Tools:         req.Tools,
 		ToolChoice:    req.ToolChoice,
 		Metadata:      req.Metadata,
+		Thinking:      req.Thinking,
 	}
 
 	// Marshal request body
-	bodyBytes, err := json.Marshal(proxyReq)
+	bodyBytes, err := marshalAnthropicRequest(proxyReq, rules)
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	// Serve deterministic repeats from the response cache
+	cacheKey := s.cache.Key(req.ClientKeyID, "/v1/messages", bodyBytes, req.NoCache)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		var response model.AnthropicMessageResponse
+		if err := json.Unmarshal(cached, &response); err == nil {
+			s.logCacheHit(channelID, requestID, req.Model, upstreamModel, startTime, cached, ipAddress, req.ClientKeyID)
+			s.presentAnthropicResponse(&response, req.Model, requestID)
+			return &response, nil
+		}
+	}
+
 	// Create HTTP request with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
@@ -138,53 +202,101 @@ func (s *ProxyService) proxyToChannel(req *model.AnthropicMessageRequest, apiKey
 	// Make request
 	httpResp, err := s.client.Do(httpReq)
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "http_request", err.Error(), ipAddress)
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "http_request"), err.Error(), ipAddress, req.ClientKeyID)
+		return nil, transportError("failed to make request", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Read response body
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "read_response", err.Error(), ipAddress)
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "read_response"), err.Error(), ipAddress, req.ClientKeyID)
+		return nil, transportError("failed to read response", err)
 	}
 
 	// Check for error response
 	if httpResp.StatusCode != http.StatusOK {
-		var errResp model.AnthropicErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil {
-			s.logError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, errResp.Error.Type, errResp.Error.Message, ipAddress)
-			return nil, fmt.Errorf("API error: %s - %s", errResp.Error.Type, errResp.Error.Message)
-		}
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "unknown", string(respBody), ipAddress)
-		return nil, fmt.Errorf("API error: %s", string(respBody))
+		s.keyPool.ReportErrorResponse(key, httpResp.StatusCode, respBody)
+		upstreamErr := newUpstreamError(httpResp, respBody)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, upstreamErr.Type, upstreamErr.Message, ipAddress, req.ClientKeyID)
+		return nil, upstreamErr
 	}
 
 	// Parse success response
 	var response model.AnthropicMessageResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "parse_response", err.Error(), ipAddress)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, "parse_response", err.Error(), ipAddress, req.ClientKeyID)
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	s.cache.Set(cacheKey, respBody)
+
 	// Log successful request
-	s.logSuccess(channelID, requestID, req.Model, upstreamModel, startTime, &response, ipAddress)
+	s.logSuccess(channelID, key, requestID, req.Model, upstreamModel, startTime, &response, ipAddress, req.ClientKeyID)
 
+	s.presentAnthropicResponse(&response, req.Model, requestID)
 	return &response, nil
 }
 
-// ProxyMessageStream proxies a streaming request
-func (s *ProxyService) ProxyMessageStream(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, w http.ResponseWriter) error {
-	startTime := time.Now()
+// ProxyMessageStream proxies a streaming request, falling back along the model's
+// fallback chain as long as nothing has been written to the client
+func (s *ProxyService) ProxyMessageStream(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, sessionID string, w http.ResponseWriter) error {
 	requestID := uuid.New().String()
 
+	err := s.proxyMessageStreamModel(req, apiKey, ipAddress, sessionID, requestID, w)
+	if err == nil || responseStarted(w) || !shouldFallback(err) {
+		return err
+	}
+
+	for i, fallbackModel := range s.fallbackModels(req.Model, req.AllowedModels) {
+		fallbackReq := *req
+		fallbackReq.Model = fallbackModel
+		fallbackID := fallbackRequestID(requestID, i)
+
+		logger.Info("Model %s failed, falling back to %s", req.Model, fallbackModel)
+		w.Header().Set(model.FallbackModelHeader, fallbackModel)
+		err = s.proxyMessageStreamModel(&fallbackReq, apiKey, ipAddress, sessionID, fallbackID, w)
+		s.recordFallback(fallbackID, req.Model)
+		if err == nil || responseStarted(w) || !shouldFallback(err) {
+			break
+		}
+	}
+
+	if err != nil && !responseStarted(w) {
+		w.Header().Del(model.FallbackModelHeader)
+	}
+	return err
+}
+
+// proxyMessageStreamModel tries each channel mapped to the request's model
+func (s *ProxyService) proxyMessageStreamModel(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, sessionID string, requestID string, w http.ResponseWriter) error {
 	// Find model mapping
 	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
-	if err != nil || len(mappings) == 0 {
-		return s.proxyStreamToChannel(req, apiKey, ipAddress, requestID, startTime, nil, req.Model, w)
+	if err != nil {
+		return err
+	}
+
+	// Keep only the channel groups the client key is pinned to
+	mappings, accessErr := s.restrictChannelGroups(mappings, req.ChannelGroups, req.Model)
+	if accessErr != nil {
+		return accessErr
+	}
+
+	if len(mappings) == 0 {
+		if err := checkPassthrough(req.ClientKeyID, req.Model); err != nil {
+			return err
+		}
+		return s.proxyStreamToChannel(req, apiKey, ipAddress, requestID, nil, nil, req.Model, nil, w)
 	}
 
+	// Narrow the candidates with content-aware routing rules
+	mappings = s.routeMappings(mappings, anthropicFeatures(req))
+
+	// Keep sessions on the channel and key that served them last
+	sessionKey := anthropicSessionKey(req, sessionID)
+	pin := s.affinity.Get(sessionKey)
+	mappings = preferPinnedChannel(s.healthRepo, mappings, pin)
+
 	// Track if we've started writing to response
 	// Once headers are written, we cannot try another channel
 	var lastErr error
@@ -200,7 +312,11 @@ func (s *ProxyService) ProxyMessageStream(req *model.AnthropicMessageRequest, ap
 			continue
 		}
 
-		err = s.proxyStreamToChannel(req, apiKey, ipAddress, requestID, startTime, channel, mapping.UpstreamModel, w)
+		key := s.selectKey(channel, pin)
+		if key == nil {
+			continue
+		}
+		err = s.proxyStreamToChannel(req, apiKey, ipAddress, requestID, channel, key, mapping.UpstreamModel, mapping.RewriteRules, w)
 		if err != nil {
 			logger.Error("Stream proxy to channel %d failed: %v", channel.ID, err)
 			lastErr = err
@@ -210,19 +326,26 @@ func (s *ProxyService) ProxyMessageStream(req *model.AnthropicMessageRequest, ap
 				// Response already started, cannot switch channels
 				return err
 			}
+			if !retryable(err) {
+				return err
+			}
 			continue
 		}
+		s.affinity.Pin(sessionKey, channel.ID, key.ID)
 		return nil
 	}
 
 	if lastErr != nil {
 		return lastErr
 	}
-	return fmt.Errorf("all channels failed for streaming model: %s", req.Model)
+	return fmt.Errorf("all channels failed for streaming model %s: %w", req.Model, errNoChannel)
 }
 
 // proxyStreamToChannel proxies a streaming request to a specific channel
-func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, requestID string, startTime time.Time, channel *model.Channel, upstreamModel string, w http.ResponseWriter) error {
+func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest, apiKey string, ipAddress string, requestID string, channel *model.Channel, key *model.ChannelKey, upstreamModel string, rules *model.RewriteRules, w http.ResponseWriter) error {
+	// Each attempt is logged with its own start time and latency
+	startTime := time.Now()
+
 	var channelID int64
 	var baseURL string
 	var apiKeyToUse string
@@ -231,7 +354,7 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 	if channel != nil {
 		channelID = channel.ID
 		baseURL = channel.BaseURL
-		apiKeyToUse = channel.APIKey
+		apiKeyToUse = key.APIKey
 		timeout = time.Duration(channel.Timeout) * time.Second
 	} else {
 		channelID = 0
@@ -254,20 +377,28 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 		Tools:         req.Tools,
 		ToolChoice:    req.ToolChoice,
 		Metadata:      req.Metadata,
+		Thinking:      req.Thinking,
 	}
 
-	bodyBytes, err := json.Marshal(proxyReq)
+	bodyBytes, err := marshalAnthropicRequest(proxyReq, rules)
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	// Replay deterministic repeats from the response cache
+	cacheKey := s.cache.Key(req.ClientKeyID, "/v1/messages", bodyBytes, req.NoCache)
+	if cached, ok := s.cache.Get(cacheKey); ok && s.replayAnthropicStream(w, cached, req.Model, requestID) == nil {
+		s.logCacheHit(channelID, requestID, req.Model, upstreamModel, startTime, cached, ipAddress, req.ClientKeyID)
+		return nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -276,21 +407,18 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 
 	httpResp, err := s.client.Do(httpReq)
 	if err != nil {
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "http_request", err.Error(), ipAddress)
-		return err
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "http_request"), err.Error(), ipAddress, req.ClientKeyID)
+		return transportError("failed to make request", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Check for error response before streaming
 	if httpResp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(httpResp.Body)
-		var errResp model.AnthropicErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil {
-			s.logError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, errResp.Error.Type, errResp.Error.Message, ipAddress)
-			return fmt.Errorf("API error: %s - %s", errResp.Error.Type, errResp.Error.Message)
-		}
-		s.logError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "unknown", string(respBody), ipAddress)
-		return fmt.Errorf("API error: status %d, response: %s", httpResp.StatusCode, string(respBody))
+		s.keyPool.ReportErrorResponse(key, httpResp.StatusCode, respBody)
+		upstreamErr := newUpstreamError(httpResp, respBody)
+		s.logError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, upstreamErr.Type, upstreamErr.Message, ipAddress, req.ClientKeyID)
+		return upstreamErr
 	}
 
 	// Set streaming headers
@@ -304,12 +432,15 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 		return fmt.Errorf("streaming not supported")
 	}
 
-	inputTokens := 0
-	outputTokens := 0
+	meter := s.newStreamMeter(req.Quota, upstreamModel)
+	upstreamMessageID := ""
+
+	// Rebuild the message from the stream so it can fill the response cache
+	body, capture := s.cache.captureStream(cacheKey, httpResp.Body, false)
 
 	// Use SSE line scanner instead of JSON decoder
 	// Anthropic streaming uses SSE format: "event: xxx\ndata: {...}\n\n"
-	scanner := newLineScanner(httpResp.Body)
+	scanner := newLineScanner(body)
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -336,8 +467,19 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 				continue
 			}
 
+			// Show the display model instead of the upstream's in message_start
+			if strings.Contains(dataStr, `"message_start"`) {
+				var upstreamID string
+				dataStr, upstreamID = s.presentStreamData(dataStr, req.Model, requestID)
+				if upstreamID != "" {
+					upstreamMessageID = upstreamID
+				}
+				line = "data: " + dataStr
+			}
+
 			// Parse JSON to track token usage
 			var event map[string]interface{}
+			var quotaErr error
 			if err := json.Unmarshal([]byte(dataStr), &event); err == nil {
 				// Track token usage
 				if eventType, ok := event["type"].(string); ok {
@@ -347,20 +489,26 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 						latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 
 						log := &model.RequestLog{
-							ChannelID:     channelID,
-							RequestID:     requestID,
-							ModelName:     req.Model,
-							UpstreamModel: upstreamModel,
-							InputTokens:   inputTokens,
-							OutputTokens:  outputTokens,
-							TotalTokens:   inputTokens + outputTokens,
-							RequestTime:   startTime,
-							ResponseTime:  &responseTime,
-							LatencyMs:     latencyMs,
-							Status:        "success",
-							IPAddress:     ipAddress,
+							ChannelID:         channelID,
+							ChannelKeyID:      channelKeyID(key),
+							APIKeyMasked:      maskedKey(key),
+							RequestID:         requestID,
+							ModelName:         req.Model,
+							UpstreamModel:     upstreamModel,
+							UpstreamMessageID: upstreamMessageID,
+							InputTokens:       meter.inputTokens,
+							OutputTokens:      meter.output(),
+							TotalTokens:       meter.inputTokens + meter.output(),
+							Cost:              meter.cost(),
+							RequestTime:       startTime,
+							ResponseTime:      &responseTime,
+							LatencyMs:         latencyMs,
+							Status:            "success",
+							IPAddress:         ipAddress,
+							ClientKeyID:       req.ClientKeyID,
 						}
 						s.logRepo.Create(log)
+						s.rateLimits.AddTokens(req.ClientKeyID, log.TotalTokens)
 					}
 				}
 
@@ -368,7 +516,7 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 				if msg, ok := event["message"].(map[string]interface{}); ok {
 					if u, ok := msg["usage"].(map[string]interface{}); ok {
 						if input, ok := u["input_tokens"].(float64); ok {
-							inputTokens = int(input)
+							meter.inputTokens = int(input)
 						}
 					}
 				}
@@ -378,10 +526,16 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 					if eventType == "message_delta" {
 						if usage, ok := event["usage"].(map[string]interface{}); ok {
 							if output, ok := usage["output_tokens"].(float64); ok {
-								outputTokens = int(output)
+								meter.outputTokens = int(output)
 							}
 						}
 					}
+
+					// Enforce the client key's quota as the generation grows
+					if eventType == "message_start" || eventType == "content_block_delta" {
+						meter.addText(deltaText(event))
+						quotaErr = meter.check()
+					}
 				}
 			}
 
@@ -389,39 +543,51 @@ func (s *ProxyService) proxyStreamToChannel(req *model.AnthropicMessageRequest,
 			w.Write([]byte(line))
 			w.Write([]byte("\n\n"))
 			flusher.Flush()
+
+			if quotaErr != nil {
+				s.logQuotaExceeded(channelID, key, requestID, req.Model, upstreamModel, upstreamMessageID, startTime, meter, quotaErr, ipAddress, req.ClientKeyID)
+				return quotaErr
+			}
 		}
 	}
 
+	s.cache.storeStream(cacheKey, capture)
 	return nil
 }
 
 // logSuccess logs a successful request
-func (s *ProxyService) logSuccess(channelID int64, requestID, modelName, upstreamModel string, startTime time.Time, resp *model.AnthropicMessageResponse, ipAddress string) {
+func (s *ProxyService) logSuccess(channelID int64, key *model.ChannelKey, requestID, modelName, upstreamModel string, startTime time.Time, resp *model.AnthropicMessageResponse, ipAddress string, clientKeyID int64) {
 	responseTime := time.Now()
 	latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 
 	log := &model.RequestLog{
-		ChannelID:     channelID,
-		RequestID:     requestID,
-		ModelName:     modelName,
-		UpstreamModel: upstreamModel,
-		InputTokens:   resp.Usage.InputTokens,
-		OutputTokens:  resp.Usage.OutputTokens,
-		TotalTokens:   resp.Usage.InputTokens + resp.Usage.OutputTokens,
-		RequestTime:   startTime,
-		ResponseTime:  &responseTime,
-		LatencyMs:     latencyMs,
-		Status:        "success",
-		IPAddress:     ipAddress,
+		ChannelID:         channelID,
+		ChannelKeyID:      channelKeyID(key),
+		APIKeyMasked:      maskedKey(key),
+		RequestID:         requestID,
+		ModelName:         modelName,
+		UpstreamModel:     upstreamModel,
+		UpstreamMessageID: resp.ID,
+		InputTokens:       resp.Usage.InputTokens,
+		OutputTokens:      resp.Usage.OutputTokens,
+		TotalTokens:       resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		Cost:              s.modelPrice(upstreamModel).Cost(resp.Usage.InputTokens, resp.Usage.OutputTokens),
+		RequestTime:       startTime,
+		ResponseTime:      &responseTime,
+		LatencyMs:         latencyMs,
+		Status:            "success",
+		IPAddress:         ipAddress,
+		ClientKeyID:       clientKeyID,
 	}
 
 	if _, err := s.logRepo.Create(log); err != nil {
 		logger.Error("Failed to create log: %v", err)
 	}
+	s.rateLimits.AddTokens(clientKeyID, log.TotalTokens)
 }
 
 // logError logs a failed request
-func (s *ProxyService) logError(channelID int64, requestID, modelName, upstreamModel string, startTime time.Time, httpResp *http.Response, errorCode, errorMessage, ipAddress string) {
+func (s *ProxyService) logError(channelID int64, key *model.ChannelKey, requestID, modelName, upstreamModel string, startTime time.Time, httpResp *http.Response, errorCode, errorMessage, ipAddress string, clientKeyID int64) {
 	responseTime := time.Now()
 	latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 
@@ -432,6 +598,8 @@ func (s *ProxyService) logError(channelID int64, requestID, modelName, upstreamM
 
 	log := &model.RequestLog{
 		ChannelID:     channelID,
+		ChannelKeyID:  channelKeyID(key),
+		APIKeyMasked:  maskedKey(key),
 		RequestID:     requestID,
 		ModelName:     modelName,
 		UpstreamModel: upstreamModel,
@@ -442,6 +610,7 @@ func (s *ProxyService) logError(channelID int64, requestID, modelName, upstreamM
 		ErrorCode:     errorCode,
 		ErrorMessage:  errorMessage,
 		IPAddress:     ipAddress,
+		ClientKeyID:   clientKeyID,
 	}
 
 	if statusCode != "" {
@@ -453,81 +622,149 @@ func (s *ProxyService) logError(channelID int64, requestID, modelName, upstreamM
 	}
 }
 
-// TestChannel tests a channel connection
-func (s *ProxyService) TestChannel(baseURL, apiKey string) error {
-	req := &model.AnthropicMessageRequest{
-		Model:     "claude-3-haiku-20240307",
-		MaxTokens: 10,
-		Messages: []model.Message{
-			{Role: "user", Content: "Hi"},
-		},
+// TestChannel tests an unsaved channel with a non-streaming and a streaming probe
+func (s *ProxyService) TestChannel(req *model.ChannelTestRequest) (*model.ChannelTestResult, error) {
+	target := probeTarget{
+		BaseURL:  strings.TrimRight(req.BaseURL, "/"),
+		APIKey:   req.APIKey,
+		Provider: req.Provider,
+		Model:    req.Model,
+	}
+	if target.Provider == "" {
+		target.Provider = "anthropic"
+	}
+	if target.Model == "" {
+		target.Model = defaultProbeModels[target.Provider]
+	}
+	if target.Model == "" {
+		return nil, fmt.Errorf("model is required for provider: %s", target.Provider)
 	}
 
-	bodyBytes, _ := json.Marshal(req)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	return s.testTarget(target), nil
+}
 
-	httpResp, err := s.client.Do(httpReq)
+// TestChannelByID tests a saved channel using its base URL and key pool.
+// The model defaults to the channel's first enabled mapping.
+func (s *ProxyService) TestChannelByID(id int64, req *model.ChannelTestByIDRequest) (*model.ChannelTestResult, error) {
+	channel, err := s.channelRepo.GetByID(id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	key := s.keyPool.Peek(channel)
+	if key == nil {
+		return nil, errNoUsableKey
 	}
-	defer httpResp.Body.Close()
 
-	if httpResp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(httpResp.Body)
-		return fmt.Errorf("status %d: %s", httpResp.StatusCode, string(respBody))
+	target := probeTarget{
+		BaseURL:  channel.BaseURL,
+		APIKey:   key.APIKey,
+		Provider: req.Provider,
+		Model:    req.Model,
+	}
+	if target.Provider == "" {
+		target.Provider = channel.Provider
+	}
+	if target.Model == "" {
+		target.Model = mappedProbeModel(s.mappingRepo, channel.ID)
+	}
+	if target.Model == "" {
+		target.Model = defaultProbeModels[target.Provider]
+	}
+	if target.Model == "" {
+		return nil, fmt.Errorf("model is required for provider: %s", target.Provider)
 	}
 
-	return nil
+	return s.testTarget(target), nil
 }
 
-// ProxyChat proxies an OpenAI format chat completion request
-func (s *ProxyService) ProxyChat(req *model.OpenAIChatRequest, apiKey string, ipAddress string) (*model.OpenAIChatResponse, error) {
-	startTime := time.Now()
+// testTarget runs the non-streaming and streaming probes for a channel test
+func (s *ProxyService) testTarget(target probeTarget) *model.ChannelTestResult {
+	result := &model.ChannelTestResult{
+		Provider:     target.Provider,
+		Model:        target.Model,
+		NonStreaming: runProbe(s.client, target, false, 16),
+		Streaming:    runProbe(s.client, target, true, 16),
+	}
+	result.Success = result.NonStreaming.Success && result.Streaming.Success
+	return result
+}
+
+// ProxyChat proxies an OpenAI format chat completion request, falling back along
+// the model's fallback chain when every channel fails
+func (s *ProxyService) ProxyChat(req *model.OpenAIChatRequest, apiKey string, ipAddress string, sessionID string, header http.Header) (*model.OpenAIChatResponse, error) {
 	requestID := uuid.New().String()
 
-	// Find model mapping
-	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
-	if err != nil || len(mappings) == 0 {
-		// No mapping found, proxy directly
-		return s.proxyChatToChannel(req, apiKey, ipAddress, requestID, startTime, nil, req.Model)
+	resp, err := s.proxyChatModel(req, apiKey, ipAddress, sessionID, requestID)
+	if err == nil || !shouldFallback(err) {
+		return resp, err
 	}
 
-	// Try each mapped channel in priority order
-	for _, mapping := range mappings {
-		if !mapping.IsEnabled {
-			continue
+	for i, fallbackModel := range s.fallbackModels(req.Model, req.AllowedModels) {
+		fallbackReq := *req
+		fallbackReq.Model = fallbackModel
+		fallbackID := fallbackRequestID(requestID, i)
+
+		logger.Info("Model %s failed, falling back to %s", req.Model, fallbackModel)
+		resp, fallbackErr := s.proxyChatModel(&fallbackReq, apiKey, ipAddress, sessionID, fallbackID)
+		s.recordFallback(fallbackID, req.Model)
+		if fallbackErr == nil {
+			header.Set(model.FallbackModelHeader, fallbackModel)
+			return resp, nil
 		}
-
-		channel, err := s.channelRepo.GetByID(mapping.ChannelID)
-		if err != nil {
-			logger.Error("Failed to get channel %d: %v", mapping.ChannelID, err)
-			continue
+		err = fallbackErr
+		if !shouldFallback(err) {
+			break
 		}
+	}
 
-		if !channel.IsActive {
-			logger.Debug("Channel %d is not active", channel.ID)
-			continue
-		}
+	return nil, err
+}
 
-		resp, err := s.proxyChatToChannel(req, apiKey, ipAddress, requestID, startTime, channel, mapping.UpstreamModel)
-		if err != nil {
-			logger.Error("Failed to proxy chat to channel %d: %v", channel.ID, err)
-			continue
+// proxyChatModel tries each channel mapped to the request's model
+func (s *ProxyService) proxyChatModel(req *model.OpenAIChatRequest, apiKey string, ipAddress string, sessionID string, requestID string) (*model.OpenAIChatResponse, error) {
+	// Find model mapping
+	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only the channel groups the client key is pinned to
+	mappings, accessErr := s.restrictChannelGroups(mappings, req.ChannelGroups, req.Model)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	if len(mappings) == 0 {
+		if err := checkPassthrough(req.ClientKeyID, req.Model); err != nil {
+			return nil, err
 		}
-		return resp, nil
+		// No mapping found, proxy directly with the caller's key
+		return s.proxyChatToChannel(context.Background(), req, apiKey, ipAddress, requestID, nil, nil, req.Model, nil)
 	}
 
-	return nil, fmt.Errorf("all channels failed for model: %s", req.Model)
+	// Narrow the candidates with content-aware routing rules
+	mappings = s.routeMappings(mappings, openAIFeatures(req))
+
+	// Keep sessions on the channel and key that served them last
+	sessionKey := openAISessionKey(req, sessionID)
+	pin := s.affinity.Get(sessionKey)
+	mappings = preferPinnedChannel(s.healthRepo, mappings, pin)
+
+	// Try each mapped channel in priority order, hedging where the mapping asks for it
+	resp, err := tryChannels(s, mappings, pin, sessionKey, requestID, func(ctx context.Context, requestID string, target *upstreamTarget) (*model.OpenAIChatResponse, error) {
+		return s.proxyChatToChannel(ctx, req, apiKey, ipAddress, requestID, target.channel, target.key, target.mapping.UpstreamModel, target.mapping.RewriteRules)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("all channels failed for model %s: %w", req.Model, err)
+	}
+	return resp, nil
 }
 
 // proxyChatToChannel proxies OpenAI chat request to a specific channel
-func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey string, ipAddress string, requestID string, startTime time.Time, channel *model.Channel, upstreamModel string) (*model.OpenAIChatResponse, error) {
+func (s *ProxyService) proxyChatToChannel(ctx context.Context, req *model.OpenAIChatRequest, apiKey string, ipAddress string, requestID string, channel *model.Channel, key *model.ChannelKey, upstreamModel string, rules *model.RewriteRules) (*model.OpenAIChatResponse, error) {
+	// Each attempt is logged with its own start time and latency
+	startTime := time.Now()
+
 	var channelID int64
 	var baseURL string
 	var apiKeyToUse string
@@ -537,7 +774,7 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 	if channel != nil {
 		channelID = channel.ID
 		baseURL = channel.BaseURL
-		apiKeyToUse = channel.APIKey
+		apiKeyToUse = key.APIKey
 		timeout = time.Duration(channel.Timeout) * time.Second
 		provider = channel.Provider
 	} else {
@@ -548,10 +785,11 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 		provider = "anthropic"
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var httpReq *http.Request
+	var cacheKey string
 	var err error
 
 	// Choose API format based on provider
@@ -559,15 +797,16 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 		// Convert OpenAI format to Anthropic format
 		anthropicReq := s.convertOpenAIToAnthropic(req, upstreamModel)
 
-		bodyBytes, err := json.Marshal(anthropicReq)
+		bodyBytes, err := marshalAnthropicRequest(anthropicReq, rules)
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
+		cacheKey = s.cache.Key(req.ClientKeyID, "/v1/messages", bodyBytes, req.NoCache)
 
 		httpReq, err = http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
@@ -577,14 +816,16 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 	} else {
 		// Use OpenAI format directly
 		proxyReq := &model.OpenAIChatRequest{
-			Model:       upstreamModel,
-			Messages:    req.Messages,
-			Temperature: req.Temperature,
-			TopP:        req.TopP,
-			Stream:      false,
-			Stop:        req.Stop,
-			Tools:       req.Tools,
-			ToolChoice:  req.ToolChoice,
+			Model:           upstreamModel,
+			Messages:        req.Messages,
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			Stream:          false,
+			Stop:            req.Stop,
+			Tools:           req.Tools,
+			ToolChoice:      req.ToolChoice,
+			ReasoningEffort: req.ReasoningEffort,
+			User:            req.User,
 		}
 
 		if req.MaxTokens != nil {
@@ -594,15 +835,16 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 			proxyReq.MaxTokens = &defaultTokens
 		}
 
-		bodyBytes, err := json.Marshal(proxyReq)
+		bodyBytes, err := marshalOpenAIRequest(proxyReq, rules)
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
+		cacheKey = s.cache.Key(req.ClientKeyID, "/v1/chat/completions", bodyBytes, req.NoCache)
 
 		httpReq, err = http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/chat/completions", bytes.NewReader(bodyBytes))
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
@@ -610,47 +852,67 @@ func (s *ProxyService) proxyChatToChannel(req *model.OpenAIChatRequest, apiKey s
 		httpReq.Header.Set("Authorization", "Bearer "+apiKeyToUse)
 	}
 
+	// Serve deterministic repeats from the response cache
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		if response, err := s.parseChatResponse(provider, cached, req.Model); err == nil {
+			s.logCacheHit(channelID, requestID, req.Model, upstreamModel, startTime, cached, ipAddress, req.ClientKeyID)
+			s.presentChatResponse(response, req.Model, requestID)
+			return response, nil
+		}
+	}
+
 	// Make request
 	httpResp, err := s.client.Do(httpReq)
 	if err != nil {
-		s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "http_request", err.Error(), ipAddress)
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "http_request"), err.Error(), ipAddress, req.ClientKeyID)
+		return nil, transportError("failed to make request", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Read response body
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "read_response", err.Error(), ipAddress)
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "read_response"), err.Error(), ipAddress, req.ClientKeyID)
+		return nil, transportError("failed to read response", err)
 	}
 
 	// Check for error response
 	if httpResp.StatusCode != http.StatusOK {
-		s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "api_error", string(respBody), ipAddress)
-		return nil, fmt.Errorf("API error: status %d, response: %s", httpResp.StatusCode, string(respBody))
+		s.keyPool.ReportErrorResponse(key, httpResp.StatusCode, respBody)
+		upstreamErr := newUpstreamError(httpResp, respBody)
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, upstreamErr.Type, upstreamErr.Message, ipAddress, req.ClientKeyID)
+		return nil, upstreamErr
 	}
 
 	// Parse response based on provider
-	var response *model.OpenAIChatResponse
+	response, err := s.parseChatResponse(provider, respBody, req.Model)
+	if err != nil {
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, "parse_response", err.Error(), ipAddress, req.ClientKeyID)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.Set(cacheKey, respBody)
+
+	// Log successful request
+	s.logChatSuccess(channelID, key, requestID, req.Model, upstreamModel, startTime, response, ipAddress, req.ClientKeyID)
+
+	s.presentChatResponse(response, req.Model, requestID)
+	return response, nil
+}
+
+// parseChatResponse decodes an upstream chat response, converting Anthropic responses to OpenAI format
+func (s *ProxyService) parseChatResponse(provider string, respBody []byte, displayModel string) (*model.OpenAIChatResponse, error) {
 	if provider == "anthropic" {
-		// Convert Anthropic response to OpenAI format
 		var anthropicResp model.AnthropicMessageResponse
 		if err := json.Unmarshal(respBody, &anthropicResp); err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "parse_response", err.Error(), ipAddress)
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-		response = s.convertAnthropicToOpenAI(&anthropicResp, req.Model)
-	} else {
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "parse_response", err.Error(), ipAddress)
-			return nil, fmt.Errorf("failed to parse response: %w", err)
+			return nil, err
 		}
+		return s.convertAnthropicToOpenAI(&anthropicResp, displayModel), nil
 	}
 
-	// Log successful request
-	s.logChatSuccess(channelID, requestID, req.Model, upstreamModel, startTime, response, ipAddress)
-
+	var response *model.OpenAIChatResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
 	return response, nil
 }
 
@@ -749,17 +1011,65 @@ func (s *ProxyService) convertAnthropicToOpenAI(resp *model.AnthropicMessageResp
 	}
 }
 
-// ProxyChatStream proxies an OpenAI streaming chat completion request
-func (s *ProxyService) ProxyChatStream(req *model.OpenAIChatRequest, apiKey string, ipAddress string, w http.ResponseWriter) error {
-	startTime := time.Now()
+// ProxyChatStream proxies an OpenAI streaming chat completion request, falling back
+// along the model's fallback chain as long as nothing has been written to the client
+func (s *ProxyService) ProxyChatStream(req *model.OpenAIChatRequest, apiKey string, ipAddress string, sessionID string, w http.ResponseWriter) error {
 	requestID := uuid.New().String()
 
+	err := s.proxyChatStreamModel(req, apiKey, ipAddress, sessionID, requestID, w)
+	if err == nil || responseStarted(w) || !shouldFallback(err) {
+		return err
+	}
+
+	for i, fallbackModel := range s.fallbackModels(req.Model, req.AllowedModels) {
+		fallbackReq := *req
+		fallbackReq.Model = fallbackModel
+		fallbackID := fallbackRequestID(requestID, i)
+
+		logger.Info("Model %s failed, falling back to %s", req.Model, fallbackModel)
+		w.Header().Set(model.FallbackModelHeader, fallbackModel)
+		err = s.proxyChatStreamModel(&fallbackReq, apiKey, ipAddress, sessionID, fallbackID, w)
+		s.recordFallback(fallbackID, req.Model)
+		if err == nil || responseStarted(w) || !shouldFallback(err) {
+			break
+		}
+	}
+
+	if err != nil && !responseStarted(w) {
+		w.Header().Del(model.FallbackModelHeader)
+	}
+	return err
+}
+
+// proxyChatStreamModel tries each channel mapped to the request's model
+func (s *ProxyService) proxyChatStreamModel(req *model.OpenAIChatRequest, apiKey string, ipAddress string, sessionID string, requestID string, w http.ResponseWriter) error {
 	// Find model mapping
 	mappings, err := s.mappingRepo.FindByDisplayModel(req.Model)
-	if err != nil || len(mappings) == 0 {
-		return s.proxyChatStreamToChannel(req, apiKey, ipAddress, requestID, startTime, nil, req.Model, w)
+	if err != nil {
+		return err
 	}
 
+	// Keep only the channel groups the client key is pinned to
+	mappings, accessErr := s.restrictChannelGroups(mappings, req.ChannelGroups, req.Model)
+	if accessErr != nil {
+		return accessErr
+	}
+
+	if len(mappings) == 0 {
+		if err := checkPassthrough(req.ClientKeyID, req.Model); err != nil {
+			return err
+		}
+		return s.proxyChatStreamToChannel(req, apiKey, ipAddress, requestID, nil, nil, req.Model, nil, w)
+	}
+
+	// Narrow the candidates with content-aware routing rules
+	mappings = s.routeMappings(mappings, openAIFeatures(req))
+
+	// Keep sessions on the channel and key that served them last
+	sessionKey := openAISessionKey(req, sessionID)
+	pin := s.affinity.Get(sessionKey)
+	mappings = preferPinnedChannel(s.healthRepo, mappings, pin)
+
 	// Track if we've started writing to response
 	var lastErr error
 
@@ -774,7 +1084,11 @@ func (s *ProxyService) ProxyChatStream(req *model.OpenAIChatRequest, apiKey stri
 			continue
 		}
 
-		err = s.proxyChatStreamToChannel(req, apiKey, ipAddress, requestID, startTime, channel, mapping.UpstreamModel, w)
+		key := s.selectKey(channel, pin)
+		if key == nil {
+			continue
+		}
+		err = s.proxyChatStreamToChannel(req, apiKey, ipAddress, requestID, channel, key, mapping.UpstreamModel, mapping.RewriteRules, w)
 		if err != nil {
 			logger.Error("Chat stream proxy to channel %d failed: %v", channel.ID, err)
 			lastErr = err
@@ -782,19 +1096,26 @@ func (s *ProxyService) ProxyChatStream(req *model.OpenAIChatRequest, apiKey stri
 			if rw, ok := w.(interface{ Written() bool }); ok && rw.Written() {
 				return err
 			}
+			if !retryable(err) {
+				return err
+			}
 			continue
 		}
+		s.affinity.Pin(sessionKey, channel.ID, key.ID)
 		return nil
 	}
 
 	if lastErr != nil {
 		return lastErr
 	}
-	return fmt.Errorf("all channels failed for streaming model: %s", req.Model)
+	return fmt.Errorf("all channels failed for streaming model %s: %w", req.Model, errNoChannel)
 }
 
 // proxyChatStreamToChannel proxies OpenAI streaming request to a specific channel
-func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, apiKey string, ipAddress string, requestID string, startTime time.Time, channel *model.Channel, upstreamModel string, w http.ResponseWriter) error {
+func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, apiKey string, ipAddress string, requestID string, channel *model.Channel, key *model.ChannelKey, upstreamModel string, rules *model.RewriteRules, w http.ResponseWriter) error {
+	// Each attempt is logged with its own start time and latency
+	startTime := time.Now()
+
 	var channelID int64
 	var baseURL string
 	var apiKeyToUse string
@@ -804,7 +1125,7 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 	if channel != nil {
 		channelID = channel.ID
 		baseURL = channel.BaseURL
-		apiKeyToUse = channel.APIKey
+		apiKeyToUse = key.APIKey
 		timeout = time.Duration(channel.Timeout) * time.Second
 		provider = channel.Provider
 	} else {
@@ -819,6 +1140,7 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 	defer cancel()
 
 	var httpReq *http.Request
+	var cacheKey string
 	var err error
 
 	// Choose API format based on provider
@@ -827,15 +1149,16 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 		anthropicReq := s.convertOpenAIToAnthropic(req, upstreamModel)
 		anthropicReq.Stream = true
 
-		bodyBytes, err := json.Marshal(anthropicReq)
+		bodyBytes, err := marshalAnthropicRequest(anthropicReq, rules)
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
+		cacheKey = s.cache.Key(req.ClientKeyID, "/v1/messages", bodyBytes, req.NoCache)
 
 		httpReq, err = http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 			return fmt.Errorf("failed to create request: %w", err)
 		}
 
@@ -845,14 +1168,16 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 	} else {
 		// Use OpenAI format directly
 		proxyReq := &model.OpenAIChatRequest{
-			Model:       upstreamModel,
-			Messages:    req.Messages,
-			Temperature: req.Temperature,
-			TopP:        req.TopP,
-			Stream:      true,
-			Stop:        req.Stop,
-			Tools:       req.Tools,
-			ToolChoice:  req.ToolChoice,
+			Model:           upstreamModel,
+			Messages:        req.Messages,
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			Stream:          true,
+			Stop:            req.Stop,
+			Tools:           req.Tools,
+			ToolChoice:      req.ToolChoice,
+			ReasoningEffort: req.ReasoningEffort,
+			User:            req.User,
 		}
 
 		if req.MaxTokens != nil {
@@ -862,15 +1187,16 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 			proxyReq.MaxTokens = &defaultTokens
 		}
 
-		bodyBytes, err := json.Marshal(proxyReq)
+		bodyBytes, err := marshalOpenAIRequest(proxyReq, rules)
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "marshal_request", err.Error(), ipAddress, req.ClientKeyID)
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
+		cacheKey = s.cache.Key(req.ClientKeyID, "/v1/chat/completions", bodyBytes, req.NoCache)
 
 		httpReq, err = http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/chat/completions", bytes.NewReader(bodyBytes))
 		if err != nil {
-			s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress)
+			s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, "create_request", err.Error(), ipAddress, req.ClientKeyID)
 			return fmt.Errorf("failed to create request: %w", err)
 		}
 
@@ -878,18 +1204,33 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 		httpReq.Header.Set("Authorization", "Bearer "+apiKeyToUse)
 	}
 
+	// Replay deterministic repeats from the response cache
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		err := s.replayChatStream(w, provider, cached, req.Model, requestID)
+		if err == nil {
+			s.logCacheHit(channelID, requestID, req.Model, upstreamModel, startTime, cached, ipAddress, req.ClientKeyID)
+			return nil
+		}
+		if responseStarted(w) {
+			logger.Error("Failed to replay cached response: %v", err)
+			return err
+		}
+	}
+
 	httpResp, err := s.client.Do(httpReq)
 	if err != nil {
-		s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, nil, "http_request", err.Error(), ipAddress)
-		return err
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, nil, transportErrorCode(ctx, "http_request"), err.Error(), ipAddress, req.ClientKeyID)
+		return transportError("failed to make request", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Check for error response before streaming
 	if httpResp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(httpResp.Body)
-		s.logChatError(channelID, requestID, req.Model, upstreamModel, startTime, httpResp, "api_error", string(respBody), ipAddress)
-		return fmt.Errorf("API error: status %d, response: %s", httpResp.StatusCode, string(respBody))
+		s.keyPool.ReportErrorResponse(key, httpResp.StatusCode, respBody)
+		upstreamErr := newUpstreamError(httpResp, respBody)
+		s.logChatError(channelID, key, requestID, req.Model, upstreamModel, startTime, httpResp, upstreamErr.Type, upstreamErr.Message, ipAddress, req.ClientKeyID)
+		return upstreamErr
 	}
 
 	// Set streaming headers after upstream validation
@@ -905,12 +1246,18 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 
 	// Handle streaming based on provider
 	hasData := false
+	upstreamMessageID := ""
+	meter := s.newStreamMeter(req.Quota, upstreamModel)
+	var quotaErr error
+
+	// Rebuild the upstream response from the stream so it can fill the response cache
+	body, capture := s.cache.captureStream(cacheKey, httpResp.Body, provider != "anthropic")
 	if provider == "anthropic" {
 		// Convert Anthropic SSE stream to OpenAI SSE format
-		hasData = s.convertAnthropicStreamToOpenAI(httpResp.Body, w, flusher, req.Model, requestID)
+		hasData, upstreamMessageID, quotaErr = s.convertAnthropicStreamToOpenAI(body, w, flusher, meter, req.Model, requestID)
 	} else {
 		// Forward OpenAI SSE stream directly
-		scanner := newLineScanner(httpResp.Body)
+		scanner := newLineScanner(body)
 		for scanner.Scan() {
 			line := scanner.Text()
 
@@ -924,38 +1271,76 @@ func (s *ProxyService) proxyChatStreamToChannel(req *model.OpenAIChatRequest, ap
 
 			if strings.HasPrefix(line, "data:") {
 				hasData = true
+
+				// Show the display model instead of the upstream's in every chunk
+				dataStr := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+				hasText := false
+				if dataStr != "[DONE]" {
+					var upstreamID string
+					dataStr, upstreamID = s.presentStreamData(dataStr, req.Model, requestID)
+					if upstreamMessageID == "" {
+						upstreamMessageID = upstreamID
+					}
+					line = "data: " + dataStr
+					hasText = meter.addChatChunk(dataStr)
+				}
+
 				w.Write([]byte(line))
 				w.Write([]byte("\n\n"))
 				flusher.Flush()
+
+				// Enforce the client key's quota as the generation grows
+				if hasText {
+					if quotaErr = meter.check(); quotaErr != nil {
+						break
+					}
+				}
 			}
 		}
 	}
 
+	if quotaErr != nil {
+		s.logQuotaExceeded(channelID, key, requestID, req.Model, upstreamModel, upstreamMessageID, startTime, meter, quotaErr, ipAddress, req.ClientKeyID)
+		return quotaErr
+	}
+
 	// Log success for streaming
 	if hasData {
 		responseTime := time.Now()
 		latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 		log := &model.RequestLog{
-			ChannelID:     channelID,
-			RequestID:     requestID,
-			ModelName:     req.Model,
-			UpstreamModel: upstreamModel,
-			RequestTime:   startTime,
-			ResponseTime:  &responseTime,
-			LatencyMs:     latencyMs,
-			Status:        "success",
-			IPAddress:     ipAddress,
+			ChannelID:         channelID,
+			ChannelKeyID:      channelKeyID(key),
+			APIKeyMasked:      maskedKey(key),
+			RequestID:         requestID,
+			ModelName:         req.Model,
+			UpstreamModel:     upstreamModel,
+			UpstreamMessageID: upstreamMessageID,
+			InputTokens:       meter.inputTokens,
+			OutputTokens:      meter.output(),
+			TotalTokens:       meter.inputTokens + meter.output(),
+			Cost:              meter.cost(),
+			RequestTime:       startTime,
+			ResponseTime:      &responseTime,
+			LatencyMs:         latencyMs,
+			Status:            "success",
+			IPAddress:         ipAddress,
+			ClientKeyID:       req.ClientKeyID,
 		}
 		s.logRepo.Create(log)
+		s.rateLimits.AddTokens(req.ClientKeyID, log.TotalTokens)
 	}
 
+	s.cache.storeStream(cacheKey, capture)
 	return nil
 }
 
-// convertAnthropicStreamToOpenAI converts Anthropic SSE stream to OpenAI SSE format
-func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.ResponseWriter, flusher http.Flusher, displayModel string, requestID string) bool {
+// convertAnthropicStreamToOpenAI converts Anthropic SSE stream to OpenAI SSE format.
+// It stops with a QuotaExceededError once the meter runs out of the client key's quota.
+func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.ResponseWriter, flusher http.Flusher, meter *streamMeter, displayModel string, requestID string) (bool, string, error) {
 	scanner := newLineScanner(body)
 	hasData := false
+	upstreamMessageID := ""
 	messageID := "chatcmpl-" + requestID
 
 	for scanner.Scan() {
@@ -986,6 +1371,8 @@ func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.Res
 
 			switch eventType {
 			case "content_block_delta":
+				meter.addText(deltaText(event))
+
 				// Extract text delta
 				if delta, ok := event["delta"].(map[string]interface{}); ok {
 					if text, ok := delta["text"].(string); ok {
@@ -1016,6 +1403,14 @@ func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.Res
 			case "message_start":
 				// Send initial chunk with role
 				hasData = true
+				if message, ok := event["message"].(map[string]interface{}); ok {
+					upstreamMessageID, _ = message["id"].(string)
+					if usage, ok := message["usage"].(map[string]interface{}); ok {
+						if input, ok := usage["input_tokens"].(float64); ok {
+							meter.inputTokens = int(input)
+						}
+					}
+				}
 				chunk := model.OpenAIStreamChunk{
 					ID:      messageID,
 					Object:  "chat.completion.chunk",
@@ -1037,6 +1432,13 @@ func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.Res
 				w.Write([]byte("\n\n"))
 				flusher.Flush()
 
+			case "message_delta":
+				if usage, ok := event["usage"].(map[string]interface{}); ok {
+					if output, ok := usage["output_tokens"].(float64); ok {
+						meter.outputTokens = int(output)
+					}
+				}
+
 			case "message_stop":
 				// Send final chunk with finish_reason
 				finishReason := "stop"
@@ -1060,39 +1462,52 @@ func (s *ProxyService) convertAnthropicStreamToOpenAI(body io.Reader, w http.Res
 				w.Write([]byte("data: [DONE]\n\n"))
 				flusher.Flush()
 			}
+
+			// Enforce the client key's quota as the generation grows
+			if eventType == "message_start" || eventType == "content_block_delta" {
+				if err := meter.check(); err != nil {
+					return hasData, upstreamMessageID, err
+				}
+			}
 		}
 	}
 
-	return hasData
+	return hasData, upstreamMessageID, nil
 }
 
 // logChatSuccess logs a successful OpenAI chat request
-func (s *ProxyService) logChatSuccess(channelID int64, requestID, modelName, upstreamModel string, startTime time.Time, resp *model.OpenAIChatResponse, ipAddress string) {
+func (s *ProxyService) logChatSuccess(channelID int64, key *model.ChannelKey, requestID, modelName, upstreamModel string, startTime time.Time, resp *model.OpenAIChatResponse, ipAddress string, clientKeyID int64) {
 	responseTime := time.Now()
 	latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 
 	log := &model.RequestLog{
-		ChannelID:     channelID,
-		RequestID:     requestID,
-		ModelName:     modelName,
-		UpstreamModel: upstreamModel,
-		InputTokens:   resp.Usage.PromptTokens,
-		OutputTokens:  resp.Usage.CompletionTokens,
-		TotalTokens:   resp.Usage.TotalTokens,
-		RequestTime:   startTime,
-		ResponseTime:  &responseTime,
-		LatencyMs:     latencyMs,
-		Status:        "success",
-		IPAddress:     ipAddress,
+		ChannelID:         channelID,
+		ChannelKeyID:      channelKeyID(key),
+		APIKeyMasked:      maskedKey(key),
+		RequestID:         requestID,
+		ModelName:         modelName,
+		UpstreamModel:     upstreamModel,
+		UpstreamMessageID: resp.ID,
+		InputTokens:       resp.Usage.PromptTokens,
+		OutputTokens:      resp.Usage.CompletionTokens,
+		TotalTokens:       resp.Usage.TotalTokens,
+		Cost:              s.modelPrice(upstreamModel).Cost(resp.Usage.PromptTokens, resp.Usage.CompletionTokens),
+		RequestTime:       startTime,
+		ResponseTime:      &responseTime,
+		LatencyMs:         latencyMs,
+		Status:            "success",
+		IPAddress:         ipAddress,
+		ClientKeyID:       clientKeyID,
 	}
 
 	if _, err := s.logRepo.Create(log); err != nil {
 		logger.Error("Failed to create log: %v", err)
 	}
+	s.rateLimits.AddTokens(clientKeyID, log.TotalTokens)
 }
 
 // logChatError logs a failed OpenAI chat request
-func (s *ProxyService) logChatError(channelID int64, requestID, modelName, upstreamModel string, startTime time.Time, httpResp *http.Response, errorCode, errorMessage, ipAddress string) {
+func (s *ProxyService) logChatError(channelID int64, key *model.ChannelKey, requestID, modelName, upstreamModel string, startTime time.Time, httpResp *http.Response, errorCode, errorMessage, ipAddress string, clientKeyID int64) {
 	responseTime := time.Now()
 	latencyMs := int(responseTime.Sub(startTime).Milliseconds())
 
@@ -1103,6 +1518,8 @@ func (s *ProxyService) logChatError(channelID int64, requestID, modelName, upstr
 
 	log := &model.RequestLog{
 		ChannelID:     channelID,
+		ChannelKeyID:  channelKeyID(key),
+		APIKeyMasked:  maskedKey(key),
 		RequestID:     requestID,
 		ModelName:     modelName,
 		UpstreamModel: upstreamModel,
@@ -1113,6 +1530,7 @@ func (s *ProxyService) logChatError(channelID int64, requestID, modelName, upstr
 		ErrorCode:     errorCode,
 		ErrorMessage:  errorMessage,
 		IPAddress:     ipAddress,
+		ClientKeyID:   clientKeyID,
 	}
 
 	if statusCode != "" {
@@ -1124,32 +1542,44 @@ func (s *ProxyService) logChatError(channelID int64, requestID, modelName, upstr
 	}
 }
 
+// channelKeyID returns the pooled key ID for logging, 0 for channel-level keys
+func channelKeyID(key *model.ChannelKey) int64 {
+	if key == nil {
+		return 0
+	}
+	return key.ID
+}
+
+// maskedKey returns the masked API key for logging
+func maskedKey(key *model.ChannelKey) string {
+	if key == nil {
+		return ""
+	}
+	return secret.Mask(key.APIKey)
+}
+
 // lineScanner helps scan SSE streams line by line
 type lineScanner struct {
-	reader io.Reader
+	reader *bufio.Reader
 	buffer []byte
 }
 
 func newLineScanner(r io.Reader) *lineScanner {
-	return &lineScanner{reader: r, buffer: make([]byte, 0, 4096)}
+	return &lineScanner{reader: bufio.NewReaderSize(r, 4096)}
 }
 
+// Scan advances to the next non-empty line. Blank lines separate SSE events
+// and are skipped; Scan returns false only once the stream is exhausted.
 func (s *lineScanner) Scan() bool {
-	buf := make([]byte, 1)
-	s.buffer = s.buffer[:0]
-
 	for {
-		n, err := s.reader.Read(buf)
-		if err != nil {
-			return len(s.buffer) > 0
+		line, err := s.reader.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			s.buffer = line
+			return true
 		}
-		if n > 0 {
-			if buf[0] == '\n' {
-				return len(s.buffer) > 0
-			}
-			if buf[0] != '\r' {
-				s.buffer = append(s.buffer, buf[0])
-			}
+		if err != nil {
+			return false
 		}
 	}
 }
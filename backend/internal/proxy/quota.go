@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// bytesPerToken approximates how many bytes of generated text make up a token,
+// used to meter a stream until the upstream reports its usage
+const bytesPerToken = 4
+
+// streamMeter tracks the tokens and estimated cost of a streaming response, so a client key's
+// quota is enforced while a long generation streams and not only before the next request
+type streamMeter struct {
+	allowance    *model.QuotaAllowance
+	price        *model.ModelPrice
+	inputTokens  int
+	outputTokens int // as reported by the upstream
+	outputBytes  int // generated text streamed so far
+}
+
+// newStreamMeter creates the meter of a streaming attempt
+func (s *ProxyService) newStreamMeter(allowance *model.QuotaAllowance, upstreamModel string) *streamMeter {
+	return &streamMeter{allowance: allowance, price: s.modelPrice(upstreamModel)}
+}
+
+// addText counts streamed text
+func (m *streamMeter) addText(text string) {
+	m.outputBytes += len(text)
+}
+
+// output returns the output tokens reported by the upstream, or an estimate from the streamed text
+func (m *streamMeter) output() int {
+	return max(m.outputTokens, m.outputBytes/bytesPerToken)
+}
+
+// cost returns the estimated cost of the stream so far
+func (m *streamMeter) cost() float64 {
+	return m.price.Cost(m.inputTokens, m.output())
+}
+
+// check returns a QuotaExceededError once the stream has used up the client key's allowance
+func (m *streamMeter) check() error {
+	return m.allowance.Check(int64(m.inputTokens+m.output()), m.cost())
+}
+
+// addChatChunk counts the text and usage of an OpenAI stream chunk and reports whether it carried text
+func (m *streamMeter) addChatChunk(data string) bool {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"delta"`
+		} `json:"choices"`
+		Usage *model.OpenAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return false
+	}
+
+	hasText := false
+	for _, choice := range chunk.Choices {
+		m.addText(choice.Delta.Content)
+		m.addText(choice.Delta.ReasoningContent)
+		hasText = hasText || choice.Delta.Content != "" || choice.Delta.ReasoningContent != ""
+	}
+	if chunk.Usage != nil {
+		m.inputTokens = chunk.Usage.PromptTokens
+		m.outputTokens = chunk.Usage.CompletionTokens
+	}
+	return hasText
+}
+
+// deltaText returns the generated text carried by an Anthropic content_block_delta event
+func deltaText(event map[string]interface{}) string {
+	delta, _ := event["delta"].(map[string]interface{})
+	for _, field := range []string{"text", "thinking", "partial_json"} {
+		if text, ok := delta[field].(string); ok {
+			return text
+		}
+	}
+	return ""
+}
+
+// modelPrice returns the price of an upstream model, or nil if it isn't priced
+func (s *ProxyService) modelPrice(upstreamModel string) *model.ModelPrice {
+	price, err := s.priceRepo.FindByModel(upstreamModel)
+	if err != nil {
+		logger.Error("Failed to find price of model %s: %v", upstreamModel, err)
+		return nil
+	}
+	return price
+}
+
+// logQuotaExceeded logs a stream cut off because its client key ran out of quota.
+// The tokens it consumed still count against the key.
+func (s *ProxyService) logQuotaExceeded(channelID int64, key *model.ChannelKey, requestID, modelName, upstreamModel, upstreamMessageID string, startTime time.Time, meter *streamMeter, quotaErr error, ipAddress string, clientKeyID int64) {
+	responseTime := time.Now()
+
+	log := &model.RequestLog{
+		ChannelID:         channelID,
+		ChannelKeyID:      channelKeyID(key),
+		APIKeyMasked:      maskedKey(key),
+		RequestID:         requestID,
+		ModelName:         modelName,
+		UpstreamModel:     upstreamModel,
+		UpstreamMessageID: upstreamMessageID,
+		InputTokens:       meter.inputTokens,
+		OutputTokens:      meter.output(),
+		TotalTokens:       meter.inputTokens + meter.output(),
+		Cost:              meter.cost(),
+		RequestTime:       startTime,
+		ResponseTime:      &responseTime,
+		LatencyMs:         int(responseTime.Sub(startTime).Milliseconds()),
+		Status:            "error",
+		ErrorCode:         "quota_exceeded",
+		ErrorMessage:      quotaErr.Error(),
+		IPAddress:         ipAddress,
+		ClientKeyID:       clientKeyID,
+	}
+
+	if _, err := s.logRepo.Create(log); err != nil {
+		logger.Error("Failed to create quota log: %v", err)
+	}
+	s.rateLimits.AddTokens(clientKeyID, log.TotalTokens)
+}
@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// Rate limit headers, named after Anthropic's so SDKs back off on their own
+const (
+	headerRequestsLimit     = "anthropic-ratelimit-requests-limit"
+	headerRequestsRemaining = "anthropic-ratelimit-requests-remaining"
+	headerRequestsReset     = "anthropic-ratelimit-requests-reset"
+	headerTokensLimit       = "anthropic-ratelimit-tokens-limit"
+	headerTokensRemaining   = "anthropic-ratelimit-tokens-remaining"
+	headerTokensReset       = "anthropic-ratelimit-tokens-reset"
+)
+
+// Rate limits of a client key
+const (
+	RateLimitRequests    = "requests"
+	RateLimitTokens      = "tokens"
+	RateLimitConcurrency = "concurrency"
+)
+
+// RateLimitError reports that a client key has hit one of its rate limits
+type RateLimitError struct {
+	Limit   string    // RateLimitRequests, RateLimitTokens or RateLimitConcurrency
+	Max     int64     // the limit that was hit
+	RetryAt time.Time // zero for concurrency, which frees up when an in-flight request finishes
+}
+
+func (e *RateLimitError) Error() string {
+	switch e.Limit {
+	case RateLimitConcurrency:
+		return fmt.Sprintf("client key has reached its limit of %d concurrent requests", e.Max)
+	case RateLimitTokens:
+		return fmt.Sprintf("client key has exceeded its limit of %d tokens per minute", e.Max)
+	}
+	return fmt.Sprintf("client key has exceeded its limit of %d requests per minute", e.Max)
+}
+
+// tokenBucket refills continuously up to a per-minute limit. Its level may go negative when
+// a request turns out to use more tokens than were left; the debt is paid off by refilling.
+type tokenBucket struct {
+	level   float64
+	updated time.Time
+}
+
+// refill tops the bucket up for the time passed since it was last updated
+func (b *tokenBucket) refill(limit int64, now time.Time) {
+	b.level = math.Min(b.level+now.Sub(b.updated).Minutes()*float64(limit), float64(limit))
+	b.updated = now
+}
+
+// availableAt returns when the bucket will hold the given amount
+func (b *tokenBucket) availableAt(limit int64, amount float64, now time.Time) time.Time {
+	if b.level >= amount {
+		return now
+	}
+	return now.Add(time.Duration((amount - b.level) / float64(limit) * float64(time.Minute)))
+}
+
+// remaining returns the whole units left in the bucket
+func (b *tokenBucket) remaining() int64 {
+	return int64(math.Max(math.Floor(b.level), 0))
+}
+
+// clientKeyRate is the rate limiting state of one client key
+type clientKeyRate struct {
+	requests tokenBucket
+	tokens   tokenBucket
+	tpmLimit int64 // as of the last request, for charging tokens afterwards
+	inFlight int64
+}
+
+// rateLimiter enforces the RPM, TPM and concurrency limits of client keys. State is kept
+// in memory, so limits apply per gateway instance and start over on restart.
+type rateLimiter struct {
+	mu   sync.Mutex
+	keys map[int64]*clientKeyRate
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{keys: make(map[int64]*clientKeyRate)}
+}
+
+// state returns the rate limiting state of a client key, starting with full buckets
+func (l *rateLimiter) state(clientKey *model.ClientKey, now time.Time) *clientKeyRate {
+	state, ok := l.keys[clientKey.ID]
+	if !ok {
+		state = &clientKeyRate{
+			requests: tokenBucket{level: float64(clientKey.RPMLimit), updated: now},
+			tokens:   tokenBucket{level: float64(clientKey.TPMLimit), updated: now},
+		}
+		l.keys[clientKey.ID] = state
+	}
+	return state
+}
+
+// Acquire admits a request of a client key, or returns a RateLimitError. On admission the caller
+// must call the returned release once the request has finished. The key's rate limit headers are
+// set either way.
+func (l *rateLimiter) Acquire(clientKey *model.ClientKey, header http.Header) (func(), error) {
+	if clientKey == nil || !clientKey.HasRateLimit() {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	state := l.state(clientKey, now)
+	state.requests.refill(clientKey.RPMLimit, now)
+	state.tokens.refill(clientKey.TPMLimit, now)
+	state.tpmLimit = clientKey.TPMLimit
+
+	var err error
+	switch {
+	case clientKey.ConcurrencyLimit > 0 && state.inFlight >= clientKey.ConcurrencyLimit:
+		err = &RateLimitError{Limit: RateLimitConcurrency, Max: clientKey.ConcurrencyLimit}
+	case clientKey.RPMLimit > 0 && state.requests.level < 1:
+		err = &RateLimitError{Limit: RateLimitRequests, Max: clientKey.RPMLimit, RetryAt: state.requests.availableAt(clientKey.RPMLimit, 1, now)}
+	case clientKey.TPMLimit > 0 && state.tokens.level < 1:
+		err = &RateLimitError{Limit: RateLimitTokens, Max: clientKey.TPMLimit, RetryAt: state.tokens.availableAt(clientKey.TPMLimit, 1, now)}
+	}
+
+	if err == nil {
+		state.requests.level--
+		state.inFlight++
+	}
+	setRateLimitHeaders(header, clientKey, state, now)
+	if err != nil {
+		return nil, err
+	}
+
+	released := false
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !released {
+			state.inFlight--
+			released = true
+		}
+	}, nil
+}
+
+// AddTokens charges the tokens a request used to its client key's TPM limit
+func (l *rateLimiter) AddTokens(clientKeyID int64, tokens int) {
+	if clientKeyID == 0 || tokens <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.keys[clientKeyID]
+	if !ok || state.tpmLimit <= 0 {
+		return
+	}
+	state.tokens.refill(state.tpmLimit, time.Now())
+	state.tokens.level -= float64(tokens)
+}
+
+// setRateLimitHeaders reports the limits configured on a client key, what is left of them and
+// when they will be fully replenished
+func setRateLimitHeaders(header http.Header, clientKey *model.ClientKey, state *clientKeyRate, now time.Time) {
+	if clientKey.RPMLimit > 0 {
+		header.Set(headerRequestsLimit, strconv.FormatInt(clientKey.RPMLimit, 10))
+		header.Set(headerRequestsRemaining, strconv.FormatInt(state.requests.remaining(), 10))
+		header.Set(headerRequestsReset, state.requests.availableAt(clientKey.RPMLimit, float64(clientKey.RPMLimit), now).UTC().Format(time.RFC3339))
+	}
+	if clientKey.TPMLimit > 0 {
+		header.Set(headerTokensLimit, strconv.FormatInt(clientKey.TPMLimit, 10))
+		header.Set(headerTokensRemaining, strconv.FormatInt(state.tokens.remaining(), 10))
+		header.Set(headerTokensReset, state.tokens.availableAt(clientKey.TPMLimit, float64(clientKey.TPMLimit), now).UTC().Format(time.RFC3339))
+	}
+}
+
+// AcquireRateLimit admits a request against the rate limits of its client key, setting the
+// rate limit headers of the response. The returned release must be called once the request
+// has finished.
+func (s *ProxyService) AcquireRateLimit(clientKey *model.ClientKey, header http.Header) (func(), error) {
+	return s.rateLimits.Acquire(clientKey, header)
+}
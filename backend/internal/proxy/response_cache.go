@@ -0,0 +1,359 @@
+package proxy
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// volatileRequestFields don't affect the generated response and are left out of cache keys
+var volatileRequestFields = []string{"stream", "stream_options", "metadata", "user"}
+
+// responseCache serves repeated deterministic requests from an in-memory LRU backed by SQLite
+type responseCache struct {
+	ttl       time.Duration
+	size      int
+	repo      *repository.ResponseCacheRepository
+	mu        sync.Mutex
+	order     *list.List
+	entries   map[string]*list.Element
+	lastSweep time.Time
+}
+
+func newResponseCache(ttl time.Duration, size int) *responseCache {
+	return &responseCache{
+		ttl:       ttl,
+		size:      size,
+		repo:      repository.NewResponseCacheRepository(),
+		order:     list.New(),
+		entries:   make(map[string]*list.Element),
+		lastSweep: time.Now(),
+	}
+}
+
+// EnableResponseCache turns on the response cache with the given TTL and in-memory size; a zero TTL leaves it off
+func (s *ProxyService) EnableResponseCache(ttl time.Duration, size int) {
+	if ttl > 0 {
+		if size <= 0 {
+			size = 1
+		}
+		s.cache = newResponseCache(ttl, size)
+	}
+}
+
+// Key derives the cache key of an upstream request, or "" when the request must not be cached:
+// the cache is off, the client opted out, or the request isn't deterministic (temperature 0).
+// Keys are scoped to the client key, so one client is never served another's responses.
+func (c *responseCache) Key(clientKeyID int64, path string, bodyBytes []byte, noCache bool) string {
+	if c == nil || noCache {
+		return ""
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return ""
+	}
+	if temperature, ok := body["temperature"].(float64); !ok || temperature != 0 {
+		return ""
+	}
+	for _, field := range volatileRequestFields {
+		delete(body, field)
+	}
+
+	// Maps marshal with sorted keys, so equal requests normalize to equal bytes
+	normalized, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte(fmt.Sprintf("%d\n%s\n", clientKeyID, path)), normalized...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached upstream response body for a key
+func (c *responseCache) Get(key string) ([]byte, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*model.ResponseCacheEntry)
+		if time.Now().Before(entry.ExpiresAt) {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry.Body, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	entry, err := c.repo.Get(key)
+	if err != nil {
+		logger.Error("Failed to read response cache: %v", err)
+		return nil, false
+	}
+	if entry == nil || !time.Now().Before(entry.ExpiresAt) {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.remember(entry)
+	c.mu.Unlock()
+	return entry.Body, true
+}
+
+// Set caches an upstream response body in memory and in SQLite
+func (c *responseCache) Set(key string, body []byte) {
+	if c == nil || key == "" {
+		return
+	}
+
+	now := time.Now()
+	entry := &model.ResponseCacheEntry{CacheKey: key, Body: body, ExpiresAt: now.Add(c.ttl), CreatedAt: now}
+	if err := c.repo.Set(entry); err != nil {
+		logger.Error("Failed to write response cache: %v", err)
+	}
+
+	c.mu.Lock()
+	c.remember(entry)
+	sweep := now.Sub(c.lastSweep) > c.ttl
+	if sweep {
+		c.lastSweep = now
+	}
+	c.mu.Unlock()
+
+	// Drop expired rows now and then so the table doesn't grow without bound
+	if sweep {
+		if _, err := c.repo.DeleteExpired(now); err != nil {
+			logger.Error("Failed to purge response cache: %v", err)
+		}
+	}
+}
+
+// remember puts an entry at the front of the LRU, evicting the least recently used ones. Callers hold mu.
+func (c *responseCache) remember(entry *model.ResponseCacheEntry) {
+	if elem, ok := c.entries[entry.CacheKey]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.CacheKey] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*model.ResponseCacheEntry).CacheKey)
+	}
+}
+
+// logCacheHit logs a request served from the response cache. No upstream tokens were spent, so
+// the log's tokens and cost are 0; the client key is billed the cached response's usage separately.
+func (s *ProxyService) logCacheHit(channelID int64, requestID, modelName, upstreamModel string, startTime time.Time, cached []byte, ipAddress string, clientKeyID int64) {
+	responseTime := time.Now()
+	inputTokens, outputTokens := cachedUsage(cached)
+
+	log := &model.RequestLog{
+		ChannelID:     channelID,
+		RequestID:     requestID,
+		ModelName:     modelName,
+		UpstreamModel: upstreamModel,
+		BilledTokens:  inputTokens + outputTokens,
+		BilledCost:    s.modelPrice(upstreamModel).Cost(inputTokens, outputTokens),
+		RequestTime:   startTime,
+		ResponseTime:  &responseTime,
+		LatencyMs:     int(responseTime.Sub(startTime).Milliseconds()),
+		Status:        "success",
+		IPAddress:     ipAddress,
+		ClientKeyID:   clientKeyID,
+		CacheHit:      true,
+	}
+
+	if _, err := s.logRepo.Create(log); err != nil {
+		logger.Error("Failed to create cache hit log: %v", err)
+	}
+	s.rateLimits.AddTokens(clientKeyID, log.BilledTokens)
+}
+
+// cachedUsage returns the token usage recorded in a cached Anthropic message or OpenAI chat completion
+func cachedUsage(body []byte) (int, int) {
+	var resp struct {
+		Usage struct {
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, 0
+	}
+	return resp.Usage.InputTokens + resp.Usage.PromptTokens, resp.Usage.OutputTokens + resp.Usage.CompletionTokens
+}
+
+// anthropicEventStream rebuilds the SSE events of a cached Anthropic message, one delta per content block
+func anthropicEventStream(body []byte) ([]byte, error) {
+	var message map[string]interface{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("failed to parse cached response: %w", err)
+	}
+	blocks, _ := message["content"].([]interface{})
+	usage, _ := message["usage"].(map[string]interface{})
+
+	var buf bytes.Buffer
+	writeEvent := func(event string, data map[string]interface{}) {
+		encoded, _ := json.Marshal(data)
+		fmt.Fprintf(&buf, "event: %s\ndata: %s\n\n", event, encoded)
+	}
+
+	start := make(map[string]interface{}, len(message))
+	for k, v := range message {
+		start[k] = v
+	}
+	start["content"] = []interface{}{}
+	start["stop_reason"] = nil
+	writeEvent("message_start", map[string]interface{}{"type": "message_start", "message": start})
+
+	for i, raw := range blocks {
+		block, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var emptyBlock, delta map[string]interface{}
+		switch block["type"] {
+		case "text":
+			emptyBlock = map[string]interface{}{"type": "text", "text": ""}
+			delta = map[string]interface{}{"type": "text_delta", "text": block["text"]}
+		case "thinking":
+			emptyBlock = map[string]interface{}{"type": "thinking", "thinking": ""}
+			delta = map[string]interface{}{"type": "thinking_delta", "thinking": block["thinking"]}
+		case "tool_use":
+			input, _ := json.Marshal(block["input"])
+			emptyBlock = map[string]interface{}{"type": "tool_use", "id": block["id"], "name": block["name"], "input": map[string]interface{}{}}
+			delta = map[string]interface{}{"type": "input_json_delta", "partial_json": string(input)}
+		default:
+			// Blocks without a delta form are sent whole
+			emptyBlock = block
+		}
+
+		writeEvent("content_block_start", map[string]interface{}{"type": "content_block_start", "index": i, "content_block": emptyBlock})
+		if delta != nil {
+			writeEvent("content_block_delta", map[string]interface{}{"type": "content_block_delta", "index": i, "delta": delta})
+		}
+		if signature, ok := block["signature"]; ok && block["type"] == "thinking" {
+			writeEvent("content_block_delta", map[string]interface{}{"type": "content_block_delta", "index": i,
+				"delta": map[string]interface{}{"type": "signature_delta", "signature": signature}})
+		}
+		writeEvent("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": i})
+	}
+
+	writeEvent("message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": message["stop_reason"], "stop_sequence": message["stop_sequence"]},
+		"usage": map[string]interface{}{"output_tokens": usage["output_tokens"]},
+	})
+	writeEvent("message_stop", map[string]interface{}{"type": "message_stop"})
+	return buf.Bytes(), nil
+}
+
+// replayAnthropicStream writes a cached Anthropic message to the client as SSE, under the display model
+func (s *ProxyService) replayAnthropicStream(w http.ResponseWriter, body []byte, displayModel string, requestID string) error {
+	// Rewrite the raw message so blocks the response model doesn't know survive the replay
+	var message map[string]interface{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return fmt.Errorf("failed to parse cached response: %w", err)
+	}
+	message["model"] = displayModel
+	if s.gatewayIDs {
+		message["id"] = gatewayMessageID("msg_", requestID)
+	}
+	presented, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached response: %w", err)
+	}
+
+	events, err := anthropicEventStream(presented)
+	if err != nil {
+		return err
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Write(events)
+	flusher.Flush()
+	return nil
+}
+
+// replayChatStream writes a cached upstream response to an OpenAI streaming client,
+// converting it the same way as a live response from the channel's provider
+func (s *ProxyService) replayChatStream(w http.ResponseWriter, provider string, body []byte, displayModel string, requestID string) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	if provider == "anthropic" {
+		events, err := anthropicEventStream(body)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		// The replay is not metered as it streams; logCacheHit bills the cached usage once it completes
+		_, _, err = s.convertAnthropicStreamToOpenAI(bytes.NewReader(events), w, flusher, &streamMeter{}, displayModel, requestID)
+		return err
+	}
+
+	var resp model.OpenAIChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse cached response: %w", err)
+	}
+	s.presentChatResponse(&resp, displayModel, requestID)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	writeOpenAIReplay(w, flusher, &resp)
+	return nil
+}
+
+// writeOpenAIReplay streams a cached OpenAI chat completion to the client as chunks
+func writeOpenAIReplay(w http.ResponseWriter, flusher http.Flusher, resp *model.OpenAIChatResponse) {
+	writeChunk := func(choice model.OpenAIStreamChoice) {
+		chunkBytes, _ := json.Marshal(model.OpenAIStreamChunk{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: []model.OpenAIStreamChoice{choice},
+		})
+		w.Write([]byte("data: "))
+		w.Write(chunkBytes)
+		w.Write([]byte("\n\n"))
+	}
+
+	for _, choice := range resp.Choices {
+		content, _ := choice.Message.Content.(string)
+		writeChunk(model.OpenAIStreamChoice{Index: choice.Index, Delta: model.OpenAIDelta{Role: "assistant", Content: content}})
+		finishReason := choice.FinishReason
+		writeChunk(model.OpenAIStreamChoice{Index: choice.Index, Delta: model.OpenAIDelta{}, FinishReason: &finishReason})
+	}
+	w.Write([]byte("data: [DONE]\n\n"))
+	flusher.Flush()
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// EnableGatewayMessageIDs replaces upstream message IDs in responses with IDs derived from the gateway's request ID
+func (s *ProxyService) EnableGatewayMessageIDs(enabled bool) {
+	s.gatewayIDs = enabled
+}
+
+// gatewayMessageID derives a response ID from the request ID the gateway logged the request under
+func gatewayMessageID(prefix, requestID string) string {
+	return prefix + strings.ReplaceAll(requestID, "-", "")
+}
+
+// presentAnthropicResponse shows a response under the display model, and under a gateway message ID when enabled.
+// The upstream values stay in the request log.
+func (s *ProxyService) presentAnthropicResponse(resp *model.AnthropicMessageResponse, displayModel, requestID string) {
+	resp.Model = displayModel
+	if s.gatewayIDs {
+		resp.ID = gatewayMessageID("msg_", requestID)
+	}
+}
+
+// presentChatResponse shows an OpenAI response under the display model, and under a gateway completion ID when enabled
+func (s *ProxyService) presentChatResponse(resp *model.OpenAIChatResponse, displayModel, requestID string) {
+	resp.Model = displayModel
+	if s.gatewayIDs {
+		resp.ID = gatewayMessageID("chatcmpl-", requestID)
+	}
+}
+
+// presentStreamData rewrites the model and ID of an SSE data payload. Anthropic carries them in the
+// message of message_start, OpenAI in every chunk. It returns the payload to forward and the upstream ID, if any.
+func (s *ProxyService) presentStreamData(data string, displayModel, requestID string) (string, string) {
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return data, ""
+	}
+
+	target, prefix := event, "chatcmpl-"
+	if message, ok := event["message"].(map[string]interface{}); ok && event["type"] == "message_start" {
+		target, prefix = message, "msg_"
+	} else if _, ok := event["model"]; !ok {
+		return data, ""
+	}
+
+	upstreamID, _ := target["id"].(string)
+	target["model"] = displayModel
+	if s.gatewayIDs {
+		target["id"] = gatewayMessageID(prefix, requestID)
+	}
+
+	rewritten, err := json.Marshal(event)
+	if err != nil {
+		return data, upstreamID
+	}
+	return string(rewritten), upstreamID
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// marshalAnthropicRequest applies a mapping's rewrite rules to an upstream Anthropic request and encodes it
+func marshalAnthropicRequest(req *model.AnthropicMessageRequest, rules *model.RewriteRules) ([]byte, error) {
+	if rules != nil {
+		req.System = joinSystemPrompt(rules.SystemPrepend, req.System, rules.SystemAppend)
+		if rules.MaxTokensCeiling > 0 && req.MaxTokens > rules.MaxTokensCeiling {
+			req.MaxTokens = rules.MaxTokensCeiling
+		}
+		if rules.Temperature != nil {
+			req.Temperature = rules.Temperature
+		}
+		if rules.TopP != nil {
+			req.TopP = rules.TopP
+		}
+		if rules.TopK != nil {
+			req.TopK = rules.TopK
+		}
+		if len(req.StopSequences) == 0 && len(rules.DefaultStopSequences) > 0 {
+			req.StopSequences = rules.DefaultStopSequences
+		}
+	}
+
+	bodyBytes, err := json.Marshal(req)
+	if err != nil || rules == nil {
+		return bodyBytes, err
+	}
+	return dropFields(bodyBytes, rules.DropFields)
+}
+
+// marshalOpenAIRequest applies a mapping's rewrite rules to an upstream OpenAI request and encodes it.
+// top_k has no OpenAI equivalent and is ignored.
+func marshalOpenAIRequest(req *model.OpenAIChatRequest, rules *model.RewriteRules) ([]byte, error) {
+	if rules != nil {
+		req.Messages = rewriteOpenAISystem(req.Messages, rules.SystemPrepend, rules.SystemAppend)
+		if rules.MaxTokensCeiling > 0 && req.MaxTokens != nil && *req.MaxTokens > rules.MaxTokensCeiling {
+			ceiling := rules.MaxTokensCeiling
+			req.MaxTokens = &ceiling
+		}
+		if rules.Temperature != nil {
+			req.Temperature = rules.Temperature
+		}
+		if rules.TopP != nil {
+			req.TopP = rules.TopP
+		}
+		if req.Stop == nil && len(rules.DefaultStopSequences) > 0 {
+			req.Stop = rules.DefaultStopSequences
+		}
+	}
+
+	bodyBytes, err := json.Marshal(req)
+	if err != nil || rules == nil {
+		return bodyBytes, err
+	}
+	return dropFields(bodyBytes, rules.DropFields)
+}
+
+// joinSystemPrompt surrounds a system prompt with the configured prefix and suffix
+func joinSystemPrompt(prefix, system, suffix string) string {
+	var parts []string
+	for _, part := range []string{prefix, system, suffix} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// rewriteOpenAISystem applies the system prompt prefix and suffix to an OpenAI message list.
+// The client's messages are copied, never modified in place, since a request may be retried on other channels.
+func rewriteOpenAISystem(messages []model.OpenAIMessage, prefix, suffix string) []model.OpenAIMessage {
+	if prefix == "" && suffix == "" {
+		return messages
+	}
+
+	rewritten := make([]model.OpenAIMessage, len(messages))
+	copy(rewritten, messages)
+
+	if len(rewritten) > 0 && rewritten[0].Role == "system" {
+		if content, ok := rewritten[0].Content.(string); ok {
+			rewritten[0].Content = joinSystemPrompt(prefix, content, suffix)
+			return rewritten
+		}
+	}
+
+	// No plain-text system message to extend, so add the prompts as messages of their own
+	if prefix != "" {
+		rewritten = append([]model.OpenAIMessage{{Role: "system", Content: prefix}}, rewritten...)
+	}
+	if suffix != "" {
+		insertAt := 0
+		for insertAt < len(rewritten) && rewritten[insertAt].Role == "system" {
+			insertAt++
+		}
+		rewritten = append(rewritten[:insertAt], append([]model.OpenAIMessage{{Role: "system", Content: suffix}}, rewritten[insertAt:]...)...)
+	}
+	return rewritten
+}
+
+// dropFields removes top-level fields from an encoded request body
+func dropFields(bodyBytes []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return bodyBytes, nil
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		delete(body, field)
+	}
+	return json.Marshal(body)
+}
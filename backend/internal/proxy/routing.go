@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/logger"
+)
+
+// requestFeatures summarizes the parts of a request that routing rules inspect
+type requestFeatures struct {
+	Model       string
+	InputTokens int
+	HasImages   bool
+	HasTools    bool
+	HasThinking bool
+}
+
+// anthropicFeatures inspects an Anthropic Messages request
+func anthropicFeatures(req *model.AnthropicMessageRequest) requestFeatures {
+	features := requestFeatures{
+		Model:    req.Model,
+		HasTools: len(req.Tools) > 0,
+	}
+
+	chars := len(req.System)
+	for _, msg := range req.Messages {
+		n, images := inspectContent(msg.Content, "image")
+		chars += n
+		features.HasImages = features.HasImages || images
+	}
+	if features.HasTools {
+		toolBytes, _ := json.Marshal(req.Tools)
+		chars += len(toolBytes)
+	}
+	features.InputTokens = estimateTokens(chars)
+
+	if thinking, ok := req.Thinking.(map[string]interface{}); ok {
+		features.HasThinking = thinking["type"] != "disabled"
+	} else {
+		features.HasThinking = req.Thinking != nil
+	}
+	return features
+}
+
+// openAIFeatures inspects an OpenAI chat completion request
+func openAIFeatures(req *model.OpenAIChatRequest) requestFeatures {
+	features := requestFeatures{
+		Model:       req.Model,
+		HasTools:    req.Tools != nil || req.Functions != nil,
+		HasThinking: req.ReasoningEffort != "" && req.ReasoningEffort != "none",
+	}
+
+	chars := 0
+	for _, msg := range req.Messages {
+		n, images := inspectContent(msg.Content, "image_url")
+		chars += n
+		features.HasImages = features.HasImages || images
+	}
+	if features.HasTools {
+		toolBytes, _ := json.Marshal(req.Tools)
+		functionBytes, _ := json.Marshal(req.Functions)
+		chars += len(toolBytes) + len(functionBytes)
+	}
+	features.InputTokens = estimateTokens(chars)
+	return features
+}
+
+// inspectContent returns the text length of a message's content and whether it has a block of imageType
+func inspectContent(content any, imageType string) (int, bool) {
+	switch c := content.(type) {
+	case string:
+		return len(c), false
+	case []interface{}:
+		chars, hasImage := 0, false
+		for _, block := range c {
+			m, ok := block.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if m["type"] == imageType {
+				hasImage = true
+			}
+			if text, ok := m["text"].(string); ok {
+				chars += len(text)
+			}
+		}
+		return chars, hasImage
+	}
+	return 0, false
+}
+
+// estimateTokens approximates a token count from a character count (about 4 characters per token)
+func estimateTokens(chars int) int {
+	return (chars + 3) / 4
+}
+
+// routeMappings narrows mappings to the channel group picked by the first matching routing rule.
+// All mappings are kept when no rule matches or none of the group's channels serves the model.
+func (s *ProxyService) routeMappings(mappings []*model.ModelMappingWithChannel, features requestFeatures) []*model.ModelMappingWithChannel {
+	rule := s.matchRoutingRule(features)
+	if rule == nil {
+		return mappings
+	}
+
+	var routed []*model.ModelMappingWithChannel
+	for _, mapping := range mappings {
+		channel, err := s.channelRepo.GetByID(mapping.ChannelID)
+		if err == nil && channel.Group == rule.TargetGroup {
+			routed = append(routed, mapping)
+		}
+	}
+	if len(routed) == 0 {
+		logger.Info("Routing rule %d (%s) matched model %s but group %s has no mapped channel", rule.ID, rule.Name, features.Model, rule.TargetGroup)
+		return mappings
+	}
+
+	logger.Debug("Routing rule %d (%s) routed model %s to group %s", rule.ID, rule.Name, features.Model, rule.TargetGroup)
+	return routed
+}
+
+// matchRoutingRule returns the first enabled rule whose conditions all hold for the request
+func (s *ProxyService) matchRoutingRule(features requestFeatures) *model.RoutingRule {
+	rules, err := s.routingRepo.ListEnabled()
+	if err != nil {
+		logger.Error("Failed to load routing rules: %v", err)
+		return nil
+	}
+
+	for _, rule := range rules {
+		if ruleMatches(rule, features) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// ruleMatches reports whether a request satisfies every condition set on a rule
+func ruleMatches(rule *model.RoutingRule, features requestFeatures) bool {
+	if rule.ModelPattern != "" {
+		re, err := repository.CompileMappingPattern(model.MatchTypeGlob, rule.ModelPattern)
+		if err != nil || !re.MatchString(features.Model) {
+			return false
+		}
+	}
+	if rule.MinInputTokens > 0 && features.InputTokens < rule.MinInputTokens {
+		return false
+	}
+	if rule.HasImages != nil && *rule.HasImages != features.HasImages {
+		return false
+	}
+	if rule.HasTools != nil && *rule.HasTools != features.HasTools {
+		return false
+	}
+	if rule.HasThinking != nil && *rule.HasThinking != features.HasThinking {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// sessionPin records the channel and key that last served a session
+type sessionPin struct {
+	ChannelID int64
+	KeyID     int64
+	ExpiresAt time.Time
+}
+
+// sessionAffinity keeps sessions on the channel and key that served them, so that
+// upstream prompt caches, which are per account, keep getting hits
+type sessionAffinity struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	pins      map[string]*sessionPin
+	lastSweep time.Time
+}
+
+func newSessionAffinity(ttl time.Duration) *sessionAffinity {
+	return &sessionAffinity{
+		ttl:       ttl,
+		pins:      make(map[string]*sessionPin),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get returns the live pin of a session, or nil. A nil affinity has no pins.
+func (a *sessionAffinity) Get(sessionKey string) *sessionPin {
+	if a == nil || sessionKey == "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pin, ok := a.pins[sessionKey]
+	if !ok || time.Now().After(pin.ExpiresAt) {
+		return nil
+	}
+	copied := *pin
+	return &copied
+}
+
+// Pin (re)binds a session to a channel and key, extending its TTL
+func (a *sessionAffinity) Pin(sessionKey string, channelID, keyID int64) {
+	if a == nil || sessionKey == "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.pins[sessionKey] = &sessionPin{ChannelID: channelID, KeyID: keyID, ExpiresAt: now.Add(a.ttl)}
+
+	// Drop expired pins now and then so idle sessions don't accumulate
+	if now.Sub(a.lastSweep) > a.ttl {
+		for k, pin := range a.pins {
+			if now.After(pin.ExpiresAt) {
+				delete(a.pins, k)
+			}
+		}
+		a.lastSweep = now
+	}
+}
+
+// EnableSessionAffinity turns on sticky session routing with the given TTL; zero leaves it off
+func (s *ProxyService) EnableSessionAffinity(ttl time.Duration) {
+	if ttl > 0 {
+		s.affinity = newSessionAffinity(ttl)
+	}
+}
+
+// anthropicSessionKey identifies the session of an Anthropic request: the client-supplied
+// session ID, then metadata.user_id, then a hash of the system prompt and first message
+func anthropicSessionKey(req *model.AnthropicMessageRequest, sessionID string) string {
+	if sessionID == "" {
+		if metadata, ok := req.Metadata.(map[string]interface{}); ok {
+			sessionID, _ = metadata["user_id"].(string)
+		}
+	}
+	if sessionID == "" && len(req.Messages) > 0 {
+		sessionID = promptHash(req.System, req.Messages[0])
+	}
+	return sessionKey(req.Model, sessionID)
+}
+
+// openAISessionKey identifies the session of an OpenAI request: the client-supplied
+// session ID, then the user field, then a hash of the system prompt and first message
+func openAISessionKey(req *model.OpenAIChatRequest, sessionID string) string {
+	if sessionID == "" {
+		sessionID = req.User
+	}
+	if sessionID == "" && len(req.Messages) > 0 {
+		var system interface{}
+		first := req.Messages[0]
+		if first.Role == "system" && len(req.Messages) > 1 {
+			system, first = first.Content, req.Messages[1]
+		}
+		sessionID = promptHash(system, first)
+	}
+	return sessionKey(req.Model, sessionID)
+}
+
+// sessionKey scopes a session to a display model, since prompt caches are per model
+func sessionKey(displayModel, sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	return displayModel + "\x00" + sessionID
+}
+
+// promptHash fingerprints the stable prefix of a conversation
+func promptHash(system interface{}, firstMessage interface{}) string {
+	encoded, _ := json.Marshal([]interface{}{system, firstMessage})
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
+
+// preferPinnedChannel moves the mappings of a session's pinned channel to the front,
+// unless its latest health check found it unhealthy
+func preferPinnedChannel(healthRepo *repository.HealthRepository, mappings []*model.ModelMappingWithChannel, pin *sessionPin) []*model.ModelMappingWithChannel {
+	if pin == nil {
+		return mappings
+	}
+	if history, err := healthRepo.ListByChannel(pin.ChannelID, 1); err == nil && len(history) > 0 &&
+		history[0].Status == model.HealthStatusUnhealthy {
+		return mappings
+	}
+
+	ordered := make([]*model.ModelMappingWithChannel, 0, len(mappings))
+	for _, mapping := range mappings {
+		if mapping.ChannelID == pin.ChannelID {
+			ordered = append(ordered, mapping)
+		}
+	}
+	for _, mapping := range mappings {
+		if mapping.ChannelID != pin.ChannelID {
+			ordered = append(ordered, mapping)
+		}
+	}
+	return ordered
+}
+
+// selectKey picks the key for a channel, reusing the session's pinned key while it stays enabled.
+// It returns nil when every pooled key of the channel is disabled.
+func (s *ProxyService) selectKey(channel *model.Channel, pin *sessionPin) *model.ChannelKey {
+	if pin != nil && pin.ChannelID == channel.ID && pin.KeyID != 0 {
+		if key := s.keyPool.SelectByID(channel, pin.KeyID); key != nil {
+			return key
+		}
+	}
+	return s.keyPool.Select(channel)
+}
@@ -0,0 +1,311 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// maxCapturedStreamSize bounds the upstream stream kept to rebuild a response for the cache.
+// Longer streams are not cached.
+const maxCapturedStreamSize = 4 << 20
+
+// streamCapture rebuilds the complete response of an upstream stream from its raw SSE bytes,
+// so a stream that finishes fills the response cache like a non-streaming response does.
+// The result has the shape of the upstream's non-streaming response: an Anthropic message,
+// or an OpenAI chat completion. Streams it cannot rebuild faithfully are not captured.
+type streamCapture struct {
+	openAI bool
+	line   []byte // incomplete line carried over between writes
+	size   int
+	failed bool
+	done   bool
+
+	// Anthropic streams
+	message   map[string]interface{}
+	blocks    []map[string]interface{}
+	inputJSON map[int]string
+
+	// OpenAI streams
+	chat     *model.OpenAIChatResponse
+	contents map[int]string
+	finished map[int]string
+}
+
+func newStreamCapture(openAI bool) *streamCapture {
+	return &streamCapture{
+		openAI:    openAI,
+		inputJSON: make(map[int]string),
+		contents:  make(map[int]string),
+		finished:  make(map[int]string),
+	}
+}
+
+// Write takes raw stream bytes. It never fails, so it can sit behind an io.TeeReader.
+func (c *streamCapture) Write(p []byte) (int, error) {
+	if c.failed {
+		return len(p), nil
+	}
+	if c.size += len(p); c.size > maxCapturedStreamSize {
+		c.failed = true
+		return len(p), nil
+	}
+
+	c.line = append(c.line, p...)
+	for {
+		end := bytes.IndexByte(c.line, '\n')
+		if end < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(c.line[:end]))
+		c.line = c.line[end+1:]
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			c.addData(strings.TrimSpace(data))
+		}
+	}
+	return len(p), nil
+}
+
+// Body returns the rebuilt response, if the stream completed and could be rebuilt
+func (c *streamCapture) Body() ([]byte, bool) {
+	if c.failed || !c.done {
+		return nil, false
+	}
+
+	var body []byte
+	var err error
+	if c.openAI {
+		body, err = json.Marshal(c.chatResponse())
+	} else {
+		content := make([]interface{}, len(c.blocks))
+		for i, block := range c.blocks {
+			content[i] = block
+		}
+		c.message["content"] = content
+		body, err = json.Marshal(c.message)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+func (c *streamCapture) addData(data string) {
+	if data == "" || c.failed {
+		return
+	}
+	if c.openAI {
+		c.addChatChunk(data)
+	} else {
+		c.addAnthropicEvent(data)
+	}
+}
+
+// addAnthropicEvent applies an Anthropic stream event to the message being rebuilt
+func (c *streamCapture) addAnthropicEvent(data string) {
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		c.failed = true
+		return
+	}
+
+	index := -1
+	if i, ok := event["index"].(float64); ok {
+		index = int(i)
+	}
+	block := func() map[string]interface{} {
+		if index < 0 || index >= len(c.blocks) || c.blocks[index] == nil {
+			c.failed = true
+			return nil
+		}
+		return c.blocks[index]
+	}
+
+	switch event["type"] {
+	case "message_start":
+		message, ok := event["message"].(map[string]interface{})
+		if !ok {
+			c.failed = true
+			return
+		}
+		c.message = message
+
+	case "content_block_start":
+		contentBlock, ok := event["content_block"].(map[string]interface{})
+		if !ok || index < 0 {
+			c.failed = true
+			return
+		}
+		for len(c.blocks) <= index {
+			c.blocks = append(c.blocks, nil)
+		}
+		c.blocks[index] = contentBlock
+
+	case "content_block_delta":
+		contentBlock := block()
+		delta, _ := event["delta"].(map[string]interface{})
+		if contentBlock == nil || delta == nil {
+			c.failed = true
+			return
+		}
+		switch delta["type"] {
+		case "text_delta":
+			contentBlock["text"] = stringField(contentBlock, "text") + stringField(delta, "text")
+		case "thinking_delta":
+			contentBlock["thinking"] = stringField(contentBlock, "thinking") + stringField(delta, "thinking")
+		case "signature_delta":
+			contentBlock["signature"] = delta["signature"]
+		case "input_json_delta":
+			c.inputJSON[index] += stringField(delta, "partial_json")
+		default:
+			// Deltas the replay can't reproduce, such as citations
+			c.failed = true
+		}
+
+	case "content_block_stop":
+		contentBlock := block()
+		if contentBlock == nil {
+			return
+		}
+		if partial, ok := c.inputJSON[index]; ok {
+			input := map[string]interface{}{}
+			if partial != "" {
+				if err := json.Unmarshal([]byte(partial), &input); err != nil {
+					c.failed = true
+					return
+				}
+			}
+			contentBlock["input"] = input
+		}
+
+	case "message_delta":
+		if c.message == nil {
+			c.failed = true
+			return
+		}
+		if delta, ok := event["delta"].(map[string]interface{}); ok {
+			for field, value := range delta {
+				c.message[field] = value
+			}
+		}
+		if usage, ok := event["usage"].(map[string]interface{}); ok {
+			merged, _ := c.message["usage"].(map[string]interface{})
+			if merged == nil {
+				merged = make(map[string]interface{})
+			}
+			for field, value := range usage {
+				merged[field] = value
+			}
+			c.message["usage"] = merged
+		}
+
+	case "message_stop":
+		c.done = c.message != nil
+
+	case "error":
+		c.failed = true
+	}
+}
+
+// addChatChunk applies an OpenAI stream chunk to the chat completion being rebuilt
+func (c *streamCapture) addChatChunk(data string) {
+	if data == "[DONE]" {
+		c.done = c.chat != nil && len(c.finished) == len(c.contents)
+		return
+	}
+
+	var chunk struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Index        int                        `json:"index"`
+			Delta        map[string]json.RawMessage `json:"delta"`
+			FinishReason *string                    `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *model.OpenAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		c.failed = true
+		return
+	}
+
+	if c.chat == nil {
+		c.chat = &model.OpenAIChatResponse{ID: chunk.ID, Object: "chat.completion", Created: chunk.Created, Model: chunk.Model}
+	}
+	if chunk.Usage != nil {
+		c.chat.Usage = *chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		content := c.contents[choice.Index]
+		for field, raw := range choice.Delta {
+			switch {
+			case field == "content":
+				var text *string
+				if err := json.Unmarshal(raw, &text); err != nil {
+					c.failed = true
+					return
+				}
+				if text != nil {
+					content += *text
+				}
+			case field == "role" || string(raw) == "null":
+			default:
+				// Tool calls and other deltas the replay can't reproduce
+				c.failed = true
+				return
+			}
+		}
+		c.contents[choice.Index] = content
+		if choice.FinishReason != nil {
+			c.finished[choice.Index] = *choice.FinishReason
+		}
+	}
+}
+
+// chatResponse assembles the rebuilt OpenAI chat completion
+func (c *streamCapture) chatResponse() *model.OpenAIChatResponse {
+	indexes := make([]int, 0, len(c.contents))
+	for index := range c.contents {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	resp := *c.chat
+	for _, index := range indexes {
+		resp.Choices = append(resp.Choices, model.OpenAIChoice{
+			Index:        index,
+			Message:      model.OpenAIMessage{Role: "assistant", Content: c.contents[index]},
+			FinishReason: c.finished[index],
+		})
+	}
+	return &resp
+}
+
+// captureStream tees an upstream stream into a streamCapture when its response may be cached
+func (c *responseCache) captureStream(key string, body io.Reader, openAI bool) (io.Reader, *streamCapture) {
+	if c == nil || key == "" {
+		return body, nil
+	}
+	capture := newStreamCapture(openAI)
+	return io.TeeReader(body, capture), capture
+}
+
+// storeStream caches the response rebuilt from a completed stream
+func (c *responseCache) storeStream(key string, capture *streamCapture) {
+	if capture == nil {
+		return
+	}
+	if body, ok := capture.Body(); ok {
+		c.Set(key, body)
+	}
+}
+
+func stringField(m map[string]interface{}, field string) string {
+	s, _ := m[field].(string)
+	return s
+}
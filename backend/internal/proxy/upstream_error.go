@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// statusOverloaded is the status Anthropic uses when its API is temporarily overloaded
+const statusOverloaded = 529
+
+// UpstreamError is a failed exchange with an upstream channel. It keeps the upstream's
+// status and error type so the failure can be reported to clients with matching semantics.
+type UpstreamError struct {
+	StatusCode int    // status of the upstream response, 0 when no response was received
+	Type       string // error type reported by the upstream, "unknown" when it sent none
+	Message    string
+	RetryAfter string // upstream Retry-After header, if any
+	Timeout    bool   // the exchange timed out before a response was received
+	Err        error  // transport error, if any
+}
+
+func (e *UpstreamError) Error() string {
+	if e.StatusCode == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("API error: status %d, %s - %s", e.StatusCode, e.Type, e.Message)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// newUpstreamError builds the error of a non-200 upstream response. Anthropic and OpenAI both
+// nest the error type and message under "error"; other bodies are kept whole as the message.
+func newUpstreamError(httpResp *http.Response, body []byte) *UpstreamError {
+	upstreamErr := &UpstreamError{
+		StatusCode: httpResp.StatusCode,
+		Type:       "unknown",
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: httpResp.Header.Get("Retry-After"),
+	}
+
+	var errResp model.AnthropicErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		upstreamErr.Message = errResp.Error.Message
+		if errResp.Error.Type != "" {
+			upstreamErr.Type = errResp.Error.Type
+		}
+	}
+	return upstreamErr
+}
+
+// transportError wraps a failure to send a request or to read its response
+func transportError(action string, err error) *UpstreamError {
+	var netErr net.Error
+	timeout := errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+
+	return &UpstreamError{
+		Message: fmt.Sprintf("%s: %v", action, err),
+		Timeout: timeout,
+		Err:     err,
+	}
+}
+
+// clientError maps a proxy failure to the status and Anthropic error type reported to clients.
+// Upstream rejections of the channel's own credentials or billing are the gateway's problem,
+// not the client's, so they surface as a bad gateway instead of an authentication error.
+func clientError(err error) (int, string, string) {
+	var quotaErr *model.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return http.StatusTooManyRequests, "rate_limit_error", quotaErr.Error()
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return http.StatusTooManyRequests, "rate_limit_error", rateErr.Error()
+	}
+
+	var accessErr *model.AccessDeniedError
+	if errors.As(err, &accessErr) {
+		if accessErr.Unauthenticated {
+			return http.StatusUnauthorized, "authentication_error", accessErr.Error()
+		}
+		return http.StatusForbidden, "permission_error", accessErr.Error()
+	}
+
+	var notFoundErr *ModelNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return http.StatusNotFound, "not_found_error", notFoundErr.Error()
+	}
+
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) {
+		return http.StatusBadGateway, "api_error", err.Error()
+	}
+
+	message := upstreamErr.Message
+	if upstreamErr.StatusCode == 0 {
+		if upstreamErr.Timeout {
+			return http.StatusGatewayTimeout, "timeout_error", message
+		}
+		return http.StatusBadGateway, "api_error", message
+	}
+
+	switch upstreamErr.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return http.StatusBadRequest, "invalid_request_error", message
+	case http.StatusNotFound:
+		return http.StatusNotFound, "not_found_error", message
+	case http.StatusRequestEntityTooLarge:
+		return http.StatusRequestEntityTooLarge, "request_too_large", message
+	case http.StatusTooManyRequests:
+		return http.StatusTooManyRequests, "rate_limit_error", message
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return http.StatusGatewayTimeout, "timeout_error", message
+	case http.StatusServiceUnavailable, statusOverloaded:
+		return statusOverloaded, "overloaded_error", message
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+		return http.StatusBadGateway, "api_error", "upstream channel rejected the gateway's credentials: " + message
+	case http.StatusInternalServerError:
+		return http.StatusInternalServerError, "api_error", message
+	}
+	return http.StatusBadGateway, "api_error", message
+}
+
+// retryable reports whether another channel could succeed where err failed. An upstream
+// rejecting the request itself with a 4xx, other than a timeout, conflict or rate limit,
+// would reject it on every channel, so failover stops there.
+func retryable(err error) bool {
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.StatusCode < 400 || upstreamErr.StatusCode >= 500 {
+		return true
+	}
+	switch upstreamErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// AnthropicError converts a proxy failure into the status and body an Anthropic client expects
+func AnthropicError(err error) (int, *model.AnthropicErrorResponse) {
+	status, errorType, message := clientError(err)
+	return status, &model.AnthropicErrorResponse{
+		Type:  "error",
+		Error: model.ErrorDetail{Type: errorType, Message: message},
+	}
+}
+
+// OpenAIError converts a proxy failure into the status and body an OpenAI client expects.
+// The Anthropic error type is kept as the code, and overload becomes 503 which OpenAI clients know.
+func OpenAIError(err error) (int, *model.OpenAIErrorResponse) {
+	status, errorType, message := clientError(err)
+
+	openAIType := "api_error"
+	switch status {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusRequestEntityTooLarge:
+		openAIType = "invalid_request_error"
+	case http.StatusTooManyRequests:
+		openAIType = "rate_limit_error"
+	case statusOverloaded:
+		status = http.StatusServiceUnavailable
+	}
+
+	return status, &model.OpenAIErrorResponse{
+		Error: model.OpenAIErrorDetail{Type: openAIType, Message: message, Code: errorType},
+	}
+}
+
+// RetryAfter returns the Retry-After an upstream sent with a failure, or when the exceeded
+// quota or rate limit of a client key frees up, or ""
+func RetryAfter(err error) string {
+	var quotaErr *model.QuotaExceededError
+	if errors.As(err, &quotaErr) && !quotaErr.ResetAt.IsZero() {
+		return secondsUntil(quotaErr.ResetAt)
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) && !rateErr.RetryAt.IsZero() {
+		return secondsUntil(rateErr.RetryAt)
+	}
+
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.RetryAfter
+	}
+	return ""
+}
+
+// secondsUntil formats the whole seconds until t for a Retry-After header, at least 1
+func secondsUntil(t time.Time) string {
+	seconds := int(math.Ceil(time.Until(t).Seconds()))
+	return strconv.Itoa(max(seconds, 1))
+}
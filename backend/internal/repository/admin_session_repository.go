@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// AdminSessionRepository handles admin session data operations. Times are written by the
+// gateway in UTC, truncated to seconds, so they compare correctly as stored.
+type AdminSessionRepository struct {
+	db *sql.DB
+}
+
+// NewAdminSessionRepository creates a new admin session repository
+func NewAdminSessionRepository() *AdminSessionRepository {
+	return &AdminSessionRepository{db: database.DB}
+}
+
+// adminSessionColumns selects from admin_sessions s joined with the owning admin_users u.
+// The role is empty when the session's user was deleted or disabled.
+const adminSessionColumns = `
+	s.id, s.admin_user_id, COALESCE(u.username, ''), CASE WHEN u.is_enabled THEN u.role ELSE '' END, s.csrf_token,
+	COALESCE(s.admin_key_fingerprint, ''),
+	COALESCE(s.ip_address, ''), COALESCE(s.user_agent, ''), s.created_at, s.last_seen_at, s.expires_at, s.revoked_at
+`
+
+const adminSessionTables = `admin_sessions s LEFT JOIN admin_users u ON s.admin_user_id = u.id`
+
+func scanAdminSession(scanner interface{ Scan(...any) error }) (*model.AdminSession, error) {
+	session := &model.AdminSession{}
+	err := scanner.Scan(
+		&session.ID,
+		&session.AdminUserID,
+		&session.Username,
+		&session.Role,
+		&session.CSRFToken,
+		&session.KeyFingerprint,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// sessionTime returns a time as stored in admin_sessions
+func sessionTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// Create stores a new session
+func (r *AdminSessionRepository) Create(session *model.AdminSession, tokenHash string) (int64, error) {
+	query := `
+		INSERT INTO admin_sessions (token_hash, admin_user_id, csrf_token, admin_key_fingerprint, ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(query,
+		tokenHash,
+		session.AdminUserID,
+		session.CSRFToken,
+		session.KeyFingerprint,
+		session.IPAddress,
+		session.UserAgent,
+		sessionTime(session.CreatedAt),
+		sessionTime(session.LastSeenAt),
+		sessionTime(session.ExpiresAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create admin session: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// FindByHash retrieves the session with the given token hash, or nil if there is none
+func (r *AdminSessionRepository) FindByHash(tokenHash string) (*model.AdminSession, error) {
+	query := `SELECT ` + adminSessionColumns + ` FROM ` + adminSessionTables + ` WHERE s.token_hash = ?`
+	session, err := scanAdminSession(r.db.QueryRow(query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin session: %w", err)
+	}
+	return session, nil
+}
+
+// ListActive retrieves the sessions that are neither revoked nor expired, most recently used first
+func (r *AdminSessionRepository) ListActive(now, idleSince time.Time) ([]*model.AdminSession, error) {
+	query := `
+		SELECT ` + adminSessionColumns + ` FROM ` + adminSessionTables + `
+		WHERE s.revoked_at IS NULL AND s.expires_at > ? AND s.last_seen_at > ?
+		  AND (s.admin_user_id = 0 OR u.is_enabled = 1)
+		ORDER BY s.last_seen_at DESC, s.id DESC
+	`
+	rows, err := r.db.Query(query, sessionTime(now), sessionTime(idleSince))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.AdminSession
+	for rows.Next() {
+		session, err := scanAdminSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Touch records that a session was just used
+func (r *AdminSessionRepository) Touch(id int64, now time.Time) error {
+	query := `UPDATE admin_sessions SET last_seen_at = ? WHERE id = ?`
+	if _, err := r.db.Exec(query, sessionTime(now), id); err != nil {
+		return fmt.Errorf("failed to update admin session: %w", err)
+	}
+	return nil
+}
+
+// Revoke ends a session
+func (r *AdminSessionRepository) Revoke(id int64, now time.Time) error {
+	query := `UPDATE admin_sessions SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`
+	result, err := r.db.Exec(query, sessionTime(now), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke admin session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("admin session not found")
+	}
+	return nil
+}
+
+// RevokeByUser ends the sessions of an admin user, except the one with the given ID
+func (r *AdminSessionRepository) RevokeByUser(userID, exceptID int64, now time.Time) error {
+	query := `UPDATE admin_sessions SET revoked_at = ? WHERE admin_user_id = ? AND id != ? AND revoked_at IS NULL`
+	if _, err := r.db.Exec(query, sessionTime(now), userID, exceptID); err != nil {
+		return fmt.Errorf("failed to revoke admin sessions: %w", err)
+	}
+	return nil
+}
+
+// DeleteEnded removes sessions that were revoked or expired before the given time
+func (r *AdminSessionRepository) DeleteEnded(before time.Time) error {
+	query := `DELETE FROM admin_sessions WHERE revoked_at < ? OR expires_at < ? OR last_seen_at < ?`
+	cutoff := sessionTime(before)
+	if _, err := r.db.Exec(query, cutoff, cutoff, cutoff); err != nil {
+		return fmt.Errorf("failed to delete ended admin sessions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// AdminUserRepository handles admin user data operations
+type AdminUserRepository struct {
+	db *sql.DB
+}
+
+// NewAdminUserRepository creates a new admin user repository
+func NewAdminUserRepository() *AdminUserRepository {
+	return &AdminUserRepository{db: database.DB}
+}
+
+const adminUserColumns = `
+	id, username, password_hash, role, is_enabled,
+	COALESCE(totp_secret, ''), totp_enabled, totp_last_step, COALESCE(oidc_subject, ''),
+	last_login_at, created_at, updated_at
+`
+
+func scanAdminUser(scanner interface{ Scan(...any) error }) (*model.AdminUser, error) {
+	user := &model.AdminUser{}
+	err := scanner.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.IsEnabled,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
+		&user.TOTPLastStep,
+		&user.OIDCSubject,
+		&user.LastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Create creates an admin user with the given password hash
+func (r *AdminUserRepository) Create(user *model.AdminUserCreate, passwordHash string) (int64, error) {
+	query := `INSERT INTO admin_users (username, password_hash, role) VALUES (?, ?, ?)`
+	result, err := r.db.Exec(query, user.Username, passwordHash, user.Role)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create admin user: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByID retrieves an admin user by ID
+func (r *AdminUserRepository) GetByID(id int64) (*model.AdminUser, error) {
+	query := `SELECT ` + adminUserColumns + ` FROM admin_users WHERE id = ?`
+	user, err := scanAdminUser(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("admin user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	return user, nil
+}
+
+// FindByUsername retrieves the admin user with the given username, or nil if there is none
+func (r *AdminUserRepository) FindByUsername(username string) (*model.AdminUser, error) {
+	query := `SELECT ` + adminUserColumns + ` FROM admin_users WHERE username = ?`
+	user, err := scanAdminUser(r.db.QueryRow(query, username))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin user: %w", err)
+	}
+	return user, nil
+}
+
+// FindByOIDCSubject retrieves the admin user of an OpenID Connect subject, or nil if there is none
+func (r *AdminUserRepository) FindByOIDCSubject(subject string) (*model.AdminUser, error) {
+	query := `SELECT ` + adminUserColumns + ` FROM admin_users WHERE oidc_subject = ?`
+	user, err := scanAdminUser(r.db.QueryRow(query, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateOIDC creates the passwordless admin user of an OpenID Connect subject
+func (r *AdminUserRepository) CreateOIDC(username, role, subject string) (int64, error) {
+	query := `INSERT INTO admin_users (username, password_hash, role, oidc_subject) VALUES (?, '', ?, ?)`
+	result, err := r.db.Exec(query, username, role, subject)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create admin user: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// List retrieves all admin users
+func (r *AdminUserRepository) List() ([]*model.AdminUser, error) {
+	query := `SELECT ` + adminUserColumns + ` FROM admin_users ORDER BY id ASC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// Count returns the number of admin users
+func (r *AdminUserRepository) Count() (int64, error) {
+	var count int64
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM admin_users`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count admin users: %w", err)
+	}
+	return count, nil
+}
+
+// CountEnabledAdmins returns the number of enabled admin users with the admin role
+func (r *AdminUserRepository) CountEnabledAdmins() (int64, error) {
+	query := `SELECT COUNT(*) FROM admin_users WHERE role = ? AND is_enabled = 1`
+	var count int64
+	if err := r.db.QueryRow(query, model.RoleAdmin).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count admin users: %w", err)
+	}
+	return count, nil
+}
+
+// Update updates an admin user, replacing the password hash when one is given
+func (r *AdminUserRepository) Update(id int64, update *model.AdminUserUpdate, passwordHash *string) error {
+	query := `
+		UPDATE admin_users
+		SET role = COALESCE(?, role),
+		    password_hash = COALESCE(?, password_hash),
+		    is_enabled = COALESCE(?, is_enabled),
+		    totp_secret = CASE WHEN ? THEN '' ELSE totp_secret END,
+		    totp_enabled = CASE WHEN ? THEN 0 ELSE totp_enabled END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(query, update.Role, passwordHash, update.IsEnabled, update.ResetTOTP, update.ResetTOTP, id)
+	if err != nil {
+		return fmt.Errorf("failed to update admin user: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("admin user not found")
+	}
+	return nil
+}
+
+// SetTOTP stores a user's TOTP secret and whether two-factor authentication is on
+func (r *AdminUserRepository) SetTOTP(id int64, secret string, enabled bool) error {
+	query := `UPDATE admin_users SET totp_secret = ?, totp_enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := r.db.Exec(query, secret, enabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update admin user TOTP: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("admin user not found")
+	}
+	return nil
+}
+
+// UseTOTPStep records a TOTP step as used, reporting false if it or a later step was already used
+func (r *AdminUserRepository) UseTOTPStep(id, step int64) (bool, error) {
+	query := `UPDATE admin_users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`
+	result, err := r.db.Exec(query, step, id, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// RecordLogin records that an admin user signed in
+func (r *AdminUserRepository) RecordLogin(id int64) error {
+	_, err := r.db.Exec(`UPDATE admin_users SET last_login_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to record admin user login: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an admin user
+func (r *AdminUserRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM admin_users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete admin user: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("admin user not found")
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// ChannelKeyRepository handles channel key pool data operations
+type ChannelKeyRepository struct {
+	db *sql.DB
+}
+
+// NewChannelKeyRepository creates a new channel key repository
+func NewChannelKeyRepository() *ChannelKeyRepository {
+	return &ChannelKeyRepository{db: database.DB}
+}
+
+const channelKeyColumns = `
+	id, channel_id, COALESCE(name, ''), api_key, is_enabled, usage_count, failure_count,
+	last_used_at, COALESCE(last_error, ''), COALESCE(disabled_reason, ''), created_at, updated_at
+`
+
+// scanChannelKey scans a pooled key row and decrypts its API key
+func scanChannelKey(scanner interface{ Scan(...any) error }) (*model.ChannelKey, error) {
+	key := &model.ChannelKey{}
+	err := scanner.Scan(
+		&key.ID,
+		&key.ChannelID,
+		&key.Name,
+		&key.APIKey,
+		&key.IsEnabled,
+		&key.UsageCount,
+		&key.FailureCount,
+		&key.LastUsedAt,
+		&key.LastError,
+		&key.DisabledReason,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if key.APIKey, err = secret.Open(key.APIKey, apiKeyField(model.StoredKeyTableChannelKeys, key.ID)); err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key of channel key %d: %w", key.ID, err)
+	}
+	key.APIKeyMasked = secret.Mask(key.APIKey)
+	return key, nil
+}
+
+// Create adds a key to a channel's pool. The key is encrypted once the row has an ID to bind it to.
+func (r *ChannelKeyRepository) Create(channelID int64, key *model.ChannelKeyCreate) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel key: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO channel_keys (channel_id, name, api_key) VALUES (?, ?, '')`
+	result, err := tx.Exec(query, channelID, key.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel key: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel key: %w", err)
+	}
+
+	apiKey, err := sealAPIKey(model.StoredKeyTableChannelKeys, id, key.APIKey)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE channel_keys SET api_key = ? WHERE id = ?`, apiKey, id); err != nil {
+		return 0, fmt.Errorf("failed to create channel key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to create channel key: %w", err)
+	}
+	return id, nil
+}
+
+// GetByID retrieves a pooled key by ID
+func (r *ChannelKeyRepository) GetByID(id int64) (*model.ChannelKey, error) {
+	query := `SELECT ` + channelKeyColumns + ` FROM channel_keys WHERE id = ?`
+	key, err := scanChannelKey(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("channel key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel key: %w", err)
+	}
+	return key, nil
+}
+
+// ListByChannel retrieves all pooled keys of a channel
+func (r *ChannelKeyRepository) ListByChannel(channelID int64) ([]*model.ChannelKey, error) {
+	query := `SELECT ` + channelKeyColumns + ` FROM channel_keys WHERE channel_id = ? ORDER BY id ASC`
+	return r.list(query, channelID)
+}
+
+// ListEnabledByChannel retrieves the enabled pooled keys of a channel
+func (r *ChannelKeyRepository) ListEnabledByChannel(channelID int64) ([]*model.ChannelKey, error) {
+	query := `SELECT ` + channelKeyColumns + ` FROM channel_keys WHERE channel_id = ? AND is_enabled = 1 ORDER BY id ASC`
+	return r.list(query, channelID)
+}
+
+func (r *ChannelKeyRepository) list(query string, args ...interface{}) ([]*model.ChannelKey, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.ChannelKey
+	for rows.Next() {
+		key, err := scanChannelKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Update updates a pooled key. Re-enabling a key clears its disabled reason.
+func (r *ChannelKeyRepository) Update(id int64, update *model.ChannelKeyUpdate) error {
+	var apiKey *string
+	if update.APIKey != nil {
+		sealed, err := sealAPIKey(model.StoredKeyTableChannelKeys, id, *update.APIKey)
+		if err != nil {
+			return err
+		}
+		apiKey = &sealed
+	}
+
+	query := `
+		UPDATE channel_keys
+		SET name = COALESCE(?, name),
+		    api_key = COALESCE(?, api_key),
+		    is_enabled = COALESCE(?, is_enabled),
+		    disabled_reason = CASE WHEN ? = 1 THEN '' ELSE disabled_reason END,
+		    failure_count = CASE WHEN ? = 1 THEN 0 ELSE failure_count END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	reenabled := update.IsEnabled != nil && *update.IsEnabled
+	result, err := r.db.Exec(query, update.Name, apiKey, update.IsEnabled, reenabled, reenabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update channel key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("channel key not found")
+	}
+	return nil
+}
+
+// RecordUsage increments the usage counter of a key and stamps its last use
+func (r *ChannelKeyRepository) RecordUsage(id int64) error {
+	query := `UPDATE channel_keys SET usage_count = usage_count + 1, last_used_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to record channel key usage: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure increments the failure counter of a key and stores the last error
+func (r *ChannelKeyRepository) RecordFailure(id int64, lastError string) error {
+	query := `UPDATE channel_keys SET failure_count = failure_count + 1, last_error = ? WHERE id = ?`
+	if _, err := r.db.Exec(query, lastError, id); err != nil {
+		return fmt.Errorf("failed to record channel key failure: %w", err)
+	}
+	return nil
+}
+
+// Disable disables a key and records why
+func (r *ChannelKeyRepository) Disable(id int64, reason string) error {
+	query := `UPDATE channel_keys SET is_enabled = 0, disabled_reason = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.Exec(query, reason, id); err != nil {
+		return fmt.Errorf("failed to disable channel key: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a pooled key
+func (r *ChannelKeyRepository) Delete(id int64) error {
+	query := `DELETE FROM channel_keys WHERE id = ?`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("channel key not found")
+	}
+	return nil
+}
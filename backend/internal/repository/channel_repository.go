@@ -6,6 +6,7 @@ import (
 
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/pkg/database"
+	"github.com/claude-api-gateway/backend/pkg/secret"
 )
 
 // ChannelRepository handles channel data operations
@@ -18,18 +19,60 @@ func NewChannelRepository() *ChannelRepository {
 	return &ChannelRepository{db: database.DB}
 }
 
-// Create creates a new channel
+const channelColumns = `
+	id, name, base_url, api_key, provider, key_strategy, COALESCE(channel_group, ''), is_active, auto_disabled, priority,
+	max_retries, timeout, rate_limit, created_at, updated_at
+`
+
+// scanChannel scans a channel row and decrypts its API key
+func scanChannel(scanner interface{ Scan(...any) error }) (*model.Channel, error) {
+	channel := &model.Channel{}
+	err := scanner.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.BaseURL,
+		&channel.APIKey,
+		&channel.Provider,
+		&channel.KeyStrategy,
+		&channel.Group,
+		&channel.IsActive,
+		&channel.AutoDisabled,
+		&channel.Priority,
+		&channel.MaxRetries,
+		&channel.Timeout,
+		&channel.RateLimit,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if channel.APIKey, err = secret.Open(channel.APIKey, apiKeyField(model.StoredKeyTableChannels, channel.ID)); err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key of channel %d: %w", channel.ID, err)
+	}
+	channel.APIKeyMasked = secret.Mask(channel.APIKey)
+	return channel, nil
+}
+
+// Create creates a new channel. Its API key is encrypted once the row has an ID to bind it to.
 func (r *ChannelRepository) Create(channel *model.ChannelCreate) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO channels (name, base_url, api_key, provider, priority, max_retries, timeout, rate_limit)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO channels (name, base_url, api_key, provider, key_strategy, channel_group, priority, max_retries, timeout, rate_limit)
+		VALUES (?, ?, '', ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.Exec(
+	result, err := tx.Exec(
 		query,
 		channel.Name,
 		channel.BaseURL,
-		channel.APIKey,
 		channel.Provider,
+		channel.KeyStrategy,
+		channel.Group,
 		channel.Priority,
 		channel.MaxRetries,
 		channel.Timeout,
@@ -38,31 +81,28 @@ func (r *ChannelRepository) Create(channel *model.ChannelCreate) (int64, error)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create channel: %w", err)
 	}
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	apiKey, err := sealAPIKey(model.StoredKeyTableChannels, id, channel.APIKey)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE channels SET api_key = ? WHERE id = ?`, apiKey, id); err != nil {
+		return 0, fmt.Errorf("failed to create channel: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to create channel: %w", err)
+	}
+	return id, nil
 }
 
 // GetByID retrieves a channel by ID
 func (r *ChannelRepository) GetByID(id int64) (*model.Channel, error) {
-	query := `
-		SELECT id, name, base_url, api_key, provider, is_active, priority,
-		       max_retries, timeout, rate_limit, created_at, updated_at
-		FROM channels WHERE id = ?
-	`
-	channel := &model.Channel{}
-	err := r.db.QueryRow(query, id).Scan(
-		&channel.ID,
-		&channel.Name,
-		&channel.BaseURL,
-		&channel.APIKey,
-		&channel.Provider,
-		&channel.IsActive,
-		&channel.Priority,
-		&channel.MaxRetries,
-		&channel.Timeout,
-		&channel.RateLimit,
-		&channel.CreatedAt,
-		&channel.UpdatedAt,
-	)
+	query := `SELECT ` + channelColumns + ` FROM channels WHERE id = ?`
+	channel, err := scanChannel(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("channel not found")
 	}
@@ -74,72 +114,32 @@ func (r *ChannelRepository) GetByID(id int64) (*model.Channel, error) {
 
 // List retrieves all channels
 func (r *ChannelRepository) List() ([]*model.Channel, error) {
-	query := `
-		SELECT id, name, base_url, api_key, provider, is_active, priority,
-		       max_retries, timeout, rate_limit, created_at, updated_at
-		FROM channels ORDER BY priority DESC, id ASC
-	`
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list channels: %w", err)
-	}
-	defer rows.Close()
-
-	var channels []*model.Channel
-	for rows.Next() {
-		channel := &model.Channel{}
-		err := rows.Scan(
-			&channel.ID,
-			&channel.Name,
-			&channel.BaseURL,
-			&channel.APIKey,
-			&channel.Provider,
-			&channel.IsActive,
-			&channel.Priority,
-			&channel.MaxRetries,
-			&channel.Timeout,
-			&channel.RateLimit,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan channel: %w", err)
-		}
-		channels = append(channels, channel)
-	}
-	return channels, nil
+	query := `SELECT ` + channelColumns + ` FROM channels ORDER BY priority DESC, id ASC`
+	return r.list(query)
 }
 
 // ListActive retrieves all active channels ordered by priority
 func (r *ChannelRepository) ListActive() ([]*model.Channel, error) {
-	query := `
-		SELECT id, name, base_url, api_key, provider, is_active, priority,
-		       max_retries, timeout, rate_limit, created_at, updated_at
-		FROM channels WHERE is_active = 1 ORDER BY priority DESC, id ASC
-	`
-	rows, err := r.db.Query(query)
+	query := `SELECT ` + channelColumns + ` FROM channels WHERE is_active = 1 ORDER BY priority DESC, id ASC`
+	return r.list(query)
+}
+
+// ListForHealthCheck retrieves active channels plus channels disabled by the health checker
+func (r *ChannelRepository) ListForHealthCheck() ([]*model.Channel, error) {
+	query := `SELECT ` + channelColumns + ` FROM channels WHERE is_active = 1 OR auto_disabled = 1 ORDER BY priority DESC, id ASC`
+	return r.list(query)
+}
+
+func (r *ChannelRepository) list(query string, args ...interface{}) ([]*model.Channel, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list active channels: %w", err)
+		return nil, fmt.Errorf("failed to list channels: %w", err)
 	}
 	defer rows.Close()
 
 	var channels []*model.Channel
 	for rows.Next() {
-		channel := &model.Channel{}
-		err := rows.Scan(
-			&channel.ID,
-			&channel.Name,
-			&channel.BaseURL,
-			&channel.APIKey,
-			&channel.Provider,
-			&channel.IsActive,
-			&channel.Priority,
-			&channel.MaxRetries,
-			&channel.Timeout,
-			&channel.RateLimit,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-		)
+		channel, err := scanChannel(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan channel: %w", err)
 		}
@@ -148,15 +148,27 @@ func (r *ChannelRepository) ListActive() ([]*model.Channel, error) {
 	return channels, nil
 }
 
-// Update updates a channel
+// Update updates a channel. Setting the active status by hand clears auto_disabled, as SetActive does.
 func (r *ChannelRepository) Update(id int64, update *model.ChannelUpdate) error {
+	var apiKey *string
+	if update.APIKey != nil {
+		sealed, err := sealAPIKey(model.StoredKeyTableChannels, id, *update.APIKey)
+		if err != nil {
+			return err
+		}
+		apiKey = &sealed
+	}
+
 	query := `
 		UPDATE channels
 		SET name = COALESCE(?, name),
 		    base_url = COALESCE(?, base_url),
 		    api_key = COALESCE(?, api_key),
 		    provider = COALESCE(?, provider),
+		    key_strategy = COALESCE(?, key_strategy),
+		    channel_group = COALESCE(?, channel_group),
 		    is_active = COALESCE(?, is_active),
+		    auto_disabled = CASE WHEN ? IS NULL THEN auto_disabled ELSE 0 END,
 		    priority = COALESCE(?, priority),
 		    max_retries = COALESCE(?, max_retries),
 		    timeout = COALESCE(?, timeout),
@@ -168,8 +180,11 @@ func (r *ChannelRepository) Update(id int64, update *model.ChannelUpdate) error
 		query,
 		update.Name,
 		update.BaseURL,
-		update.APIKey,
+		apiKey,
 		update.Provider,
+		update.KeyStrategy,
+		update.Group,
+		update.IsActive,
 		update.IsActive,
 		update.Priority,
 		update.MaxRetries,
@@ -189,7 +204,7 @@ func (r *ChannelRepository) Update(id int64, update *model.ChannelUpdate) error
 
 // SetActive sets the active status of a channel
 func (r *ChannelRepository) SetActive(id int64, isActive bool) error {
-	query := `UPDATE channels SET is_active = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	query := `UPDATE channels SET is_active = ?, auto_disabled = 0, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
 	result, err := r.db.Exec(query, isActive, id)
 	if err != nil {
 		return fmt.Errorf("failed to set channel active status: %w", err)
@@ -201,6 +216,15 @@ func (r *ChannelRepository) SetActive(id int64, isActive bool) error {
 	return nil
 }
 
+// SetAutoDisabled deactivates or reactivates a channel on behalf of the health checker
+func (r *ChannelRepository) SetAutoDisabled(id int64, disabled bool) error {
+	query := `UPDATE channels SET is_active = ?, auto_disabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.Exec(query, !disabled, disabled, id); err != nil {
+		return fmt.Errorf("failed to set channel auto disabled status: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes a channel
 func (r *ChannelRepository) Delete(id int64) error {
 	query := `DELETE FROM channels WHERE id = ?`
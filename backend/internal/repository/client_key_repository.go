@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// ClientKeyRepository handles client API key data operations
+type ClientKeyRepository struct {
+	db *sql.DB
+}
+
+// NewClientKeyRepository creates a new client key repository
+func NewClientKeyRepository() *ClientKeyRepository {
+	return &ClientKeyRepository{db: database.DB}
+}
+
+const clientKeyColumns = `
+	id, name, COALESCE(owner, ''), key_hint, is_enabled,
+	daily_token_limit, weekly_token_limit, monthly_token_limit,
+	daily_cost_limit, weekly_cost_limit, monthly_cost_limit, credit_limit,
+	rpm_limit, tpm_limit, concurrency_limit,
+	COALESCE(allowed_models, '[]'), COALESCE(allowed_channel_groups, '[]'), COALESCE(allowed_ips, '[]'),
+	expires_at, revoked_at, last_used_at, created_at, updated_at
+`
+
+func scanClientKey(scanner interface{ Scan(...any) error }) (*model.ClientKey, error) {
+	key := &model.ClientKey{}
+	var allowedModels, allowedChannelGroups, allowedIPs string
+	err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Owner,
+		&key.KeyHint,
+		&key.IsEnabled,
+		&key.DailyTokenLimit,
+		&key.WeeklyTokenLimit,
+		&key.MonthlyTokenLimit,
+		&key.DailyCostLimit,
+		&key.WeeklyCostLimit,
+		&key.MonthlyCostLimit,
+		&key.CreditLimit,
+		&key.RPMLimit,
+		&key.TPMLimit,
+		&key.ConcurrencyLimit,
+		&allowedModels,
+		&allowedChannelGroups,
+		&allowedIPs,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(allowedModels), &key.AllowedModels); err != nil {
+		return nil, fmt.Errorf("failed to parse allowed models: %w", err)
+	}
+	if err := json.Unmarshal([]byte(allowedChannelGroups), &key.AllowedChannelGroups); err != nil {
+		return nil, fmt.Errorf("failed to parse allowed channel groups: %w", err)
+	}
+	if err := json.Unmarshal([]byte(allowedIPs), &key.AllowedIPs); err != nil {
+		return nil, fmt.Errorf("failed to parse allowed IPs: %w", err)
+	}
+	return key, nil
+}
+
+// utcTime converts an optional time to UTC, so stored times compare with SQLite's own
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// encodeStringList encodes a list for a JSON column, storing nil as an empty list
+func encodeStringList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	encoded, _ := json.Marshal(list)
+	return string(encoded)
+}
+
+// Create stores a new client key by its hash
+func (r *ClientKeyRepository) Create(key *model.ClientKeyCreate, keyHash, keyHint string) (int64, error) {
+	query := `
+		INSERT INTO client_keys (name, owner, key_hash, key_hint,
+		                         daily_token_limit, weekly_token_limit, monthly_token_limit,
+		                         daily_cost_limit, weekly_cost_limit, monthly_cost_limit, credit_limit,
+		                         rpm_limit, tpm_limit, concurrency_limit,
+		                         allowed_models, allowed_channel_groups, allowed_ips, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(
+		query,
+		key.Name,
+		key.Owner,
+		keyHash,
+		keyHint,
+		key.DailyTokenLimit,
+		key.WeeklyTokenLimit,
+		key.MonthlyTokenLimit,
+		key.DailyCostLimit,
+		key.WeeklyCostLimit,
+		key.MonthlyCostLimit,
+		key.CreditLimit,
+		key.RPMLimit,
+		key.TPMLimit,
+		key.ConcurrencyLimit,
+		encodeStringList(key.AllowedModels),
+		encodeStringList(key.AllowedChannelGroups),
+		encodeStringList(key.AllowedIPs),
+		utcTime(key.ExpiresAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create client key: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByID retrieves a client key by ID
+func (r *ClientKeyRepository) GetByID(id int64) (*model.ClientKey, error) {
+	query := `SELECT ` + clientKeyColumns + ` FROM client_keys WHERE id = ?`
+	key, err := scanClientKey(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("client key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client key: %w", err)
+	}
+	return key, nil
+}
+
+// FindByHash retrieves the client key with the given hash, or nil if there is none
+func (r *ClientKeyRepository) FindByHash(keyHash string) (*model.ClientKey, error) {
+	query := `SELECT ` + clientKeyColumns + ` FROM client_keys WHERE key_hash = ?`
+	key, err := scanClientKey(r.db.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find client key: %w", err)
+	}
+	return key, nil
+}
+
+// List retrieves all client keys
+func (r *ClientKeyRepository) List() ([]*model.ClientKey, error) {
+	query := `SELECT ` + clientKeyColumns + ` FROM client_keys ORDER BY id ASC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.ClientKey
+	for rows.Next() {
+		key, err := scanClientKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// CountActive counts the client keys that can authenticate requests
+func (r *ClientKeyRepository) CountActive() (int64, error) {
+	query := `
+		SELECT COUNT(*) FROM client_keys
+		WHERE is_enabled = 1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR datetime(expires_at) > datetime('now'))
+	`
+	var count int64
+	if err := r.db.QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count client keys: %w", err)
+	}
+	return count, nil
+}
+
+// Update updates a client key
+func (r *ClientKeyRepository) Update(id int64, update *model.ClientKeyUpdate) error {
+	var allowedModels, allowedChannelGroups, allowedIPs *string
+	if update.AllowedModels != nil {
+		encoded := encodeStringList(update.AllowedModels)
+		allowedModels = &encoded
+	}
+	if update.AllowedChannelGroups != nil {
+		encoded := encodeStringList(update.AllowedChannelGroups)
+		allowedChannelGroups = &encoded
+	}
+	if update.AllowedIPs != nil {
+		encoded := encodeStringList(update.AllowedIPs)
+		allowedIPs = &encoded
+	}
+
+	query := `
+		UPDATE client_keys
+		SET name = COALESCE(?, name),
+		    owner = COALESCE(?, owner),
+		    is_enabled = COALESCE(?, is_enabled),
+		    daily_token_limit = COALESCE(?, daily_token_limit),
+		    weekly_token_limit = COALESCE(?, weekly_token_limit),
+		    monthly_token_limit = COALESCE(?, monthly_token_limit),
+		    daily_cost_limit = COALESCE(?, daily_cost_limit),
+		    weekly_cost_limit = COALESCE(?, weekly_cost_limit),
+		    monthly_cost_limit = COALESCE(?, monthly_cost_limit),
+		    credit_limit = COALESCE(?, credit_limit),
+		    rpm_limit = COALESCE(?, rpm_limit),
+		    tpm_limit = COALESCE(?, tpm_limit),
+		    concurrency_limit = COALESCE(?, concurrency_limit),
+		    allowed_models = COALESCE(?, allowed_models),
+		    allowed_channel_groups = COALESCE(?, allowed_channel_groups),
+		    allowed_ips = COALESCE(?, allowed_ips),
+		    expires_at = CASE WHEN ? THEN NULL ELSE COALESCE(?, expires_at) END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(
+		query,
+		update.Name,
+		update.Owner,
+		update.IsEnabled,
+		update.DailyTokenLimit,
+		update.WeeklyTokenLimit,
+		update.MonthlyTokenLimit,
+		update.DailyCostLimit,
+		update.WeeklyCostLimit,
+		update.MonthlyCostLimit,
+		update.CreditLimit,
+		update.RPMLimit,
+		update.TPMLimit,
+		update.ConcurrencyLimit,
+		allowedModels,
+		allowedChannelGroups,
+		allowedIPs,
+		update.ClearExpiry,
+		utcTime(update.ExpiresAt),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update client key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("client key not found")
+	}
+	return nil
+}
+
+// UpdateSecret replaces the key of a client key that hasn't been revoked
+func (r *ClientKeyRepository) UpdateSecret(id int64, keyHash, keyHint string) error {
+	query := `
+		UPDATE client_keys
+		SET key_hash = ?, key_hint = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND revoked_at IS NULL
+	`
+	result, err := r.db.Exec(query, keyHash, keyHint, id)
+	if err != nil {
+		return fmt.Errorf("failed to rotate client key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("client key not found or revoked")
+	}
+	return nil
+}
+
+// Revoke permanently disables a client key
+func (r *ClientKeyRepository) Revoke(id int64) error {
+	query := `
+		UPDATE client_keys
+		SET is_enabled = 0, revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke client key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("client key not found")
+	}
+	return nil
+}
+
+// RecordUsage stamps the last use of a client key
+func (r *ClientKeyRepository) RecordUsage(id int64) error {
+	query := `UPDATE client_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to record client key usage: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a client key. Its request logs keep the key's ID.
+func (r *ClientKeyRepository) Delete(id int64) error {
+	query := `DELETE FROM client_keys WHERE id = ?`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete client key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("client key not found")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// DeniedRequestRepository handles denied request data operations
+type DeniedRequestRepository struct {
+	db *sql.DB
+}
+
+// NewDeniedRequestRepository creates a new denied request repository
+func NewDeniedRequestRepository() *DeniedRequestRepository {
+	return &DeniedRequestRepository{db: database.DB}
+}
+
+// Create records a denied request
+func (r *DeniedRequestRepository) Create(denied *model.DeniedRequest) (int64, error) {
+	query := `INSERT INTO denied_requests (client_key_id, key_hint, ip_address, path, reason) VALUES (?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, denied.ClientKeyID, denied.KeyHint, denied.IPAddress, denied.Path, denied.Reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create denied request: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// List retrieves denied requests with pagination, newest first
+func (r *DeniedRequestRepository) List(filter *model.DeniedRequestFilter, page, pageSize int) ([]*model.DeniedRequest, int64, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.StartDate != "" {
+		whereClause += " AND d.created_at >= ?"
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		whereClause += " AND d.created_at <= ?"
+		args = append(args, filter.EndDate)
+	}
+	if filter.ClientKeyID > 0 {
+		whereClause += " AND d.client_key_id = ?"
+		args = append(args, filter.ClientKeyID)
+	}
+	if filter.IPAddress != "" {
+		whereClause += " AND d.ip_address = ?"
+		args = append(args, filter.IPAddress)
+	}
+
+	countQuery := "SELECT COUNT(*) FROM denied_requests d " + whereClause
+	var total int64
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count denied requests: %w", err)
+	}
+
+	query := `
+		SELECT d.id, d.client_key_id, COALESCE(k.name, ''), d.key_hint, COALESCE(d.ip_address, ''),
+		       COALESCE(d.path, ''), d.reason, d.created_at
+		FROM denied_requests d
+		LEFT JOIN client_keys k ON d.client_key_id = k.id
+	` + whereClause + ` ORDER BY d.created_at DESC, d.id DESC LIMIT ? OFFSET ?`
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list denied requests: %w", err)
+	}
+	defer rows.Close()
+
+	var denials []*model.DeniedRequest
+	for rows.Next() {
+		denied := &model.DeniedRequest{}
+		err := rows.Scan(
+			&denied.ID,
+			&denied.ClientKeyID,
+			&denied.ClientKeyName,
+			&denied.KeyHint,
+			&denied.IPAddress,
+			&denied.Path,
+			&denied.Reason,
+			&denied.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan denied request: %w", err)
+		}
+		denials = append(denials, denied)
+	}
+	return denials, total, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// FallbackRepository handles model fallback chain data operations
+type FallbackRepository struct {
+	db *sql.DB
+}
+
+// NewFallbackRepository creates a new fallback repository
+func NewFallbackRepository() *FallbackRepository {
+	return &FallbackRepository{db: database.DB}
+}
+
+const fallbackColumns = `id, display_model, fallback_models, is_enabled, created_at, updated_at`
+
+func scanFallback(scanner interface{ Scan(...any) error }) (*model.ModelFallback, error) {
+	fallback := &model.ModelFallback{}
+	var fallbackModels string
+	err := scanner.Scan(
+		&fallback.ID,
+		&fallback.DisplayModel,
+		&fallbackModels,
+		&fallback.IsEnabled,
+		&fallback.CreatedAt,
+		&fallback.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(fallbackModels), &fallback.FallbackModels); err != nil {
+		return nil, fmt.Errorf("failed to parse fallback models: %w", err)
+	}
+	return fallback, nil
+}
+
+// Create creates a new fallback chain
+func (r *FallbackRepository) Create(fallback *model.ModelFallbackCreate) (int64, error) {
+	fallbackModels, _ := json.Marshal(fallback.FallbackModels)
+	query := `INSERT INTO model_fallbacks (display_model, fallback_models) VALUES (?, ?)`
+	result, err := r.db.Exec(query, fallback.DisplayModel, string(fallbackModels))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create fallback: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByID retrieves a fallback chain by ID
+func (r *FallbackRepository) GetByID(id int64) (*model.ModelFallback, error) {
+	query := `SELECT ` + fallbackColumns + ` FROM model_fallbacks WHERE id = ?`
+	fallback, err := scanFallback(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("fallback not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fallback: %w", err)
+	}
+	return fallback, nil
+}
+
+// FindByDisplayModel retrieves the enabled fallback chain of a display model, or nil if there is none
+func (r *FallbackRepository) FindByDisplayModel(displayModel string) (*model.ModelFallback, error) {
+	query := `SELECT ` + fallbackColumns + ` FROM model_fallbacks WHERE display_model = ? AND is_enabled = 1`
+	fallback, err := scanFallback(r.db.QueryRow(query, displayModel))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find fallback: %w", err)
+	}
+	return fallback, nil
+}
+
+// List retrieves all fallback chains
+func (r *FallbackRepository) List() ([]*model.ModelFallback, error) {
+	query := `SELECT ` + fallbackColumns + ` FROM model_fallbacks ORDER BY id ASC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fallbacks: %w", err)
+	}
+	defer rows.Close()
+
+	var fallbacks []*model.ModelFallback
+	for rows.Next() {
+		fallback, err := scanFallback(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fallback: %w", err)
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return fallbacks, nil
+}
+
+// Update updates a fallback chain
+func (r *FallbackRepository) Update(id int64, update *model.ModelFallbackUpdate) error {
+	var fallbackModels *string
+	if update.FallbackModels != nil {
+		encoded, _ := json.Marshal(update.FallbackModels)
+		s := string(encoded)
+		fallbackModels = &s
+	}
+
+	query := `
+		UPDATE model_fallbacks
+		SET display_model = COALESCE(?, display_model),
+		    fallback_models = COALESCE(?, fallback_models),
+		    is_enabled = COALESCE(?, is_enabled),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(query, update.DisplayModel, fallbackModels, update.IsEnabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update fallback: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("fallback not found")
+	}
+	return nil
+}
+
+// Delete deletes a fallback chain
+func (r *FallbackRepository) Delete(id int64) error {
+	query := `DELETE FROM model_fallbacks WHERE id = ?`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fallback: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("fallback not found")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// HealthRepository handles channel health data operations
+type HealthRepository struct {
+	db *sql.DB
+}
+
+// NewHealthRepository creates a new health repository
+func NewHealthRepository() *HealthRepository {
+	return &HealthRepository{db: database.DB}
+}
+
+// Create records a health probe result
+func (r *HealthRepository) Create(health *model.ChannelHealth) (int64, error) {
+	query := `
+		INSERT INTO channel_health (channel_id, status, latency_ms, status_code, error_message, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(
+		query,
+		health.ChannelID,
+		health.Status,
+		health.LatencyMs,
+		health.StatusCode,
+		health.ErrorMessage,
+		health.CheckedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create channel health: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListByChannel retrieves the most recent health probe results of a channel, newest first
+func (r *HealthRepository) ListByChannel(channelID int64, limit int) ([]*model.ChannelHealth, error) {
+	query := `
+		SELECT id, channel_id, status, latency_ms, status_code, COALESCE(error_message, ''), checked_at
+		FROM channel_health WHERE channel_id = ?
+		ORDER BY checked_at DESC, id DESC LIMIT ?
+	`
+	rows, err := r.db.Query(query, channelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel health: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*model.ChannelHealth
+	for rows.Next() {
+		health := &model.ChannelHealth{}
+		err := rows.Scan(
+			&health.ID,
+			&health.ChannelID,
+			&health.Status,
+			&health.LatencyMs,
+			&health.StatusCode,
+			&health.ErrorMessage,
+			&health.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel health: %w", err)
+		}
+		history = append(history, health)
+	}
+	return history, nil
+}
+
+// DeleteBefore removes health records older than the given time
+func (r *HealthRepository) DeleteBefore(before time.Time) (int64, error) {
+	query := `DELETE FROM channel_health WHERE checked_at < ?`
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete channel health: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -19,17 +19,22 @@ func NewLogRepository() *LogRepository {
 	return &LogRepository{db: database.DB}
 }
 
-// Create creates a new request log
+// Create creates a new request log, numbered as the next attempt of its request
 func (r *LogRepository) Create(log *model.RequestLog) (int64, error) {
 	query := `
-		INSERT INTO request_logs (channel_id, request_id, model_name, upstream_model,
+		INSERT INTO request_logs (channel_id, channel_key_id, api_key_masked, request_id, attempt, model_name, upstream_model,
 		                          input_tokens, output_tokens, total_tokens, request_time,
-		                          response_time, latency_ms, status, error_code, error_message, ip_address)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                          response_time, latency_ms, status, error_code, error_message, ip_address, cache_hit,
+		                          upstream_message_id, client_key_id, cost, billed_tokens, billed_cost)
+		VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(attempt), 0) + 1 FROM request_logs WHERE request_id = ?),
+		        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := r.db.Exec(
 		query,
 		log.ChannelID,
+		log.ChannelKeyID,
+		log.APIKeyMasked,
+		log.RequestID,
 		log.RequestID,
 		log.ModelName,
 		log.UpstreamModel,
@@ -43,6 +48,12 @@ func (r *LogRepository) Create(log *model.RequestLog) (int64, error) {
 		log.ErrorCode,
 		log.ErrorMessage,
 		log.IPAddress,
+		log.CacheHit,
+		log.UpstreamMessageID,
+		log.ClientKeyID,
+		log.Cost,
+		log.BilledTokens,
+		log.BilledCost,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create log: %w", err)
@@ -50,15 +61,37 @@ func (r *LogRepository) Create(log *model.RequestLog) (int64, error) {
 	return result.LastInsertId()
 }
 
+// MarkFallback records the originally requested model on the logs of a fallback request
+func (r *LogRepository) MarkFallback(requestID string, fallbackFrom string) error {
+	query := `UPDATE request_logs SET fallback_from = ? WHERE request_id = ?`
+	if _, err := r.db.Exec(query, fallbackFrom, requestID); err != nil {
+		return fmt.Errorf("failed to mark fallback log: %w", err)
+	}
+	return nil
+}
+
+// MarkHedge records the role the latest attempt logged under a request ID played in a hedged
+// request. Earlier failover attempts of the same request keep their logs unmarked.
+func (r *LogRepository) MarkHedge(requestID string, role string) error {
+	query := `UPDATE request_logs SET hedge_role = ? WHERE id = (SELECT MAX(id) FROM request_logs WHERE request_id = ?)`
+	if _, err := r.db.Exec(query, role, requestID); err != nil {
+		return fmt.Errorf("failed to mark hedge log: %w", err)
+	}
+	return nil
+}
+
 // GetByID retrieves a log by ID
 func (r *LogRepository) GetByID(id int64) (*model.RequestLogWithChannel, error) {
 	query := `
-		SELECT l.id, l.channel_id, c.name as channel_name, l.request_id, l.model_name,
-		       l.upstream_model, l.input_tokens, l.output_tokens, l.total_tokens,
-		       l.request_time, l.response_time, l.latency_ms, l.status,
-		       l.error_code, l.error_message, l.ip_address, l.created_at
+		SELECT l.id, l.channel_id, c.name as channel_name, l.channel_key_id, l.api_key_masked, l.request_id, l.attempt, l.model_name,
+		       l.upstream_model, COALESCE(l.fallback_from, ''), COALESCE(l.hedge_role, ''), l.cache_hit,
+		       COALESCE(l.upstream_message_id, ''), l.input_tokens,
+		       l.output_tokens, l.total_tokens, COALESCE(l.cost, 0), l.request_time, l.response_time, l.latency_ms, l.status,
+		       l.error_code, l.error_message, l.ip_address, COALESCE(l.client_key_id, 0), COALESCE(k.name, ''),
+		       l.created_at
 		FROM request_logs l
 		LEFT JOIN channels c ON l.channel_id = c.id
+		LEFT JOIN client_keys k ON l.client_key_id = k.id
 		WHERE l.id = ?
 	`
 	log := &model.RequestLogWithChannel{}
@@ -66,12 +99,20 @@ func (r *LogRepository) GetByID(id int64) (*model.RequestLogWithChannel, error)
 		&log.ID,
 		&log.ChannelID,
 		&log.ChannelName,
+		&log.ChannelKeyID,
+		&log.APIKeyMasked,
 		&log.RequestID,
+		&log.Attempt,
 		&log.ModelName,
 		&log.UpstreamModel,
+		&log.FallbackFrom,
+		&log.HedgeRole,
+		&log.CacheHit,
+		&log.UpstreamMessageID,
 		&log.InputTokens,
 		&log.OutputTokens,
 		&log.TotalTokens,
+		&log.Cost,
 		&log.RequestTime,
 		&log.ResponseTime,
 		&log.LatencyMs,
@@ -79,6 +120,8 @@ func (r *LogRepository) GetByID(id int64) (*model.RequestLogWithChannel, error)
 		&log.ErrorCode,
 		&log.ErrorMessage,
 		&log.IPAddress,
+		&log.ClientKeyID,
+		&log.ClientKeyName,
 		&log.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -115,6 +158,10 @@ func (r *LogRepository) List(filter *model.StatsFilter, page, pageSize int) ([]*
 		whereClause += " AND l.status = ?"
 		args = append(args, filter.Status)
 	}
+	if filter.ClientKeyID > 0 {
+		whereClause += " AND l.client_key_id = ?"
+		args = append(args, filter.ClientKeyID)
+	}
 
 	// Count total
 	countQuery := "SELECT COUNT(*) FROM request_logs l " + whereClause
@@ -126,13 +173,16 @@ func (r *LogRepository) List(filter *model.StatsFilter, page, pageSize int) ([]*
 
 	// Get paginated data
 	query := `
-		SELECT l.id, l.channel_id, c.name as channel_name, l.request_id, l.model_name,
-		       l.upstream_model, l.input_tokens, l.output_tokens, l.total_tokens,
-		       l.request_time, l.response_time, l.latency_ms, l.status,
-		       l.error_code, l.error_message, l.ip_address, l.created_at
+		SELECT l.id, l.channel_id, c.name as channel_name, l.channel_key_id, l.api_key_masked, l.request_id, l.attempt, l.model_name,
+		       l.upstream_model, COALESCE(l.fallback_from, ''), COALESCE(l.hedge_role, ''), l.cache_hit,
+		       COALESCE(l.upstream_message_id, ''), l.input_tokens,
+		       l.output_tokens, l.total_tokens, COALESCE(l.cost, 0), l.request_time, l.response_time, l.latency_ms, l.status,
+		       l.error_code, l.error_message, l.ip_address, COALESCE(l.client_key_id, 0), COALESCE(k.name, ''),
+		       l.created_at
 		FROM request_logs l
 		LEFT JOIN channels c ON l.channel_id = c.id
-	` + whereClause + ` ORDER BY l.request_time DESC LIMIT ? OFFSET ?`
+		LEFT JOIN client_keys k ON l.client_key_id = k.id
+	` + whereClause + ` ORDER BY l.request_time DESC, l.id DESC LIMIT ? OFFSET ?`
 
 	args = append(args, pageSize, (page-1)*pageSize)
 	rows, err := r.db.Query(query, args...)
@@ -148,12 +198,20 @@ func (r *LogRepository) List(filter *model.StatsFilter, page, pageSize int) ([]*
 			&log.ID,
 			&log.ChannelID,
 			&log.ChannelName,
+			&log.ChannelKeyID,
+			&log.APIKeyMasked,
 			&log.RequestID,
+			&log.Attempt,
 			&log.ModelName,
 			&log.UpstreamModel,
+			&log.FallbackFrom,
+			&log.HedgeRole,
+			&log.CacheHit,
+			&log.UpstreamMessageID,
 			&log.InputTokens,
 			&log.OutputTokens,
 			&log.TotalTokens,
+			&log.Cost,
 			&log.RequestTime,
 			&log.ResponseTime,
 			&log.LatencyMs,
@@ -161,6 +219,8 @@ func (r *LogRepository) List(filter *model.StatsFilter, page, pageSize int) ([]*
 			&log.ErrorCode,
 			&log.ErrorMessage,
 			&log.IPAddress,
+			&log.ClientKeyID,
+			&log.ClientKeyName,
 			&log.CreatedAt,
 		)
 		if err != nil {
@@ -171,6 +231,84 @@ func (r *LogRepository) List(filter *model.StatsFilter, page, pageSize int) ([]*
 	return logs, total, nil
 }
 
+// ListAttempts retrieves every attempt of a client request in order, including its hedge and fallback attempts,
+// whose request IDs extend the original one
+func (r *LogRepository) ListAttempts(requestID string) ([]*model.RequestLogWithChannel, error) {
+	query := `
+		SELECT l.id, l.channel_id, c.name as channel_name, l.channel_key_id, l.api_key_masked, l.request_id, l.attempt, l.model_name,
+		       l.upstream_model, COALESCE(l.fallback_from, ''), COALESCE(l.hedge_role, ''), l.cache_hit,
+		       COALESCE(l.upstream_message_id, ''), l.input_tokens,
+		       l.output_tokens, l.total_tokens, COALESCE(l.cost, 0), l.request_time, l.response_time, l.latency_ms, l.status,
+		       l.error_code, l.error_message, l.ip_address, COALESCE(l.client_key_id, 0), COALESCE(k.name, ''),
+		       l.created_at
+		FROM request_logs l
+		LEFT JOIN channels c ON l.channel_id = c.id
+		LEFT JOIN client_keys k ON l.client_key_id = k.id
+		WHERE l.request_id = ? OR l.request_id GLOB ?
+		ORDER BY l.request_time, l.id
+	`
+	rows, err := r.db.Query(query, requestID, requestID+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list request attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*model.RequestLogWithChannel
+	for rows.Next() {
+		log := &model.RequestLogWithChannel{}
+		err := rows.Scan(
+			&log.ID,
+			&log.ChannelID,
+			&log.ChannelName,
+			&log.ChannelKeyID,
+			&log.APIKeyMasked,
+			&log.RequestID,
+			&log.Attempt,
+			&log.ModelName,
+			&log.UpstreamModel,
+			&log.FallbackFrom,
+			&log.HedgeRole,
+			&log.CacheHit,
+			&log.UpstreamMessageID,
+			&log.InputTokens,
+			&log.OutputTokens,
+			&log.TotalTokens,
+			&log.Cost,
+			&log.RequestTime,
+			&log.ResponseTime,
+			&log.LatencyMs,
+			&log.Status,
+			&log.ErrorCode,
+			&log.ErrorMessage,
+			&log.IPAddress,
+			&log.ClientKeyID,
+			&log.ClientKeyName,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// SumClientKeyUsage sums the tokens and estimated cost of a client key's requests made since a time.
+// A zero time sums the key's whole lifetime.
+func (r *LogRepository) SumClientKeyUsage(clientKeyID int64, since time.Time) (int64, float64, error) {
+	query := `
+		SELECT COALESCE(SUM(total_tokens + billed_tokens), 0), COALESCE(SUM(cost + billed_cost), 0)
+		FROM request_logs
+		WHERE client_key_id = ? AND request_time >= ?
+	`
+	var tokens int64
+	var cost float64
+	if err := r.db.QueryRow(query, clientKeyID, since).Scan(&tokens, &cost); err != nil {
+		return 0, 0, fmt.Errorf("failed to sum client key usage: %w", err)
+	}
+	return tokens, cost, nil
+}
+
 // GetChannelStats retrieves statistics per channel
 func (r *LogRepository) GetChannelStats(filter *model.StatsFilter) ([]*model.ChannelStats, error) {
 	whereClause := "WHERE 1=1"
@@ -192,6 +330,7 @@ func (r *LogRepository) GetChannelStats(filter *model.StatsFilter) ([]*model.Cha
 			COUNT(*) as total_requests,
 			SUM(CASE WHEN l.status = 'success' THEN 1 ELSE 0 END) as success_requests,
 			SUM(CASE WHEN l.status != 'success' THEN 1 ELSE 0 END) as failed_requests,
+			SUM(CASE WHEN l.hedge_role = 'hedge' THEN 1 ELSE 0 END) as hedged_requests,
 			COALESCE(SUM(l.input_tokens), 0) as input_tokens,
 			COALESCE(SUM(l.output_tokens), 0) as output_tokens,
 			COALESCE(SUM(l.total_tokens), 0) as total_tokens,
@@ -215,6 +354,7 @@ func (r *LogRepository) GetChannelStats(filter *model.StatsFilter) ([]*model.Cha
 			&stat.TotalRequests,
 			&stat.SuccessRequests,
 			&stat.FailedRequests,
+			&stat.HedgedRequests,
 			&stat.InputTokens,
 			&stat.OutputTokens,
 			&stat.TotalTokens,
@@ -294,6 +434,7 @@ func (r *LogRepository) GetModelStats(filter *model.StatsFilter) ([]*model.Model
 		SELECT
 			l.model_name,
 			COUNT(*) as total_requests,
+			SUM(CASE WHEN l.hedge_role = 'hedge' THEN 1 ELSE 0 END) as hedged_requests,
 			COALESCE(SUM(l.input_tokens), 0) as input_tokens,
 			COALESCE(SUM(l.output_tokens), 0) as output_tokens,
 			COALESCE(SUM(l.total_tokens), 0) as total_tokens
@@ -312,6 +453,7 @@ func (r *LogRepository) GetModelStats(filter *model.StatsFilter) ([]*model.Model
 		err := rows.Scan(
 			&stat.ModelName,
 			&stat.TotalRequests,
+			&stat.HedgedRequests,
 			&stat.InputTokens,
 			&stat.OutputTokens,
 			&stat.TotalTokens,
@@ -324,6 +466,60 @@ func (r *LogRepository) GetModelStats(filter *model.StatsFilter) ([]*model.Model
 	return stats, nil
 }
 
+// GetChannelKeyStats retrieves statistics per pooled key of a channel
+func (r *LogRepository) GetChannelKeyStats(channelID int64, filter *model.StatsFilter) ([]*model.ChannelKeyStats, error) {
+	whereClause := "WHERE l.channel_id = ?"
+	args := []interface{}{channelID}
+
+	if filter.StartDate != "" {
+		whereClause += " AND l.request_time >= ?"
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		whereClause += " AND l.request_time <= ?"
+		args = append(args, filter.EndDate)
+	}
+
+	query := `
+		SELECT
+			l.channel_key_id,
+			MAX(l.api_key_masked) as api_key_masked,
+			COUNT(*) as total_requests,
+			SUM(CASE WHEN l.status = 'success' THEN 1 ELSE 0 END) as success_requests,
+			SUM(CASE WHEN l.status != 'success' THEN 1 ELSE 0 END) as failed_requests,
+			COALESCE(SUM(l.input_tokens), 0) as input_tokens,
+			COALESCE(SUM(l.output_tokens), 0) as output_tokens,
+			COALESCE(SUM(l.total_tokens), 0) as total_tokens
+		FROM request_logs l
+	` + whereClause + ` GROUP BY l.channel_key_id ORDER BY total_requests DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel key stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.ChannelKeyStats
+	for rows.Next() {
+		stat := &model.ChannelKeyStats{}
+		err := rows.Scan(
+			&stat.ChannelKeyID,
+			&stat.APIKeyMasked,
+			&stat.TotalRequests,
+			&stat.SuccessRequests,
+			&stat.FailedRequests,
+			&stat.InputTokens,
+			&stat.OutputTokens,
+			&stat.TotalTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel key stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
 // GetTotalRequests returns total requests in a date range
 func (r *LogRepository) GetTotalRequests(startDate, endDate time.Time) (int64, error) {
 	query := `SELECT COUNT(*) FROM request_logs WHERE request_time >= ? AND request_time <= ?`
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+)
+
+// patternCache holds compiled display model patterns keyed by match type and pattern
+var patternCache sync.Map
+
+// CompileMappingPattern compiles a glob or regex display model into an anchored regexp.
+// Glob "*" and "?" become capture groups that the upstream model can reference as ${1}, ${2}, ...
+func CompileMappingPattern(matchType, pattern string) (*regexp.Regexp, error) {
+	cacheKey := matchType + "\x00" + pattern
+	if re, ok := patternCache.Load(cacheKey); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	var expr string
+	switch matchType {
+	case model.MatchTypeGlob:
+		expr = "^" + globToRegexp(pattern) + "$"
+	case model.MatchTypeRegex:
+		expr = "^(?:" + pattern + ")$"
+	default:
+		return nil, fmt.Errorf("invalid match_type: %s", matchType)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display_model pattern: %w", err)
+	}
+	patternCache.Store(cacheKey, re)
+	return re, nil
+}
+
+// globToRegexp converts a glob pattern into regexp syntax
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString("(.*)")
+		case '?':
+			b.WriteString("(.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// patternSpecificity counts the literal characters a pattern requires.
+// Patterns that pin down more of the model name are tried first.
+func patternSpecificity(matchType, pattern string) int {
+	if matchType == model.MatchTypeGlob {
+		return len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	return literalLength(re)
+}
+
+// literalLength sums the literal runes that every match of a parsed regexp must contain
+func literalLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpConcat, syntax.OpCapture:
+		n := 0
+		for _, sub := range re.Sub {
+			n += literalLength(sub)
+		}
+		return n
+	case syntax.OpPlus:
+		return literalLength(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * literalLength(re.Sub[0])
+	}
+	return 0
+}
+
+// expandUpstreamModel substitutes capture group references in an upstream model template
+func expandUpstreamModel(re *regexp.Regexp, template, displayModel string) string {
+	if !strings.Contains(template, "$") {
+		return template
+	}
+	match := re.FindStringSubmatchIndex(displayModel)
+	if match == nil {
+		return template
+	}
+	return string(re.ExpandString(nil, template, displayModel, match))
+}
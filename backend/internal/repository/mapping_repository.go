@@ -2,7 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/pkg/database"
@@ -21,10 +23,10 @@ func NewMappingRepository() *MappingRepository {
 // Create creates a new model mapping
 func (r *MappingRepository) Create(mapping *model.MappingCreate) (int64, error) {
 	query := `
-		INSERT INTO model_mappings (channel_id, upstream_model, display_model)
-		VALUES (?, ?, ?)
+		INSERT INTO model_mappings (channel_id, upstream_model, display_model, match_type, rewrite_rules, hedge_delay_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.Exec(query, mapping.ChannelID, mapping.UpstreamModel, mapping.DisplayModel)
+	result, err := r.db.Exec(query, mapping.ChannelID, mapping.UpstreamModel, mapping.DisplayModel, mapping.MatchType, encodeRewriteRules(mapping.RewriteRules), mapping.HedgeDelayMs)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create mapping: %w", err)
 	}
@@ -34,15 +36,19 @@ func (r *MappingRepository) Create(mapping *model.MappingCreate) (int64, error)
 // GetByID retrieves a mapping by ID
 func (r *MappingRepository) GetByID(id int64) (*model.ModelMapping, error) {
 	query := `
-		SELECT id, channel_id, upstream_model, display_model, is_enabled, created_at, updated_at
+		SELECT id, channel_id, upstream_model, display_model, match_type, rewrite_rules, hedge_delay_ms, is_enabled, created_at, updated_at
 		FROM model_mappings WHERE id = ?
 	`
 	mapping := &model.ModelMapping{}
+	var rewriteRules sql.NullString
 	err := r.db.QueryRow(query, id).Scan(
 		&mapping.ID,
 		&mapping.ChannelID,
 		&mapping.UpstreamModel,
 		&mapping.DisplayModel,
+		&mapping.MatchType,
+		&rewriteRules,
+		&mapping.HedgeDelayMs,
 		&mapping.IsEnabled,
 		&mapping.CreatedAt,
 		&mapping.UpdatedAt,
@@ -53,6 +59,7 @@ func (r *MappingRepository) GetByID(id int64) (*model.ModelMapping, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get mapping: %w", err)
 	}
+	mapping.RewriteRules = decodeRewriteRules(rewriteRules)
 	return mapping, nil
 }
 
@@ -60,7 +67,7 @@ func (r *MappingRepository) GetByID(id int64) (*model.ModelMapping, error) {
 func (r *MappingRepository) List() ([]*model.ModelMappingWithChannel, error) {
 	query := `
 		SELECT m.id, m.channel_id, c.name as channel_name, m.upstream_model,
-		       m.display_model, m.is_enabled, m.created_at, m.updated_at
+		       m.display_model, m.match_type, m.rewrite_rules, m.hedge_delay_ms, m.is_enabled, m.created_at, m.updated_at
 		FROM model_mappings m
 		LEFT JOIN channels c ON m.channel_id = c.id
 		ORDER BY m.id ASC
@@ -74,12 +81,16 @@ func (r *MappingRepository) List() ([]*model.ModelMappingWithChannel, error) {
 	var mappings []*model.ModelMappingWithChannel
 	for rows.Next() {
 		mapping := &model.ModelMappingWithChannel{}
+		var rewriteRules sql.NullString
 		err := rows.Scan(
 			&mapping.ID,
 			&mapping.ChannelID,
 			&mapping.ChannelName,
 			&mapping.UpstreamModel,
 			&mapping.DisplayModel,
+			&mapping.MatchType,
+			&rewriteRules,
+			&mapping.HedgeDelayMs,
 			&mapping.IsEnabled,
 			&mapping.CreatedAt,
 			&mapping.UpdatedAt,
@@ -87,6 +98,7 @@ func (r *MappingRepository) List() ([]*model.ModelMappingWithChannel, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan mapping: %w", err)
 		}
+		mapping.RewriteRules = decodeRewriteRules(rewriteRules)
 		mappings = append(mappings, mapping)
 	}
 	return mappings, nil
@@ -95,7 +107,7 @@ func (r *MappingRepository) List() ([]*model.ModelMappingWithChannel, error) {
 // ListByChannel retrieves all mappings for a specific channel
 func (r *MappingRepository) ListByChannel(channelID int64) ([]*model.ModelMapping, error) {
 	query := `
-		SELECT id, channel_id, upstream_model, display_model, is_enabled, created_at, updated_at
+		SELECT id, channel_id, upstream_model, display_model, match_type, rewrite_rules, hedge_delay_ms, is_enabled, created_at, updated_at
 		FROM model_mappings WHERE channel_id = ?
 	`
 	rows, err := r.db.Query(query, channelID)
@@ -107,11 +119,15 @@ func (r *MappingRepository) ListByChannel(channelID int64) ([]*model.ModelMappin
 	var mappings []*model.ModelMapping
 	for rows.Next() {
 		mapping := &model.ModelMapping{}
+		var rewriteRules sql.NullString
 		err := rows.Scan(
 			&mapping.ID,
 			&mapping.ChannelID,
 			&mapping.UpstreamModel,
 			&mapping.DisplayModel,
+			&mapping.MatchType,
+			&rewriteRules,
+			&mapping.HedgeDelayMs,
 			&mapping.IsEnabled,
 			&mapping.CreatedAt,
 			&mapping.UpdatedAt,
@@ -119,46 +135,113 @@ func (r *MappingRepository) ListByChannel(channelID int64) ([]*model.ModelMappin
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan mapping: %w", err)
 		}
+		mapping.RewriteRules = decodeRewriteRules(rewriteRules)
 		mappings = append(mappings, mapping)
 	}
 	return mappings, nil
 }
 
-// FindByDisplayModel finds an enabled mapping for a display model
+// FindByDisplayModel finds the enabled mappings that serve a display model.
+// Exact mappings come first in channel priority order, followed by glob and
+// regex mappings ordered by specificity, channel priority and ID. The upstream
+// model of a pattern mapping has its capture group references expanded.
 func (r *MappingRepository) FindByDisplayModel(displayModel string) ([]*model.ModelMappingWithChannel, error) {
 	query := `
 		SELECT m.id, m.channel_id, c.name as channel_name, m.upstream_model,
-		       m.display_model, m.is_enabled, m.created_at, m.updated_at
+		       m.display_model, m.match_type, m.rewrite_rules, m.hedge_delay_ms, m.is_enabled, m.created_at, m.updated_at, c.priority
 		FROM model_mappings m
 		LEFT JOIN channels c ON m.channel_id = c.id
-		WHERE m.display_model = ? AND m.is_enabled = 1 AND c.is_active = 1
-		ORDER BY c.priority DESC
+		WHERE m.display_model = ? AND m.match_type = 'exact' AND m.is_enabled = 1 AND c.is_active = 1
+		ORDER BY c.priority DESC, m.id ASC
 	`
-	rows, err := r.db.Query(query, displayModel)
+	mappings, _, err := r.queryWithPriority(query, displayModel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find mappings by display model: %w", err)
 	}
+
+	query = `
+		SELECT m.id, m.channel_id, c.name as channel_name, m.upstream_model,
+		       m.display_model, m.match_type, m.rewrite_rules, m.hedge_delay_ms, m.is_enabled, m.created_at, m.updated_at, c.priority
+		FROM model_mappings m
+		LEFT JOIN channels c ON m.channel_id = c.id
+		WHERE m.match_type != 'exact' AND m.is_enabled = 1 AND c.is_active = 1
+	`
+	candidates, priorities, err := r.queryWithPriority(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pattern mappings: %w", err)
+	}
+
+	type patternMatch struct {
+		mapping     *model.ModelMappingWithChannel
+		specificity int
+		priority    int
+	}
+	var matches []patternMatch
+	for i, mapping := range candidates {
+		re, err := CompileMappingPattern(mapping.MatchType, mapping.DisplayModel)
+		if err != nil || !re.MatchString(displayModel) {
+			continue
+		}
+		mapping.UpstreamModel = expandUpstreamModel(re, mapping.UpstreamModel, displayModel)
+		matches = append(matches, patternMatch{
+			mapping:     mapping,
+			specificity: patternSpecificity(mapping.MatchType, mapping.DisplayModel),
+			priority:    priorities[i],
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.specificity != b.specificity {
+			return a.specificity > b.specificity
+		}
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		return a.mapping.ID < b.mapping.ID
+	})
+	for _, m := range matches {
+		mappings = append(mappings, m.mapping)
+	}
+	return mappings, nil
+}
+
+// queryWithPriority runs a mapping query whose rows end with the channel priority
+func (r *MappingRepository) queryWithPriority(query string, args ...interface{}) ([]*model.ModelMappingWithChannel, []int, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer rows.Close()
 
 	var mappings []*model.ModelMappingWithChannel
+	var priorities []int
 	for rows.Next() {
 		mapping := &model.ModelMappingWithChannel{}
+		var rewriteRules sql.NullString
+		var priority int
 		err := rows.Scan(
 			&mapping.ID,
 			&mapping.ChannelID,
 			&mapping.ChannelName,
 			&mapping.UpstreamModel,
 			&mapping.DisplayModel,
+			&mapping.MatchType,
+			&rewriteRules,
+			&mapping.HedgeDelayMs,
 			&mapping.IsEnabled,
 			&mapping.CreatedAt,
 			&mapping.UpdatedAt,
+			&priority,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan mapping: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan mapping: %w", err)
 		}
+		mapping.RewriteRules = decodeRewriteRules(rewriteRules)
 		mappings = append(mappings, mapping)
+		priorities = append(priorities, priority)
 	}
-	return mappings, nil
+	return mappings, priorities, nil
 }
 
 // Update updates a mapping
@@ -167,6 +250,9 @@ func (r *MappingRepository) Update(id int64, update *model.MappingUpdate) error
 		UPDATE model_mappings
 		SET upstream_model = COALESCE(?, upstream_model),
 		    display_model = COALESCE(?, display_model),
+		    match_type = COALESCE(?, match_type),
+		    rewrite_rules = COALESCE(?, rewrite_rules),
+		    hedge_delay_ms = COALESCE(?, hedge_delay_ms),
 		    is_enabled = COALESCE(?, is_enabled),
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
@@ -175,6 +261,9 @@ func (r *MappingRepository) Update(id int64, update *model.MappingUpdate) error
 		query,
 		update.UpstreamModel,
 		update.DisplayModel,
+		update.MatchType,
+		encodeRewriteRules(update.RewriteRules),
+		update.HedgeDelayMs,
 		update.IsEnabled,
 		id,
 	)
@@ -201,3 +290,25 @@ func (r *MappingRepository) Delete(id int64) error {
 	}
 	return nil
 }
+
+// encodeRewriteRules serializes rewrite rules for storage; nil rules stay NULL
+func encodeRewriteRules(rules *model.RewriteRules) *string {
+	if rules == nil {
+		return nil
+	}
+	encoded, _ := json.Marshal(rules)
+	s := string(encoded)
+	return &s
+}
+
+// decodeRewriteRules parses stored rewrite rules, treating empty or invalid values as no rules
+func decodeRewriteRules(raw sql.NullString) *model.RewriteRules {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var rules model.RewriteRules
+	if err := json.Unmarshal([]byte(raw.String), &rules); err != nil {
+		return nil
+	}
+	return &rules
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// PriceRepository handles model price data operations
+type PriceRepository struct {
+	db *sql.DB
+}
+
+// NewPriceRepository creates a new price repository
+func NewPriceRepository() *PriceRepository {
+	return &PriceRepository{db: database.DB}
+}
+
+const priceColumns = `id, model_pattern, input_price, output_price, created_at, updated_at`
+
+func scanPrice(scanner interface{ Scan(...any) error }) (*model.ModelPrice, error) {
+	price := &model.ModelPrice{}
+	err := scanner.Scan(
+		&price.ID,
+		&price.ModelPattern,
+		&price.InputPrice,
+		&price.OutputPrice,
+		&price.CreatedAt,
+		&price.UpdatedAt,
+	)
+	return price, err
+}
+
+// Create creates a new model price
+func (r *PriceRepository) Create(price *model.ModelPriceCreate) (int64, error) {
+	query := `INSERT INTO model_prices (model_pattern, input_price, output_price) VALUES (?, ?, ?)`
+	result, err := r.db.Exec(query, price.ModelPattern, price.InputPrice, price.OutputPrice)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create model price: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByID retrieves a model price by ID
+func (r *PriceRepository) GetByID(id int64) (*model.ModelPrice, error) {
+	query := `SELECT ` + priceColumns + ` FROM model_prices WHERE id = ?`
+	price, err := scanPrice(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("model price not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model price: %w", err)
+	}
+	return price, nil
+}
+
+// List retrieves all model prices
+func (r *PriceRepository) List() ([]*model.ModelPrice, error) {
+	query := `SELECT ` + priceColumns + ` FROM model_prices ORDER BY model_pattern ASC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model prices: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []*model.ModelPrice
+	for rows.Next() {
+		price, err := scanPrice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan model price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// FindByModel returns the price of an upstream model, or nil if no pattern matches it.
+// When several patterns match, the most specific one wins.
+func (r *PriceRepository) FindByModel(upstreamModel string) (*model.ModelPrice, error) {
+	prices, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *model.ModelPrice
+	bestSpecificity := -1
+	for _, price := range prices {
+		re, err := CompileMappingPattern(model.MatchTypeGlob, price.ModelPattern)
+		if err != nil || !re.MatchString(upstreamModel) {
+			continue
+		}
+		if specificity := patternSpecificity(model.MatchTypeGlob, price.ModelPattern); specificity > bestSpecificity {
+			best = price
+			bestSpecificity = specificity
+		}
+	}
+	return best, nil
+}
+
+// Update updates a model price
+func (r *PriceRepository) Update(id int64, update *model.ModelPriceUpdate) error {
+	query := `
+		UPDATE model_prices
+		SET model_pattern = COALESCE(?, model_pattern),
+		    input_price = COALESCE(?, input_price),
+		    output_price = COALESCE(?, output_price),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(query, update.ModelPattern, update.InputPrice, update.OutputPrice, id)
+	if err != nil {
+		return fmt.Errorf("failed to update model price: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("model price not found")
+	}
+	return nil
+}
+
+// Delete deletes a model price
+func (r *PriceRepository) Delete(id int64) error {
+	query := `DELETE FROM model_prices WHERE id = ?`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete model price: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("model price not found")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// ResponseCacheRepository handles persisted response cache entries
+type ResponseCacheRepository struct {
+	db *sql.DB
+}
+
+// NewResponseCacheRepository creates a new response cache repository
+func NewResponseCacheRepository() *ResponseCacheRepository {
+	return &ResponseCacheRepository{db: database.DB}
+}
+
+// Get retrieves a cache entry by key, returning nil if there is none
+func (r *ResponseCacheRepository) Get(cacheKey string) (*model.ResponseCacheEntry, error) {
+	query := `SELECT cache_key, body, expires_at, created_at FROM response_cache WHERE cache_key = ?`
+	entry := &model.ResponseCacheEntry{}
+	var body string
+	err := r.db.QueryRow(query, cacheKey).Scan(&entry.CacheKey, &body, &entry.ExpiresAt, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response cache entry: %w", err)
+	}
+	entry.Body = []byte(body)
+	return entry, nil
+}
+
+// Set stores a cache entry, replacing any entry with the same key
+func (r *ResponseCacheRepository) Set(entry *model.ResponseCacheEntry) error {
+	query := `
+		INSERT INTO response_cache (cache_key, body, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(cache_key) DO UPDATE SET body = excluded.body, expires_at = excluded.expires_at,
+		                                     created_at = CURRENT_TIMESTAMP
+	`
+	if _, err := r.db.Exec(query, entry.CacheKey, string(entry.Body), entry.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to set response cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes entries that expired before the given time
+func (r *ResponseCacheRepository) DeleteExpired(now time.Time) (int64, error) {
+	query := `DELETE FROM response_cache WHERE expires_at < ?`
+	result, err := r.db.Exec(query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired response cache entries: %w", err)
+	}
+	return result.RowsAffected()
+}
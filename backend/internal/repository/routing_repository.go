@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+)
+
+// RoutingRepository handles routing rule data operations
+type RoutingRepository struct {
+	db *sql.DB
+}
+
+// NewRoutingRepository creates a new routing repository
+func NewRoutingRepository() *RoutingRepository {
+	return &RoutingRepository{db: database.DB}
+}
+
+const routingRuleColumns = `
+	id, name, priority, COALESCE(model_pattern, ''), min_input_tokens, has_images, has_tools, has_thinking,
+	target_group, is_enabled, created_at, updated_at
+`
+
+func scanRoutingRule(scanner interface{ Scan(...any) error }) (*model.RoutingRule, error) {
+	rule := &model.RoutingRule{}
+	err := scanner.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Priority,
+		&rule.ModelPattern,
+		&rule.MinInputTokens,
+		&rule.HasImages,
+		&rule.HasTools,
+		&rule.HasThinking,
+		&rule.TargetGroup,
+		&rule.IsEnabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	return rule, err
+}
+
+// Create creates a new routing rule
+func (r *RoutingRepository) Create(rule *model.RoutingRuleCreate) (int64, error) {
+	query := `
+		INSERT INTO routing_rules (name, priority, model_pattern, min_input_tokens, has_images, has_tools, has_thinking, target_group)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(
+		query,
+		rule.Name,
+		rule.Priority,
+		rule.ModelPattern,
+		rule.MinInputTokens,
+		rule.HasImages,
+		rule.HasTools,
+		rule.HasThinking,
+		rule.TargetGroup,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create routing rule: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByID retrieves a routing rule by ID
+func (r *RoutingRepository) GetByID(id int64) (*model.RoutingRule, error) {
+	query := `SELECT ` + routingRuleColumns + ` FROM routing_rules WHERE id = ?`
+	rule, err := scanRoutingRule(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("routing rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routing rule: %w", err)
+	}
+	return rule, nil
+}
+
+// List retrieves all routing rules in evaluation order
+func (r *RoutingRepository) List() ([]*model.RoutingRule, error) {
+	query := `SELECT ` + routingRuleColumns + ` FROM routing_rules ORDER BY priority DESC, id ASC`
+	return r.list(query)
+}
+
+// ListEnabled retrieves the enabled routing rules in evaluation order
+func (r *RoutingRepository) ListEnabled() ([]*model.RoutingRule, error) {
+	query := `SELECT ` + routingRuleColumns + ` FROM routing_rules WHERE is_enabled = 1 ORDER BY priority DESC, id ASC`
+	return r.list(query)
+}
+
+func (r *RoutingRepository) list(query string, args ...interface{}) ([]*model.RoutingRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routing rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*model.RoutingRule
+	for rows.Next() {
+		rule, err := scanRoutingRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan routing rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Update updates a routing rule
+func (r *RoutingRepository) Update(id int64, update *model.RoutingRuleUpdate) error {
+	query := `
+		UPDATE routing_rules
+		SET name = COALESCE(?, name),
+		    priority = COALESCE(?, priority),
+		    model_pattern = COALESCE(?, model_pattern),
+		    min_input_tokens = COALESCE(?, min_input_tokens),
+		    has_images = COALESCE(?, has_images),
+		    has_tools = COALESCE(?, has_tools),
+		    has_thinking = COALESCE(?, has_thinking),
+		    target_group = COALESCE(?, target_group),
+		    is_enabled = COALESCE(?, is_enabled),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	result, err := r.db.Exec(
+		query,
+		update.Name,
+		update.Priority,
+		update.ModelPattern,
+		update.MinInputTokens,
+		update.HasImages,
+		update.HasTools,
+		update.HasThinking,
+		update.TargetGroup,
+		update.IsEnabled,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update routing rule: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("routing rule not found")
+	}
+	return nil
+}
+
+// Delete deletes a routing rule
+func (r *RoutingRepository) Delete(id int64) error {
+	query := `DELETE FROM routing_rules WHERE id = ?`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete routing rule: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("routing rule not found")
+	}
+	return nil
+}
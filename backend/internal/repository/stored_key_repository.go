@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/database"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// StoredKeyRepository reads and rewrites upstream API keys as stored, without decrypting them
+type StoredKeyRepository struct {
+	db *sql.DB
+}
+
+// NewStoredKeyRepository creates a new stored key repository
+func NewStoredKeyRepository() *StoredKeyRepository {
+	return &StoredKeyRepository{db: database.DB}
+}
+
+// List retrieves the stored API keys of all channels and pooled keys
+func (r *StoredKeyRepository) List() ([]*model.StoredAPIKey, error) {
+	query := `
+		SELECT 'channels', id, api_key FROM channels
+		UNION ALL
+		SELECT 'channel_keys', id, api_key FROM channel_keys
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.StoredAPIKey
+	for rows.Next() {
+		key := &model.StoredAPIKey{}
+		if err := rows.Scan(&key.Table, &key.ID, &key.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan stored key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Replace swaps a stored API key for a new value. It reports false, leaving the row alone,
+// when the key was changed since it was read.
+func (r *StoredKeyRepository) Replace(key *model.StoredAPIKey, value string) (bool, error) {
+	var query string
+	switch key.Table {
+	case model.StoredKeyTableChannels:
+		query = `UPDATE channels SET api_key = ? WHERE id = ? AND api_key = ?`
+	case model.StoredKeyTableChannelKeys:
+		query = `UPDATE channel_keys SET api_key = ? WHERE id = ? AND api_key = ?`
+	default:
+		return false, fmt.Errorf("unknown key table: %s", key.Table)
+	}
+
+	result, err := r.db.Exec(query, value, key.ID, key.Value)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace stored key: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// apiKeyField names where the API key of a row is stored, which its encryption is bound to
+func apiKeyField(table string, id int64) string {
+	return secret.Field(table, model.StoredKeyColumn, id)
+}
+
+// sealAPIKey encrypts the API key of a row for storage
+func sealAPIKey(table string, id int64, apiKey string) (string, error) {
+	sealed, err := secret.Seal(apiKey, apiKeyField(table, id))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt API key: %w", err)
+	}
+	return sealed, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// sessionTouchInterval limits how often a session's last use is written, so idle expiry is
+// accurate to about a minute
+const sessionTouchInterval = time.Minute
+
+// AdminSessionService handles admin web UI sessions
+type AdminSessionService struct {
+	sessionRepo *repository.AdminSessionRepository
+	adminKey    string
+	idleTimeout time.Duration
+	maxAge      time.Duration
+}
+
+// NewAdminSessionService creates a new admin session service. Sessions expire after idleTimeout
+// without use and after maxAge regardless; sessions signed in with ADMIN_API_KEY also end once
+// adminKey is no longer the key they were signed in with.
+func NewAdminSessionService(adminKey string, idleTimeout, maxAge time.Duration) *AdminSessionService {
+	return &AdminSessionService{
+		sessionRepo: repository.NewAdminSessionRepository(),
+		adminKey:    adminKey,
+		idleTimeout: idleTimeout,
+		maxAge:      maxAge,
+	}
+}
+
+// MaxAge returns how long a session lasts at most
+func (s *AdminSessionService) MaxAge() time.Duration {
+	return s.maxAge
+}
+
+// Create starts a session for a signed-in admin user, or for ADMIN_API_KEY when userID is 0.
+// The token is returned only here.
+func (s *AdminSessionService) Create(userID int64, ipAddress, userAgent string) (*model.AdminSessionSecret, error) {
+	token, err := secret.Generate("")
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := secret.Generate("")
+	if err != nil {
+		return nil, err
+	}
+
+	// Ended sessions are only kept until the next sign-in
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := s.sessionRepo.DeleteEnded(now.Add(-s.idleTimeout)); err != nil {
+		return nil, err
+	}
+
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	session := &model.AdminSession{
+		AdminUserID: userID,
+		CSRFToken:   csrfToken,
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(s.maxAge),
+	}
+	if userID == 0 {
+		session.KeyFingerprint = s.adminKeyFingerprint(session)
+	}
+	id, err := s.sessionRepo.Create(session, secret.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	session.ID = id
+	return &model.AdminSessionSecret{AdminSession: session, Token: token}, nil
+}
+
+// Authenticate returns the active session with the given token, or nil if the token is
+// unknown, its session was revoked or has expired, or its user was deleted or disabled.
+// The session's role is its user's current one; ADMIN_API_KEY sessions are admins as long as
+// ADMIN_API_KEY is still the key they were signed in with.
+func (s *AdminSessionService) Authenticate(token string) (*model.AdminSession, error) {
+	if token == "" {
+		return nil, nil
+	}
+	session, err := s.sessionRepo.FindByHash(secret.Hash(token))
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) || now.Sub(session.LastSeenAt) >= s.idleTimeout {
+		return nil, nil
+	}
+	if session.AdminUserID == 0 {
+		if !s.adminKeyValid(session) {
+			return nil, nil
+		}
+		session.Role = model.RoleAdmin
+	} else if session.Role == "" {
+		return nil, nil
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessionRepo.Touch(session.ID, now); err != nil {
+			return nil, err
+		}
+		session.LastSeenAt = now
+	}
+	return session, nil
+}
+
+// List retrieves the active sessions, marking the one with the given ID as current
+func (s *AdminSessionService) List(currentID int64) ([]*model.AdminSession, error) {
+	now := time.Now()
+	sessions, err := s.sessionRepo.ListActive(now, now.Add(-s.idleTimeout))
+	if err != nil {
+		return nil, err
+	}
+	active := sessions[:0]
+	for _, session := range sessions {
+		if session.AdminUserID == 0 {
+			if !s.adminKeyValid(session) {
+				continue
+			}
+			session.Role = model.RoleAdmin
+		}
+		session.Current = session.ID == currentID
+		active = append(active, session)
+	}
+	return active, nil
+}
+
+// Revoke ends a session immediately
+func (s *AdminSessionService) Revoke(id int64) error {
+	return s.sessionRepo.Revoke(id, time.Now())
+}
+
+// adminKeyFingerprint fingerprints ADMIN_API_KEY for a session. It mixes in the session's random
+// CSRF token, so the stored value can't be used to test guesses of the key across sessions.
+func (s *AdminSessionService) adminKeyFingerprint(session *model.AdminSession) string {
+	mac := hmac.New(sha256.New, []byte(s.adminKey))
+	mac.Write([]byte(session.CSRFToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// adminKeyValid reports whether an ADMIN_API_KEY session was signed in with the configured key
+func (s *AdminSessionService) adminKeyValid(session *model.AdminSession) bool {
+	return s.adminKey != "" && hmac.Equal([]byte(session.KeyFingerprint), []byte(s.adminKeyFingerprint(session)))
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpIssuer names the gateway in authenticator apps
+const totpIssuer = "Claude API Gateway"
+
+// dummyPasswordHash is compared against when a username is unknown, so failed sign-ins take
+// as long whether or not the user exists
+const dummyPasswordHash = "$2a$10$d5yz19DxLgRdgi/lD5zCqe.Urc1WUxdDMOXhBJThkR6hFFn82B3tK"
+
+// Sign-in failures of admin users
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrTOTPRequired       = errors.New("TOTP code required")
+	ErrInvalidTOTP        = errors.New("invalid TOTP code")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,64}$`)
+
+// AdminUserService handles admin user accounts
+type AdminUserService struct {
+	userRepo    *repository.AdminUserRepository
+	sessionRepo *repository.AdminSessionRepository
+}
+
+// NewAdminUserService creates a new admin user service
+func NewAdminUserService() *AdminUserService {
+	return &AdminUserService{
+		userRepo:    repository.NewAdminUserRepository(),
+		sessionRepo: repository.NewAdminSessionRepository(),
+	}
+}
+
+// Bootstrap creates the first admin user from the configured credentials if there are no
+// admin users yet
+func (s *AdminUserService) Bootstrap(username, password string) (bool, error) {
+	if username == "" || password == "" {
+		return false, nil
+	}
+	count, err := s.userRepo.Count()
+	if err != nil || count > 0 {
+		return false, err
+	}
+	_, err = s.Create(&model.AdminUserCreate{Username: username, Password: password, Role: model.RoleAdmin})
+	return err == nil, err
+}
+
+// Create creates an admin user
+func (s *AdminUserService) Create(create *model.AdminUserCreate) (*model.AdminUser, error) {
+	if !usernamePattern.MatchString(create.Username) {
+		return nil, fmt.Errorf("username must be 1-64 letters, digits or ._@-")
+	}
+	if err := validatePassword(create.Password); err != nil {
+		return nil, err
+	}
+	if !model.ValidRole(create.Role) {
+		return nil, fmt.Errorf("invalid role: %s", create.Role)
+	}
+
+	existing, err := s.userRepo.FindByUsername(create.Username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("username already exists: %s", create.Username)
+	}
+
+	hash, err := hashPassword(create.Password)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.userRepo.Create(create, hash)
+	if err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetByID(id)
+}
+
+// GetByID retrieves an admin user by ID
+func (s *AdminUserService) GetByID(id int64) (*model.AdminUser, error) {
+	return s.userRepo.GetByID(id)
+}
+
+// List retrieves all admin users
+func (s *AdminUserService) List() ([]*model.AdminUser, error) {
+	return s.userRepo.List()
+}
+
+// Update updates an admin user. A new password signs the user out everywhere.
+func (s *AdminUserService) Update(id int64, update *model.AdminUserUpdate) error {
+	if update.Role != nil && !model.ValidRole(*update.Role) {
+		return fmt.Errorf("invalid role: %s", *update.Role)
+	}
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	demoted := update.Role != nil && *update.Role != model.RoleAdmin
+	disabled := update.IsEnabled != nil && !*update.IsEnabled
+	if demoted || disabled {
+		if err := s.checkNotLastAdmin(user); err != nil {
+			return err
+		}
+	}
+
+	var passwordHash *string
+	if update.Password != nil {
+		if err := validatePassword(*update.Password); err != nil {
+			return err
+		}
+		hash, err := hashPassword(*update.Password)
+		if err != nil {
+			return err
+		}
+		passwordHash = &hash
+	}
+
+	if err := s.userRepo.Update(id, update, passwordHash); err != nil {
+		return err
+	}
+	if passwordHash != nil {
+		return s.sessionRepo.RevokeByUser(id, 0, time.Now())
+	}
+	return nil
+}
+
+// Delete deletes an admin user and ends their sessions
+func (s *AdminUserService) Delete(id int64) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNotLastAdmin(user); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(id); err != nil {
+		return err
+	}
+	return s.sessionRepo.RevokeByUser(id, 0, time.Now())
+}
+
+// checkNotLastAdmin refuses to demote, disable or delete the only enabled admin user, which
+// would leave only ADMIN_API_KEY able to manage accounts
+func (s *AdminUserService) checkNotLastAdmin(user *model.AdminUser) error {
+	if user.Role != model.RoleAdmin || !user.IsEnabled {
+		return nil
+	}
+	count, err := s.userRepo.CountEnabledAdmins()
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return fmt.Errorf("cannot demote, disable or delete the last admin user")
+	}
+	return nil
+}
+
+// Authenticate checks an admin user's password and, if they enabled two-factor authentication,
+// their TOTP code. It returns ErrInvalidCredentials, ErrTOTPRequired or ErrInvalidTOTP when
+// sign-in fails.
+func (s *AdminUserService) Authenticate(username, password, code string) (*model.AdminUser, error) {
+	user, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsEnabled {
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.TOTPEnabled {
+		if code == "" {
+			return nil, ErrTOTPRequired
+		}
+		if err := s.verifyTOTP(user, code); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.RecordLogin(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SignInOIDC returns the admin user of an OpenID Connect subject, creating it on first sign-in.
+// The role from the identity provider replaces the stored one, so changes there apply at the
+// next sign-in.
+func (s *AdminUserService) SignInOIDC(subject, username, role string) (*model.AdminUser, error) {
+	user, err := s.userRepo.FindByOIDCSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		if !usernamePattern.MatchString(username) {
+			return nil, fmt.Errorf("username %q from the identity provider is not valid", username)
+		}
+		existing, err := s.userRepo.FindByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, fmt.Errorf("username %s is already used by another admin user", username)
+		}
+		if _, err := s.userRepo.CreateOIDC(username, role, subject); err != nil {
+			return nil, err
+		}
+	} else {
+		if !user.IsEnabled {
+			return nil, fmt.Errorf("admin user %s is disabled", user.Username)
+		}
+		if user.Role != role {
+			if err := s.userRepo.Update(user.ID, &model.AdminUserUpdate{Role: &role}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	user, err = s.userRepo.FindByOIDCSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.RecordLogin(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword changes a signed-in user's password and ends their other sessions
+func (s *AdminUserService) ChangePassword(id, sessionID int64, change *model.PasswordChange) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(change.CurrentPassword)) != nil {
+		return ErrWrongPassword
+	}
+	if err := validatePassword(change.NewPassword); err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(change.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(id, &model.AdminUserUpdate{}, &hash); err != nil {
+		return err
+	}
+	return s.sessionRepo.RevokeByUser(id, sessionID, time.Now())
+}
+
+// SetupTOTP generates a new TOTP secret for a user. It takes effect once EnableTOTP confirms
+// the user's authenticator produces matching codes.
+func (s *AdminUserService) SetupTOTP(id int64) (*model.TOTPSetup, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTP(id, secret, false); err != nil {
+		return nil, err
+	}
+	return &model.TOTPSetup{Secret: secret, URI: totp.URI(totpIssuer, user.Username, secret)}, nil
+}
+
+// EnableTOTP turns on two-factor authentication once a code from the new secret checks out
+func (s *AdminUserService) EnableTOTP(id int64, code string) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return fmt.Errorf("set up two-factor authentication first")
+	}
+	if err := s.verifyTOTP(user, code); err != nil {
+		return err
+	}
+	return s.userRepo.SetTOTP(id, user.TOTPSecret, true)
+}
+
+// DisableTOTP turns off two-factor authentication, confirmed by a current code
+func (s *AdminUserService) DisableTOTP(id int64, code string) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if err := s.verifyTOTP(user, code); err != nil {
+		return err
+	}
+	return s.userRepo.SetTOTP(id, "", false)
+}
+
+// verifyTOTP checks a TOTP code of a user, accepting each time step only once
+func (s *AdminUserService) verifyTOTP(user *model.AdminUser, code string) error {
+	step, ok := totp.Verify(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidTOTP
+	}
+	fresh, err := s.userRepo.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTOTP
+	}
+	return nil
+}
+
+// validatePassword checks a new password's length; bcrypt only uses the first 72 bytes
+func validatePassword(password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return fmt.Errorf("password must be 8 to 72 bytes long")
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
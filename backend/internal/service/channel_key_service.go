@@ -0,0 +1,61 @@
+package service
+
+import (
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// ChannelKeyService handles channel key pool business logic
+type ChannelKeyService struct {
+	keyRepo     *repository.ChannelKeyRepository
+	channelRepo *repository.ChannelRepository
+	logRepo     *repository.LogRepository
+}
+
+// NewChannelKeyService creates a new channel key service
+func NewChannelKeyService() *ChannelKeyService {
+	return &ChannelKeyService{
+		keyRepo:     repository.NewChannelKeyRepository(),
+		channelRepo: repository.NewChannelRepository(),
+		logRepo:     repository.NewLogRepository(),
+	}
+}
+
+// Create adds a key to a channel's pool
+func (s *ChannelKeyService) Create(channelID int64, create *model.ChannelKeyCreate) (int64, error) {
+	// Verify channel exists
+	if _, err := s.channelRepo.GetByID(channelID); err != nil {
+		return 0, err
+	}
+
+	return s.keyRepo.Create(channelID, create)
+}
+
+// GetByID retrieves a pooled key by ID
+func (s *ChannelKeyService) GetByID(id int64) (*model.ChannelKey, error) {
+	return s.keyRepo.GetByID(id)
+}
+
+// ListByChannel retrieves all pooled keys of a channel
+func (s *ChannelKeyService) ListByChannel(channelID int64) ([]*model.ChannelKey, error) {
+	return s.keyRepo.ListByChannel(channelID)
+}
+
+// Update updates a pooled key
+func (s *ChannelKeyService) Update(id int64, update *model.ChannelKeyUpdate) error {
+	// API keys are only returned masked, so an empty key means keep the current one
+	if update.APIKey != nil && *update.APIKey == "" {
+		update.APIKey = nil
+	}
+	return s.keyRepo.Update(id, update)
+}
+
+// Delete deletes a pooled key
+func (s *ChannelKeyService) Delete(id int64) error {
+	return s.keyRepo.Delete(id)
+}
+
+// GetStats returns per-key usage statistics for a channel
+func (s *ChannelKeyService) GetStats(channelID int64, filter *model.StatsFilter) ([]*model.ChannelKeyStats, error) {
+	return s.logRepo.GetChannelKeyStats(channelID, filter)
+}
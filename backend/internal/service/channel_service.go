@@ -10,17 +10,26 @@ import (
 // ChannelService handles channel business logic
 type ChannelService struct {
 	channelRepo *repository.ChannelRepository
+	healthRepo  *repository.HealthRepository
 }
 
 // NewChannelService creates a new channel service
 func NewChannelService() *ChannelService {
 	return &ChannelService{
 		channelRepo: repository.NewChannelRepository(),
+		healthRepo:  repository.NewHealthRepository(),
 	}
 }
 
 // Create creates a new channel
 func (s *ChannelService) Create(create *model.ChannelCreate) (int64, error) {
+	if create.KeyStrategy == "" {
+		create.KeyStrategy = model.KeyStrategyRoundRobin
+	}
+	if err := validateKeyStrategy(create.KeyStrategy); err != nil {
+		return 0, err
+	}
+
 	return s.channelRepo.Create(create)
 }
 
@@ -36,6 +45,16 @@ func (s *ChannelService) List() ([]*model.Channel, error) {
 
 // Update updates a channel
 func (s *ChannelService) Update(id int64, update *model.ChannelUpdate) error {
+	if update.KeyStrategy != nil {
+		if err := validateKeyStrategy(*update.KeyStrategy); err != nil {
+			return err
+		}
+	}
+	// API keys are only returned masked, so an empty key means keep the current one
+	if update.APIKey != nil && *update.APIKey == "" {
+		update.APIKey = nil
+	}
+
 	return s.channelRepo.Update(id, update)
 }
 
@@ -69,3 +88,55 @@ func (s *ChannelService) GetActiveCount() (int, error) {
 	}
 	return len(channels), nil
 }
+
+// GetHealth returns a channel's recent health history with summary figures
+func (s *ChannelService) GetHealth(id int64, limit int) (*model.ChannelHealthSummary, error) {
+	channel, err := s.channelRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.healthRepo.ListByChannel(id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.ChannelHealthSummary{
+		ChannelID:    id,
+		IsActive:     channel.IsActive,
+		AutoDisabled: channel.AutoDisabled,
+		TotalChecks:  len(history),
+		History:      history,
+	}
+	if summary.History == nil {
+		summary.History = []*model.ChannelHealth{}
+	}
+	if len(history) == 0 {
+		return summary, nil
+	}
+
+	summary.LastStatus = history[0].Status
+	summary.LastCheckedAt = &history[0].CheckedAt
+
+	upCount := 0
+	totalLatency := 0
+	for _, record := range history {
+		if record.Status != model.HealthStatusUnhealthy {
+			upCount++
+		}
+		totalLatency += record.LatencyMs
+	}
+	summary.UptimePercent = float64(upCount) * 100 / float64(len(history))
+	summary.AvgLatencyMs = float64(totalLatency) / float64(len(history))
+
+	return summary, nil
+}
+
+// validateKeyStrategy checks that a key selection strategy is supported
+func validateKeyStrategy(strategy string) error {
+	switch strategy {
+	case model.KeyStrategyRoundRobin, model.KeyStrategyLeastUsed:
+		return nil
+	}
+	return fmt.Errorf("invalid key strategy: %s", strategy)
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// ClientKeyService handles client API key business logic
+type ClientKeyService struct {
+	keyRepo    *repository.ClientKeyRepository
+	logRepo    *repository.LogRepository
+	deniedRepo *repository.DeniedRequestRepository
+}
+
+// NewClientKeyService creates a new client key service
+func NewClientKeyService() *ClientKeyService {
+	return &ClientKeyService{
+		keyRepo:    repository.NewClientKeyRepository(),
+		logRepo:    repository.NewLogRepository(),
+		deniedRepo: repository.NewDeniedRequestRepository(),
+	}
+}
+
+// Create issues a new client key. The plaintext key is returned only here.
+func (s *ClientKeyService) Create(create *model.ClientKeyCreate) (*model.ClientKeySecret, error) {
+	if err := validateQuotaLimits(
+		&create.DailyTokenLimit, &create.WeeklyTokenLimit, &create.MonthlyTokenLimit,
+		&create.DailyCostLimit, &create.WeeklyCostLimit, &create.MonthlyCostLimit, &create.CreditLimit,
+	); err != nil {
+		return nil, err
+	}
+	if err := validateRateLimits(&create.RPMLimit, &create.TPMLimit, &create.ConcurrencyLimit); err != nil {
+		return nil, err
+	}
+	if err := validateAllowlists(create.AllowedModels, create.AllowedChannelGroups); err != nil {
+		return nil, err
+	}
+	if err := validateRestrictions(create.AllowedIPs, create.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	key, err := secret.Generate(model.ClientKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.keyRepo.Create(create, secret.Hash(key), secret.Mask(key))
+	if err != nil {
+		return nil, err
+	}
+
+	clientKey, err := s.keyRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ClientKeySecret{ClientKey: clientKey, Key: key}, nil
+}
+
+// GetByID retrieves a client key by ID
+func (s *ClientKeyService) GetByID(id int64) (*model.ClientKey, error) {
+	return s.keyRepo.GetByID(id)
+}
+
+// List retrieves all client keys
+func (s *ClientKeyService) List() ([]*model.ClientKey, error) {
+	return s.keyRepo.List()
+}
+
+// Update updates a client key
+func (s *ClientKeyService) Update(id int64, update *model.ClientKeyUpdate) error {
+	if err := validateQuotaLimits(
+		update.DailyTokenLimit, update.WeeklyTokenLimit, update.MonthlyTokenLimit,
+		update.DailyCostLimit, update.WeeklyCostLimit, update.MonthlyCostLimit, update.CreditLimit,
+	); err != nil {
+		return err
+	}
+	if err := validateRateLimits(update.RPMLimit, update.TPMLimit, update.ConcurrencyLimit); err != nil {
+		return err
+	}
+	if err := validateAllowlists(update.AllowedModels, update.AllowedChannelGroups); err != nil {
+		return err
+	}
+	if err := validateRestrictions(update.AllowedIPs, update.ExpiresAt); err != nil {
+		return err
+	}
+	return s.keyRepo.Update(id, update)
+}
+
+// Rotate replaces a client key with a new one, invalidating the old key immediately.
+// The new plaintext key is returned only here.
+func (s *ClientKeyService) Rotate(id int64) (*model.ClientKeySecret, error) {
+	key, err := secret.Generate(model.ClientKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.keyRepo.UpdateSecret(id, secret.Hash(key), secret.Mask(key)); err != nil {
+		return nil, err
+	}
+
+	clientKey, err := s.keyRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ClientKeySecret{ClientKey: clientKey, Key: key}, nil
+}
+
+// Revoke permanently disables a client key
+func (s *ClientKeyService) Revoke(id int64) error {
+	return s.keyRepo.Revoke(id)
+}
+
+// Delete deletes a client key
+func (s *ClientKeyService) Delete(id int64) error {
+	return s.keyRepo.Delete(id)
+}
+
+// Authenticate returns the active client key matching a presented key, or nil if there is none.
+// A key that is revoked, disabled or expired is returned with an AccessDeniedError saying why.
+func (s *ClientKeyService) Authenticate(key string) (*model.ClientKey, error) {
+	clientKey, err := s.keyRepo.FindByHash(secret.Hash(key))
+	if err != nil || clientKey == nil {
+		return nil, err
+	}
+	if reason := clientKey.InactiveReason(); reason != "" {
+		return clientKey, &model.AccessDeniedError{Reason: reason, Unauthenticated: true}
+	}
+
+	s.keyRepo.RecordUsage(clientKey.ID)
+	return clientKey, nil
+}
+
+// CheckIP rejects a request from a client IP outside a client key's allowlist
+func (s *ClientKeyService) CheckIP(clientKey *model.ClientKey, ip string) error {
+	if clientKey == nil || clientKey.AllowsIP(ip) {
+		return nil
+	}
+	return &model.AccessDeniedError{Reason: fmt.Sprintf("client key may not be used from IP %s", ip)}
+}
+
+// RecordDenial records a denied request for auditing
+func (s *ClientKeyService) RecordDenial(denied *model.DeniedRequest) error {
+	_, err := s.deniedRepo.Create(denied)
+	return err
+}
+
+// ListDenials retrieves denied requests with pagination
+func (s *ClientKeyService) ListDenials(filter *model.DeniedRequestFilter, page, pageSize int) ([]*model.DeniedRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return s.deniedRepo.List(filter, page, pageSize)
+}
+
+// HasActive reports whether any client key can authenticate requests
+func (s *ClientKeyService) HasActive() (bool, error) {
+	count, err := s.keyRepo.CountActive()
+	return count > 0, err
+}
+
+// GetUsage returns the consumption of a client key in each quota period
+func (s *ClientKeyService) GetUsage(clientKey *model.ClientKey) (*model.ClientKeyUsage, error) {
+	now := time.Now()
+	periods := []model.QuotaUsage{
+		{Period: model.QuotaPeriodDaily, TokenLimit: clientKey.DailyTokenLimit, CostLimit: clientKey.DailyCostLimit},
+		{Period: model.QuotaPeriodWeekly, TokenLimit: clientKey.WeeklyTokenLimit, CostLimit: clientKey.WeeklyCostLimit},
+		{Period: model.QuotaPeriodMonthly, TokenLimit: clientKey.MonthlyTokenLimit, CostLimit: clientKey.MonthlyCostLimit},
+		{Period: model.QuotaPeriodLifetime, CostLimit: clientKey.CreditLimit},
+	}
+
+	for i := range periods {
+		since := model.QuotaPeriodStart(periods[i].Period, now)
+		if !since.IsZero() {
+			resetAt := model.QuotaPeriodEnd(periods[i].Period, now)
+			periods[i].Since = &since
+			periods[i].ResetAt = &resetAt
+		}
+
+		tokens, cost, err := s.logRepo.SumClientKeyUsage(clientKey.ID, since)
+		if err != nil {
+			return nil, err
+		}
+		periods[i].Tokens = tokens
+		periods[i].Cost = cost
+	}
+
+	return &model.ClientKeyUsage{ClientKeyID: clientKey.ID, Periods: periods}, nil
+}
+
+// CheckQuota rejects a request from a client key that has used up a quota with a
+// QuotaExceededError, and otherwise returns what is left of its quotas. Requests
+// authenticated without a client key, or keys without quotas, get a nil allowance.
+func (s *ClientKeyService) CheckQuota(clientKey *model.ClientKey) (*model.QuotaAllowance, error) {
+	if clientKey == nil || !clientKey.HasQuota() {
+		return nil, nil
+	}
+
+	usage, err := s.GetUsage(clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	allowance := usage.Allowance()
+	if err := allowance.Check(0, 0); err != nil {
+		return nil, err
+	}
+	return allowance, nil
+}
+
+// validateQuotaLimits checks that the given quota limits are not negative
+func validateQuotaLimits(dailyTokens, weeklyTokens, monthlyTokens *int64, dailyCost, weeklyCost, monthlyCost, credit *float64) error {
+	for _, limit := range []*int64{dailyTokens, weeklyTokens, monthlyTokens} {
+		if limit != nil && *limit < 0 {
+			return fmt.Errorf("token limits must not be negative")
+		}
+	}
+	for _, limit := range []*float64{dailyCost, weeklyCost, monthlyCost, credit} {
+		if limit != nil && *limit < 0 {
+			return fmt.Errorf("cost limits must not be negative")
+		}
+	}
+	return nil
+}
+
+// validateRateLimits checks that the given rate limits are not negative
+func validateRateLimits(rpm, tpm, concurrency *int64) error {
+	for _, limit := range []*int64{rpm, tpm, concurrency} {
+		if limit != nil && *limit < 0 {
+			return fmt.Errorf("rate limits must not be negative")
+		}
+	}
+	return nil
+}
+
+// validateAllowlists checks that allowlist entries are not blank
+func validateAllowlists(models, channelGroups []string) error {
+	for _, pattern := range models {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("allowed models must not be blank")
+		}
+	}
+	for _, group := range channelGroups {
+		if strings.TrimSpace(group) == "" {
+			return fmt.Errorf("allowed channel groups must not be blank")
+		}
+	}
+	return nil
+}
+
+// validateRestrictions checks that allowed IPs parse as IPs or CIDRs and that an expiry is in the future
+func validateRestrictions(allowedIPs []string, expiresAt *time.Time) error {
+	for _, ip := range allowedIPs {
+		if _, err := model.ParseIPPrefix(ip); err != nil {
+			return err
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// FallbackService handles model fallback chain business logic
+type FallbackService struct {
+	fallbackRepo *repository.FallbackRepository
+}
+
+// NewFallbackService creates a new fallback service
+func NewFallbackService() *FallbackService {
+	return &FallbackService{
+		fallbackRepo: repository.NewFallbackRepository(),
+	}
+}
+
+// Create creates a new fallback chain
+func (s *FallbackService) Create(create *model.ModelFallbackCreate) (int64, error) {
+	fallbackModels, err := normalizeFallbackModels(create.DisplayModel, create.FallbackModels)
+	if err != nil {
+		return 0, err
+	}
+	create.FallbackModels = fallbackModels
+
+	return s.fallbackRepo.Create(create)
+}
+
+// GetByID retrieves a fallback chain by ID
+func (s *FallbackService) GetByID(id int64) (*model.ModelFallback, error) {
+	return s.fallbackRepo.GetByID(id)
+}
+
+// List retrieves all fallback chains
+func (s *FallbackService) List() ([]*model.ModelFallback, error) {
+	return s.fallbackRepo.List()
+}
+
+// Update updates a fallback chain
+func (s *FallbackService) Update(id int64, update *model.ModelFallbackUpdate) error {
+	if update.DisplayModel != nil || update.FallbackModels != nil {
+		existing, err := s.fallbackRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		displayModel, fallbackModels := existing.DisplayModel, existing.FallbackModels
+		if update.DisplayModel != nil {
+			displayModel = *update.DisplayModel
+		}
+		if update.FallbackModels != nil {
+			fallbackModels = update.FallbackModels
+		}
+
+		normalized, err := normalizeFallbackModels(displayModel, fallbackModels)
+		if err != nil {
+			return err
+		}
+		update.FallbackModels = normalized
+	}
+
+	return s.fallbackRepo.Update(id, update)
+}
+
+// Delete deletes a fallback chain
+func (s *FallbackService) Delete(id int64) error {
+	return s.fallbackRepo.Delete(id)
+}
+
+// normalizeFallbackModels trims the chain and rejects empty, duplicate or self-referencing entries
+func normalizeFallbackModels(displayModel string, fallbackModels []string) ([]string, error) {
+	if len(fallbackModels) == 0 {
+		return nil, fmt.Errorf("fallback_models must not be empty")
+	}
+
+	seen := make(map[string]bool, len(fallbackModels))
+	normalized := make([]string, 0, len(fallbackModels))
+	for _, fallbackModel := range fallbackModels {
+		fallbackModel = strings.TrimSpace(fallbackModel)
+		if fallbackModel == "" {
+			return nil, fmt.Errorf("fallback_models must not contain empty names")
+		}
+		if fallbackModel == displayModel {
+			return nil, fmt.Errorf("fallback_models must not include %s itself", displayModel)
+		}
+		if seen[fallbackModel] {
+			return nil, fmt.Errorf("fallback_models must not repeat %s", fallbackModel)
+		}
+		seen[fallbackModel] = true
+		normalized = append(normalized, fallbackModel)
+	}
+	return normalized, nil
+}
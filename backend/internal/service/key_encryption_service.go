@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+	"github.com/claude-api-gateway/backend/pkg/secret"
+)
+
+// KeyEncryptionService checks and migrates the encryption of stored upstream API keys
+type KeyEncryptionService struct {
+	storedKeyRepo *repository.StoredKeyRepository
+}
+
+// NewKeyEncryptionService creates a new key encryption service
+func NewKeyEncryptionService() *KeyEncryptionService {
+	return &KeyEncryptionService{
+		storedKeyRepo: repository.NewStoredKeyRepository(),
+	}
+}
+
+// Status counts the stored upstream API keys by encryption state
+func (s *KeyEncryptionService) Status() (*model.KeyEncryptionStatus, error) {
+	keys, err := s.storedKeyRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.KeyEncryptionStatus{}
+	for _, key := range keys {
+		switch {
+		case !secret.IsSealed(key.Value):
+			status.Plaintext++
+		case canOpen(key):
+			status.Encrypted++
+		default:
+			status.Unreadable++
+		}
+	}
+	return status, nil
+}
+
+// EncryptAll encrypts every upstream API key stored in plaintext and returns how many were encrypted
+func (s *KeyEncryptionService) EncryptAll() (int, error) {
+	if !secret.Encrypting() {
+		return 0, fmt.Errorf("no master key is configured")
+	}
+
+	keys, err := s.storedKeyRepo.List()
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted := 0
+	for _, key := range keys {
+		if secret.IsSealed(key.Value) {
+			continue
+		}
+		sealed, err := secret.Seal(key.Value, secret.Field(key.Table, model.StoredKeyColumn, key.ID))
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt %s %d: %w", key.Table, key.ID, err)
+		}
+		// A key edited meanwhile was already saved encrypted by the repository
+		replaced, err := s.storedKeyRepo.Replace(key, sealed)
+		if err != nil {
+			return encrypted, err
+		}
+		if replaced {
+			encrypted++
+		}
+	}
+	return encrypted, nil
+}
+
+func canOpen(key *model.StoredAPIKey) bool {
+	_, err := secret.Open(key.Value, secret.Field(key.Table, model.StoredKeyColumn, key.ID))
+	return err == nil
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/claude-api-gateway/backend/internal/model"
 	"github.com/claude-api-gateway/backend/internal/repository"
 )
@@ -27,6 +30,19 @@ func (s *MappingService) Create(create *model.MappingCreate) (int64, error) {
 		return 0, err
 	}
 
+	if create.MatchType == "" {
+		create.MatchType = model.MatchTypeExact
+	}
+	if err := validateMappingPattern(create.MatchType, create.DisplayModel); err != nil {
+		return 0, err
+	}
+	if err := validateRewriteRules(create.RewriteRules); err != nil {
+		return 0, err
+	}
+	if create.HedgeDelayMs < 0 {
+		return 0, fmt.Errorf("hedge_delay_ms must not be negative")
+	}
+
 	return s.mappingRepo.Create(create)
 }
 
@@ -47,6 +63,29 @@ func (s *MappingService) ListByChannel(channelID int64) ([]*model.ModelMapping,
 
 // Update updates a mapping
 func (s *MappingService) Update(id int64, update *model.MappingUpdate) error {
+	if update.MatchType != nil || update.DisplayModel != nil {
+		existing, err := s.mappingRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		matchType, displayModel := existing.MatchType, existing.DisplayModel
+		if update.MatchType != nil {
+			matchType = *update.MatchType
+		}
+		if update.DisplayModel != nil {
+			displayModel = *update.DisplayModel
+		}
+		if err := validateMappingPattern(matchType, displayModel); err != nil {
+			return err
+		}
+	}
+	if err := validateRewriteRules(update.RewriteRules); err != nil {
+		return err
+	}
+	if update.HedgeDelayMs != nil && *update.HedgeDelayMs < 0 {
+		return fmt.Errorf("hedge_delay_ms must not be negative")
+	}
+
 	return s.mappingRepo.Update(id, update)
 }
 
@@ -54,3 +93,97 @@ func (s *MappingService) Update(id int64, update *model.MappingUpdate) error {
 func (s *MappingService) Delete(id int64) error {
 	return s.mappingRepo.Delete(id)
 }
+
+// Import bulk-creates mappings for a channel from a selection of upstream models.
+// Display names get the optional prefix and suffix; existing identical mappings are skipped.
+func (s *MappingService) Import(channelID int64, req *model.MappingImportRequest) (*model.MappingImportResult, error) {
+	// Verify channel exists
+	if _, err := s.channelRepo.GetByID(channelID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.mappingRepo.ListByChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, m := range existing {
+		seen[m.UpstreamModel+"\x00"+m.DisplayModel] = true
+	}
+
+	result := &model.MappingImportResult{
+		Created: []*model.ModelMapping{},
+		Skipped: []string{},
+	}
+	for _, upstreamModel := range req.Models {
+		upstreamModel = strings.TrimSpace(upstreamModel)
+		if upstreamModel == "" {
+			continue
+		}
+		displayModel := req.DisplayPrefix + upstreamModel + req.DisplaySuffix
+
+		if seen[upstreamModel+"\x00"+displayModel] {
+			result.Skipped = append(result.Skipped, upstreamModel)
+			continue
+		}
+		seen[upstreamModel+"\x00"+displayModel] = true
+
+		id, err := s.mappingRepo.Create(&model.MappingCreate{
+			ChannelID:     channelID,
+			UpstreamModel: upstreamModel,
+			DisplayModel:  displayModel,
+			MatchType:     model.MatchTypeExact,
+		})
+		if err != nil {
+			return result, err
+		}
+		mapping, err := s.mappingRepo.GetByID(id)
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, mapping)
+	}
+
+	return result, nil
+}
+
+// validateMappingPattern checks the match type and that pattern display models compile
+func validateMappingPattern(matchType, displayModel string) error {
+	switch matchType {
+	case model.MatchTypeExact:
+		return nil
+	case model.MatchTypeGlob, model.MatchTypeRegex:
+		_, err := repository.CompileMappingPattern(matchType, displayModel)
+		return err
+	default:
+		return fmt.Errorf("invalid match_type: %s", matchType)
+	}
+}
+
+// protectedRequestFields are required to route a request and cannot be dropped by rewrite rules
+var protectedRequestFields = map[string]bool{"model": true, "messages": true, "stream": true}
+
+// validateRewriteRules checks that rewrite rule values are within the ranges upstreams accept
+func validateRewriteRules(rules *model.RewriteRules) error {
+	if rules == nil {
+		return nil
+	}
+	if rules.MaxTokensCeiling < 0 {
+		return fmt.Errorf("max_tokens_ceiling must not be negative")
+	}
+	if rules.Temperature != nil && (*rules.Temperature < 0 || *rules.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if rules.TopP != nil && (*rules.TopP < 0 || *rules.TopP > 1) {
+		return fmt.Errorf("top_p must be between 0 and 1")
+	}
+	if rules.TopK != nil && *rules.TopK < 0 {
+		return fmt.Errorf("top_k must not be negative")
+	}
+	for _, field := range rules.DropFields {
+		if protectedRequestFields[field] {
+			return fmt.Errorf("drop_fields must not include %s", field)
+		}
+	}
+	return nil
+}
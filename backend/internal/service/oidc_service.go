@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claude-api-gateway/backend/internal/config"
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/pkg/oidc"
+)
+
+// oidcLoginTimeout is how long a user has to sign in at the identity provider
+const oidcLoginTimeout = 10 * time.Minute
+
+// oidcLogin is a sign-in waiting for the identity provider to redirect back
+type oidcLogin struct {
+	nonce    string
+	verifier string
+	redirect string
+	expires  time.Time
+}
+
+// OIDCService signs admin users in with an OpenID Connect identity provider, using the
+// authorization code flow with PKCE. Pending sign-ins are kept in memory.
+type OIDCService struct {
+	cfg         *config.Config
+	provider    *oidc.Provider
+	userService *AdminUserService
+	roles       map[string]string
+
+	mu      sync.Mutex
+	pending map[string]*oidcLogin
+}
+
+// NewOIDCService creates a new OpenID Connect service; it is disabled without an issuer
+func NewOIDCService(cfg *config.Config) *OIDCService {
+	s := &OIDCService{
+		cfg:         cfg,
+		userService: NewAdminUserService(),
+		roles:       cfg.OIDCRoles(),
+		pending:     make(map[string]*oidcLogin),
+	}
+	if cfg.OIDCEnabled() {
+		s.provider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+		})
+	}
+	return s
+}
+
+// ValidateOIDCConfig checks the OpenID Connect settings, if any
+func ValidateOIDCConfig(cfg *config.Config) error {
+	if !cfg.OIDCEnabled() {
+		return nil
+	}
+	if cfg.OIDCRedirectURL == "" {
+		return fmt.Errorf("OIDC_REDIRECT_URL is required with OIDC_ISSUER")
+	}
+	for value, role := range cfg.OIDCRoles() {
+		if !model.ValidRole(role) {
+			return fmt.Errorf("OIDC_ROLE_MAPPING maps %s to unknown role %q", value, role)
+		}
+	}
+	if cfg.OIDCDefaultRole != "" && !model.ValidRole(cfg.OIDCDefaultRole) {
+		return fmt.Errorf("OIDC_DEFAULT_ROLE is not a role: %q", cfg.OIDCDefaultRole)
+	}
+	return nil
+}
+
+// Enabled reports whether OpenID Connect sign-in is configured
+func (s *OIDCService) Enabled() bool {
+	return s.provider != nil
+}
+
+// Begin starts a sign-in and returns the identity provider URL to send the user to, and the
+// state to bind to their browser. The user returns to redirect once signed in.
+func (s *OIDCService) Begin(ctx context.Context, redirect string) (string, string, error) {
+	if !s.Enabled() {
+		return "", "", fmt.Errorf("OpenID Connect sign-in is not configured")
+	}
+
+	var values [3]string
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			return "", "", err
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for key, login := range s.pending {
+		if now.After(login.expires) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = &oidcLogin{nonce: nonce, verifier: verifier, redirect: redirect, expires: now.Add(oidcLoginTimeout)}
+	s.mu.Unlock()
+
+	return authURL, state, nil
+}
+
+// Complete finishes a sign-in the identity provider redirected back with, returning the signed-in
+// admin user and where to send them
+func (s *OIDCService) Complete(ctx context.Context, state, code string) (*model.AdminUser, string, error) {
+	s.mu.Lock()
+	login := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+	if login == nil || time.Now().After(login.expires) {
+		return nil, "", fmt.Errorf("sign-in expired or was not started here, please try again")
+	}
+
+	idToken, err := s.provider.Exchange(ctx, code, login.verifier)
+	if err != nil {
+		return nil, "", err
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, idToken, login.nonce)
+	if err != nil {
+		return nil, "", err
+	}
+
+	role := s.role(claims)
+	if role == "" {
+		return nil, "", fmt.Errorf("your account has no role in the gateway")
+	}
+
+	username := claims.String(s.cfg.OIDCUsernameClaim)
+	if username == "" {
+		username = claims.String("preferred_username")
+	}
+	if username == "" {
+		username = claims.String("sub")
+	}
+
+	user, err := s.userService.SignInOIDC(s.cfg.OIDCIssuer+"|"+claims.String("sub"), username, role)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, login.redirect, nil
+}
+
+// role maps the values of the role claim to the most privileged role they grant, falling back
+// to the default role
+func (s *OIDCService) role(claims oidc.Claims) string {
+	role := ""
+	for _, value := range claims.Strings(s.cfg.OIDCRoleClaim) {
+		if mapped, ok := s.roles[value]; ok && (role == "" || model.RoleAllows(mapped, role)) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = s.cfg.OIDCDefaultRole
+	}
+	return role
+}
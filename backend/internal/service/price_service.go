@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// PriceService handles model price business logic
+type PriceService struct {
+	priceRepo *repository.PriceRepository
+}
+
+// NewPriceService creates a new price service
+func NewPriceService() *PriceService {
+	return &PriceService{
+		priceRepo: repository.NewPriceRepository(),
+	}
+}
+
+// Create creates a new model price
+func (s *PriceService) Create(create *model.ModelPriceCreate) (int64, error) {
+	if err := validatePrices(&create.InputPrice, &create.OutputPrice); err != nil {
+		return 0, err
+	}
+	return s.priceRepo.Create(create)
+}
+
+// GetByID retrieves a model price by ID
+func (s *PriceService) GetByID(id int64) (*model.ModelPrice, error) {
+	return s.priceRepo.GetByID(id)
+}
+
+// List retrieves all model prices
+func (s *PriceService) List() ([]*model.ModelPrice, error) {
+	return s.priceRepo.List()
+}
+
+// Update updates a model price
+func (s *PriceService) Update(id int64, update *model.ModelPriceUpdate) error {
+	if update.ModelPattern != nil && *update.ModelPattern == "" {
+		return fmt.Errorf("model_pattern must not be empty")
+	}
+	if err := validatePrices(update.InputPrice, update.OutputPrice); err != nil {
+		return err
+	}
+	return s.priceRepo.Update(id, update)
+}
+
+// Delete deletes a model price
+func (s *PriceService) Delete(id int64) error {
+	return s.priceRepo.Delete(id)
+}
+
+// validatePrices checks that the given prices are not negative
+func validatePrices(inputPrice, outputPrice *float64) error {
+	if inputPrice != nil && *inputPrice < 0 {
+		return fmt.Errorf("input_price must not be negative")
+	}
+	if outputPrice != nil && *outputPrice < 0 {
+		return fmt.Errorf("output_price must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/claude-api-gateway/backend/internal/model"
+	"github.com/claude-api-gateway/backend/internal/repository"
+)
+
+// RoutingService handles routing rule business logic
+type RoutingService struct {
+	routingRepo *repository.RoutingRepository
+}
+
+// NewRoutingService creates a new routing service
+func NewRoutingService() *RoutingService {
+	return &RoutingService{
+		routingRepo: repository.NewRoutingRepository(),
+	}
+}
+
+// Create creates a new routing rule
+func (s *RoutingService) Create(create *model.RoutingRuleCreate) (int64, error) {
+	if err := validateRoutingRule(create.ModelPattern, create.MinInputTokens); err != nil {
+		return 0, err
+	}
+	return s.routingRepo.Create(create)
+}
+
+// GetByID retrieves a routing rule by ID
+func (s *RoutingService) GetByID(id int64) (*model.RoutingRule, error) {
+	return s.routingRepo.GetByID(id)
+}
+
+// List retrieves all routing rules in evaluation order
+func (s *RoutingService) List() ([]*model.RoutingRule, error) {
+	return s.routingRepo.List()
+}
+
+// Update updates a routing rule
+func (s *RoutingService) Update(id int64, update *model.RoutingRuleUpdate) error {
+	modelPattern, minInputTokens := "", 0
+	if update.ModelPattern != nil {
+		modelPattern = *update.ModelPattern
+	}
+	if update.MinInputTokens != nil {
+		minInputTokens = *update.MinInputTokens
+	}
+	if err := validateRoutingRule(modelPattern, minInputTokens); err != nil {
+		return err
+	}
+	return s.routingRepo.Update(id, update)
+}
+
+// Delete deletes a routing rule
+func (s *RoutingService) Delete(id int64) error {
+	return s.routingRepo.Delete(id)
+}
+
+// validateRoutingRule checks a rule's model glob and token threshold
+func validateRoutingRule(modelPattern string, minInputTokens int) error {
+	if minInputTokens < 0 {
+		return fmt.Errorf("min_input_tokens must not be negative")
+	}
+	if modelPattern != "" {
+		if _, err := repository.CompileMappingPattern(model.MatchTypeGlob, modelPattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
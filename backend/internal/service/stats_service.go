@@ -100,6 +100,11 @@ func (s *StatsService) GetRequestLogs(filter *model.StatsFilter, page, pageSize
 	return s.logRepo.List(filter, page, pageSize)
 }
 
+// GetRequestAttempts retrieves the failover chain of a client request
+func (s *StatsService) GetRequestAttempts(requestID string) ([]*model.RequestLogWithChannel, error) {
+	return s.logRepo.ListAttempts(requestID)
+}
+
 // ExportToCSV exports logs to CSV format
 func (s *StatsService) ExportToCSV(filter *model.StatsFilter) ([]byte, error) {
 	logs, _, err := s.logRepo.List(filter, 1, 10000)
@@ -112,10 +117,10 @@ func (s *StatsService) ExportToCSV(filter *model.StatsFilter) ([]byte, error) {
 
 	// Write header
 	header := []string{
-		"ID", "Channel", "Request ID", "Model", "Upstream Model",
+		"ID", "Channel", "Request ID", "Attempt", "Model", "Upstream Model",
 		"Input Tokens", "Output Tokens", "Total Tokens",
 		"Request Time", "Response Time", "Latency (ms)",
-		"Status", "Error Code", "Error Message", "IP Address",
+		"Status", "Error Code", "Error Message", "IP Address", "Client Key",
 	}
 	writer.Write(header)
 
@@ -130,6 +135,7 @@ func (s *StatsService) ExportToCSV(filter *model.StatsFilter) ([]byte, error) {
 			fmt.Sprintf("%d", log.ID),
 			log.ChannelName,
 			log.RequestID,
+			fmt.Sprintf("%d", log.Attempt),
 			log.ModelName,
 			log.UpstreamModel,
 			fmt.Sprintf("%d", log.InputTokens),
@@ -142,6 +148,7 @@ func (s *StatsService) ExportToCSV(filter *model.StatsFilter) ([]byte, error) {
 			log.ErrorCode,
 			log.ErrorMessage,
 			log.IPAddress,
+			log.ClientKeyName,
 		}
 		writer.Write(row)
 	}
@@ -0,0 +1,26 @@
+-- 渠道密钥池表
+CREATE TABLE IF NOT EXISTS channel_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id INTEGER NOT NULL,
+    name VARCHAR(100),
+    api_key VARCHAR(500) NOT NULL,
+    is_enabled BOOLEAN DEFAULT 1,
+    usage_count INTEGER DEFAULT 0,
+    failure_count INTEGER DEFAULT 0,
+    last_used_at DATETIME,
+    last_error TEXT,
+    disabled_reason VARCHAR(200),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE
+);
+
+-- 渠道密钥选择策略: round_robin / least_used
+ALTER TABLE channels ADD COLUMN key_strategy VARCHAR(20) NOT NULL DEFAULT 'round_robin';
+
+-- 请求日志记录所使用的密钥
+ALTER TABLE request_logs ADD COLUMN channel_key_id INTEGER DEFAULT 0;
+ALTER TABLE request_logs ADD COLUMN api_key_masked VARCHAR(50) DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_channel_keys_channel ON channel_keys(channel_id, is_enabled);
+CREATE INDEX IF NOT EXISTS idx_logs_channel_key ON request_logs(channel_key_id);
@@ -0,0 +1,16 @@
+-- 渠道健康检查记录表
+CREATE TABLE IF NOT EXISTS channel_health (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    latency_ms INTEGER DEFAULT 0,
+    status_code INTEGER DEFAULT 0,
+    error_message TEXT,
+    checked_at DATETIME NOT NULL,
+    FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE
+);
+
+-- 标记由健康检查自动停用的渠道，仅这些渠道会被自动恢复
+ALTER TABLE channels ADD COLUMN auto_disabled BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_channel_health_channel ON channel_health(channel_id, checked_at);
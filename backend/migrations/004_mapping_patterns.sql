@@ -0,0 +1,4 @@
+-- 映射匹配方式: exact / glob / regex
+ALTER TABLE model_mappings ADD COLUMN match_type VARCHAR(20) NOT NULL DEFAULT 'exact';
+
+CREATE INDEX IF NOT EXISTS idx_mappings_match_type ON model_mappings(match_type, is_enabled);
@@ -0,0 +1,12 @@
+-- 模型降级链: 显示模型的所有渠道都失败时依次尝试的备用显示模型
+CREATE TABLE IF NOT EXISTS model_fallbacks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    display_model VARCHAR(100) NOT NULL UNIQUE,
+    fallback_models TEXT NOT NULL,
+    is_enabled BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 降级请求记录原始请求的模型
+ALTER TABLE request_logs ADD COLUMN fallback_from VARCHAR(100) DEFAULT '';
@@ -0,0 +1,2 @@
+-- 映射级请求改写规则 (JSON)
+ALTER TABLE model_mappings ADD COLUMN rewrite_rules TEXT;
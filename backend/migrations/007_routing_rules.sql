@@ -0,0 +1,20 @@
+-- 渠道分组
+ALTER TABLE channels ADD COLUMN channel_group VARCHAR(50) DEFAULT '';
+
+-- 内容感知路由规则: 按请求内容选择渠道分组
+CREATE TABLE IF NOT EXISTS routing_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    priority INTEGER DEFAULT 0,
+    model_pattern VARCHAR(100) DEFAULT '',
+    min_input_tokens INTEGER DEFAULT 0,
+    has_images BOOLEAN,
+    has_tools BOOLEAN,
+    has_thinking BOOLEAN,
+    target_group VARCHAR(50) NOT NULL,
+    is_enabled BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_routing_rules_priority ON routing_rules(is_enabled, priority);
@@ -0,0 +1,5 @@
+-- 对冲请求: 主渠道在延迟内未响应时同时请求下一个渠道 (毫秒, 0 表示关闭)
+ALTER TABLE model_mappings ADD COLUMN hedge_delay_ms INTEGER DEFAULT 0;
+
+-- 对冲请求的两次尝试都会标记角色 (primary / hedge)
+ALTER TABLE request_logs ADD COLUMN hedge_role VARCHAR(20) DEFAULT '';
@@ -0,0 +1,12 @@
+-- 响应缓存: 键为上游路径与规范化请求体的哈希
+CREATE TABLE IF NOT EXISTS response_cache (
+    cache_key VARCHAR(64) PRIMARY KEY,
+    body TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_response_cache_expires ON response_cache(expires_at);
+
+-- 缓存命中的请求不消耗上游 Token
+ALTER TABLE request_logs ADD COLUMN cache_hit BOOLEAN DEFAULT 0;
@@ -0,0 +1,2 @@
+-- 响应中的模型名与消息 ID 会替换为网关的值，上游返回的消息 ID 记录在日志中
+ALTER TABLE request_logs ADD COLUMN upstream_message_id VARCHAR(100) DEFAULT '';
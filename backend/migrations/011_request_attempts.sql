@@ -0,0 +1,46 @@
+-- 请求日志按尝试记录: 去掉 request_id 的唯一约束，同一请求的每次渠道尝试各记一条，attempt 为尝试序号
+CREATE TABLE request_logs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id INTEGER NOT NULL,
+    request_id VARCHAR(100),
+    attempt INTEGER NOT NULL DEFAULT 1,
+    model_name VARCHAR(100) NOT NULL,
+    upstream_model VARCHAR(200),
+    input_tokens INTEGER DEFAULT 0,
+    output_tokens INTEGER DEFAULT 0,
+    total_tokens INTEGER DEFAULT 0,
+    request_time DATETIME NOT NULL,
+    response_time DATETIME,
+    latency_ms INTEGER,
+    status VARCHAR(20) NOT NULL,
+    error_code VARCHAR(50),
+    error_message TEXT,
+    ip_address VARCHAR(50),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    channel_key_id INTEGER DEFAULT 0,
+    api_key_masked VARCHAR(50) DEFAULT '',
+    fallback_from VARCHAR(100) DEFAULT '',
+    hedge_role VARCHAR(20) DEFAULT '',
+    cache_hit BOOLEAN DEFAULT 0,
+    upstream_message_id VARCHAR(100) DEFAULT '',
+    FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE SET NULL
+);
+
+INSERT INTO request_logs_new (id, channel_id, request_id, attempt, model_name, upstream_model, input_tokens, output_tokens,
+                              total_tokens, request_time, response_time, latency_ms, status, error_code, error_message,
+                              ip_address, created_at, channel_key_id, api_key_masked, fallback_from, hedge_role, cache_hit,
+                              upstream_message_id)
+SELECT id, channel_id, request_id, 1, model_name, upstream_model, input_tokens, output_tokens,
+       total_tokens, request_time, response_time, latency_ms, status, error_code, error_message,
+       ip_address, created_at, channel_key_id, api_key_masked, fallback_from, hedge_role, cache_hit,
+       upstream_message_id
+FROM request_logs;
+
+DROP TABLE request_logs;
+ALTER TABLE request_logs_new RENAME TO request_logs;
+
+CREATE INDEX IF NOT EXISTS idx_logs_channel ON request_logs(channel_id);
+CREATE INDEX IF NOT EXISTS idx_logs_request_time ON request_logs(request_time);
+CREATE INDEX IF NOT EXISTS idx_logs_status ON request_logs(status);
+CREATE INDEX IF NOT EXISTS idx_logs_channel_key ON request_logs(channel_key_id);
+CREATE INDEX IF NOT EXISTS idx_logs_request_id ON request_logs(request_id, attempt);
@@ -0,0 +1,18 @@
+-- 客户端 API Key: 只保存 SHA-256 哈希，明文仅在创建和轮换时返回一次
+CREATE TABLE IF NOT EXISTS client_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    owner VARCHAR(100) DEFAULT '',
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    key_hint VARCHAR(50) NOT NULL,
+    is_enabled BOOLEAN DEFAULT 1,
+    revoked_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 请求日志记录发起请求的客户端 Key，0 表示共享的 API_KEY 或未鉴权
+ALTER TABLE request_logs ADD COLUMN client_key_id INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_logs_client_key ON request_logs(client_key_id);
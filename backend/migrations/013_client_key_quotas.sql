@@ -0,0 +1,23 @@
+-- 模型价格: 按上游模型名（支持 * 和 ? 通配符）估算请求成本，单位为美元/百万 Token
+CREATE TABLE IF NOT EXISTS model_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    model_pattern VARCHAR(100) NOT NULL UNIQUE,
+    input_price REAL NOT NULL DEFAULT 0,
+    output_price REAL NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 请求日志记录按模型价格估算的成本（美元）
+ALTER TABLE request_logs ADD COLUMN cost REAL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_logs_client_key_time ON request_logs(client_key_id, request_time);
+
+-- 客户端 Key 配额: 按自然日/周/月限制 Token 数或成本，credit_limit 为终身额度（美元），0 表示不限
+ALTER TABLE client_keys ADD COLUMN daily_token_limit INTEGER DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN weekly_token_limit INTEGER DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN monthly_token_limit INTEGER DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN daily_cost_limit REAL DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN weekly_cost_limit REAL DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN monthly_cost_limit REAL DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN credit_limit REAL DEFAULT 0;
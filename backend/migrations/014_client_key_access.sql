@@ -0,0 +1,3 @@
+-- 客户端 Key 访问限制: 允许调用的展示模型（支持 * 和 ? 通配符）和允许使用的渠道分组，JSON 数组，为空表示不限
+ALTER TABLE client_keys ADD COLUMN allowed_models TEXT DEFAULT '[]';
+ALTER TABLE client_keys ADD COLUMN allowed_channel_groups TEXT DEFAULT '[]';
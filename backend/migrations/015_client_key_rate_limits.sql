@@ -0,0 +1,4 @@
+-- 客户端 Key 速率限制: 每分钟请求数、每分钟 Token 数和并发请求数，0 表示不限
+ALTER TABLE client_keys ADD COLUMN rpm_limit INTEGER DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN tpm_limit INTEGER DEFAULT 0;
+ALTER TABLE client_keys ADD COLUMN concurrency_limit INTEGER DEFAULT 0;
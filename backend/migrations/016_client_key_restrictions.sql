@@ -0,0 +1,17 @@
+-- 客户端 Key 过期时间和来源 IP 限制（IP 或 CIDR，JSON 数组，为空表示不限）
+ALTER TABLE client_keys ADD COLUMN expires_at DATETIME;
+ALTER TABLE client_keys ADD COLUMN allowed_ips TEXT DEFAULT '[]';
+
+-- 被拒绝的代理请求: 记录无效、过期或吊销的 Key 以及不允许的来源 IP、模型等，便于审计
+CREATE TABLE IF NOT EXISTS denied_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_key_id INTEGER DEFAULT 0,
+    key_hint VARCHAR(50) DEFAULT '',
+    ip_address VARCHAR(50),
+    path VARCHAR(200),
+    reason TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_denied_requests_time ON denied_requests(created_at);
+CREATE INDEX IF NOT EXISTS idx_denied_requests_client_key ON denied_requests(client_key_id);
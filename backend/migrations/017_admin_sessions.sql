@@ -0,0 +1,14 @@
+-- 管理界面会话: 只保存会话令牌的哈希，按空闲时间和绝对有效期过期，退出登录或手动吊销后立即失效
+CREATE TABLE IF NOT EXISTS admin_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    csrf_token VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(50),
+    user_agent VARCHAR(500),
+    created_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_sessions_expires ON admin_sessions(expires_at);
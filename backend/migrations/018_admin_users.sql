@@ -0,0 +1,19 @@
+-- 管理员账号: 密码以 bcrypt 哈希保存，可选 TOTP 两步验证
+-- 角色: viewer 只能查看统计和日志，operator 可以启停渠道、管理映射等配置，admin 可以管理密钥和账号
+CREATE TABLE IF NOT EXISTS admin_users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(100) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'viewer',
+    is_enabled BOOLEAN DEFAULT 1,
+    totp_secret VARCHAR(64) DEFAULT '',
+    totp_enabled BOOLEAN DEFAULT 0,
+    totp_last_step INTEGER DEFAULT 0,
+    last_login_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 会话所属的管理员账号，0 表示通过 ADMIN_API_KEY 登录
+ALTER TABLE admin_sessions ADD COLUMN admin_user_id INTEGER DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_admin_sessions_user ON admin_sessions(admin_user_id);
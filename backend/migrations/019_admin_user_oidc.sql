@@ -0,0 +1,3 @@
+-- 通过 OpenID Connect 登录的管理员账号: 记录身份提供方的 issuer 和 sub，每次登录时按声明同步角色
+ALTER TABLE admin_users ADD COLUMN oidc_subject VARCHAR(500);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_users_oidc_subject ON admin_users(oidc_subject) WHERE oidc_subject IS NOT NULL;
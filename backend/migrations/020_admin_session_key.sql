@@ -0,0 +1,2 @@
+-- 通过 ADMIN_API_KEY 登录的会话记录登录时密钥的指纹，密钥轮换或移除后这些会话随即失效
+ALTER TABLE admin_sessions ADD COLUMN admin_key_fingerprint VARCHAR(64) DEFAULT '';
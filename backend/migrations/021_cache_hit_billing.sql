@@ -0,0 +1,3 @@
+-- 命中响应缓存的请求不消耗上游 Token（input_tokens 等记为 0），按缓存响应的用量向客户端 Key 计费的部分单独记录
+ALTER TABLE request_logs ADD COLUMN billed_tokens INTEGER DEFAULT 0;
+ALTER TABLE request_logs ADD COLUMN billed_cost REAL DEFAULT 0;
@@ -0,0 +1,201 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 for RS256 and ES256
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384, RS512, ES384 and ES512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jwtHeader is the JOSE header of a signed JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jwt is a parsed, not yet verified, compact JWS
+type jwt struct {
+	header    jwtHeader
+	payload   []byte
+	signed    string // header and payload as signed
+	signature []byte
+}
+
+func parseJWT(raw string) (*jwt, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("ID token is not a signed JWT")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ID token header: %w", err)
+	}
+	token := &jwt{signed: parts[0] + "." + parts[1]}
+	if err := json.Unmarshal(headerJSON, &token.header); err != nil {
+		return nil, fmt.Errorf("failed to parse ID token header: %w", err)
+	}
+	if token.payload, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, fmt.Errorf("failed to decode ID token claims: %w", err)
+	}
+	if token.signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, fmt.Errorf("failed to decode ID token signature: %w", err)
+	}
+	return token, nil
+}
+
+// signatureHashes are the hashes of the supported asymmetric signature algorithms. Symmetric
+// and unsigned tokens are rejected.
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// verify checks the token's signature with a provider key
+func (t *jwt) verify(key any) error {
+	hash, ok := signatureHashes[t.header.Algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported ID token algorithm %q", t.header.Algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(t.signed))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(t.header.Algorithm, "RS") {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, t.signature); err != nil {
+			return fmt.Errorf("invalid ID token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(t.header.Algorithm, "ES") {
+			break
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return fmt.Errorf("invalid ID token signature")
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("invalid ID token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("ID token algorithm %q does not match its key", t.header.Algorithm)
+}
+
+// jsonWebKeySet is a provider's published JWK set
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// keySet holds the signing keys of a provider
+type keySet struct {
+	keys []parsedKey
+}
+
+type parsedKey struct {
+	id        string
+	algorithm string
+	key       any
+}
+
+// parse converts the set's RSA and EC signing keys, skipping keys it cannot use
+func (s *jsonWebKeySet) parse() *keySet {
+	set := &keySet{}
+	for _, jwk := range s.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			set.keys = append(set.keys, parsedKey{id: jwk.KeyID, algorithm: jwk.Algorithm, key: key})
+		}
+	}
+	return set
+}
+
+// find returns the key with the given ID, or the only key when the token names none
+func (s *keySet) find(keyID, algorithm string) any {
+	var matches []parsedKey
+	for _, key := range s.keys {
+		if (keyID == "" || key.id == keyID) && (key.algorithm == "" || key.algorithm == algorithm) {
+			matches = append(matches, key)
+		}
+	}
+	if len(matches) == 1 || (len(matches) > 1 && keyID != "") {
+		return matches[0].key
+	}
+	return nil
+}
+
+func (k *jsonWebKey) publicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("EC key is not on its curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
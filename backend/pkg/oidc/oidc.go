@@ -0,0 +1,319 @@
+// Package oidc implements the parts of OpenID Connect a relying party needs to sign users in
+// with the authorization code flow and PKCE: provider discovery, the authorization URL, the
+// code exchange and ID token verification against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes a relying party registered with an OpenID provider
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Discovery is the part of a provider's /.well-known/openid-configuration document that is used
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// Claims are the claims of a verified ID token
+type Claims map[string]any
+
+// String returns a string claim, or "" if it is missing or not a string
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// Strings returns a claim holding a string or a list of strings
+func (c Claims) Strings(name string) []string {
+	switch value := c[name].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Provider is an OpenID provider. Its discovery document and signing keys are fetched on first
+// use and the keys are refetched when a token is signed with an unknown one.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      *keySet
+}
+
+// NewProvider creates a provider for a relying party configuration
+func NewProvider(config Config) *Provider {
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Discover fetches and caches the provider's discovery document
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	var discovery Discovery
+	if err := p.getJSON(ctx, discoveryURL, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID provider: %w", err)
+	}
+	if discovery.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OpenID provider reports issuer %q instead of %q", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OpenID provider discovery document is incomplete")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// AuthCodeURL returns the URL to send the user to for signing in. The verifier is the PKCE
+// code verifier later passed to Exchange; the nonce is checked in the ID token.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", Challenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	// client_secret_basic is the default; use client_secret_post only if that is all the provider takes
+	basicAuth := len(discovery.TokenAuthMethods) == 0
+	for _, method := range discovery.TokenAuthMethods {
+		if method == "client_secret_basic" {
+			basicAuth = true
+		}
+	}
+	if !basicAuth {
+		form.Set("client_id", p.config.ClientID)
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to parse token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("token request failed (status %d): %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// clockSkew is the leeway given to token lifetimes for clock differences with the provider
+const clockSkew = time.Minute
+
+// VerifyIDToken checks an ID token's signature, issuer, audience, lifetime and nonce and
+// returns its claims
+func (p *Provider) VerifyIDToken(ctx context.Context, rawToken, nonce string) (Claims, error) {
+	token, err := parseJWT(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.signingKey(ctx, token.header.KeyID, token.header.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.verify(key); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(token.payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse ID token claims: %w", err)
+	}
+
+	if claims.String("iss") != p.config.Issuer {
+		return nil, fmt.Errorf("ID token was issued by %q", claims.String("iss"))
+	}
+	audience := claims.Strings("aud")
+	if !contains(audience, p.config.ClientID) {
+		return nil, fmt.Errorf("ID token is not intended for this client")
+	}
+	if azp := claims.String("azp"); len(audience) > 1 && azp != "" && azp != p.config.ClientID {
+		return nil, fmt.Errorf("ID token was issued to another client")
+	}
+
+	now := time.Now()
+	expiry, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(expiry), 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("ID token has expired")
+	}
+	if issuedAt, ok := claims["iat"].(float64); ok && time.Unix(int64(issuedAt), 0).After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("ID token is issued in the future")
+	}
+	if claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("ID token nonce does not match")
+	}
+	if claims.String("sub") == "" {
+		return nil, fmt.Errorf("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key set once if the
+// key is unknown, as happens after the provider rotates its keys
+func (p *Provider) signingKey(ctx context.Context, keyID, algorithm string) (any, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+	if keys != nil {
+		if key := keys.find(keyID, algorithm); key != nil {
+			return key, nil
+		}
+	}
+
+	var jwks jsonWebKeySet
+	if err := p.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenID provider keys: %w", err)
+	}
+	keys = jwks.parse()
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key := keys.find(keyID, algorithm); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("ID token is signed with an unknown key")
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// RandomString returns a random URL-safe string, for states, nonces and PKCE verifiers
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Challenge returns the S256 PKCE code challenge of a code verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "gateway"
+	testKeyID    = "key-1"
+	testNonce    = "nonce-1"
+)
+
+// testProvider is an OpenID provider serving discovery and a JWK set with one RSA key
+type testProvider struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	jwksFetches atomic.Int32
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp := &testProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:                tp.server.URL,
+			AuthorizationEndpoint: tp.server.URL + "/authorize",
+			TokenEndpoint:         tp.server.URL + "/token",
+			JWKSURI:               tp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		tp.jwksFetches.Add(1)
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			KeyType:   "RSA",
+			KeyID:     testKeyID,
+			Use:       "sig",
+			Algorithm: "RS256",
+			N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	tp.server = httptest.NewServer(mux)
+	t.Cleanup(tp.server.Close)
+	return tp
+}
+
+func (tp *testProvider) provider() *Provider {
+	return NewProvider(Config{Issuer: tp.server.URL, ClientID: testClientID})
+}
+
+// claims returns valid ID token claims, changed by the given edits
+func (tp *testProvider) claims(edits ...func(Claims)) Claims {
+	now := time.Now()
+	claims := Claims{
+		"iss":   tp.server.URL,
+		"aud":   testClientID,
+		"sub":   "user-1",
+		"nonce": testNonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	for _, edit := range edits {
+		edit(claims)
+	}
+	return claims
+}
+
+// token encodes a compact JWT with the given header and claims, signed by sign
+func token(t *testing.T, header map[string]string, claims Claims, sign func(signed string) []byte) string {
+	t.Helper()
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed))
+}
+
+// rs256 signs a token with the provider's key
+func (tp *testProvider) rs256(t *testing.T, keyID string, claims Claims) string {
+	return token(t, map[string]string{"alg": "RS256", "kid": keyID}, claims, func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, tp.key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	tp := newTestProvider(t)
+	claims, err := tp.provider().VerifyIDToken(context.Background(), tp.rs256(t, testKeyID, tp.claims()), testNonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.String("sub") != "user-1" {
+		t.Errorf("sub = %q, want user-1", claims.String("sub"))
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	tp := newTestProvider(t)
+	expired := func(c Claims) {
+		c["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+		c["exp"] = time.Now().Add(-time.Hour).Unix()
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "alg none",
+			token: token(t, map[string]string{"alg": "none", "kid": testKeyID}, tp.claims(), func(string) []byte { return nil }),
+			want:  "unknown key",
+		},
+		{
+			// The classic confusion attack: an HMAC keyed with the provider's public key
+			name: "alg HS256",
+			token: token(t, map[string]string{"alg": "HS256", "kid": testKeyID}, tp.claims(), func(signed string) []byte {
+				mac := hmac.New(sha256.New, tp.key.N.Bytes())
+				mac.Write([]byte(signed))
+				return mac.Sum(nil)
+			}),
+			want: "unknown key",
+		},
+		{
+			name:  "wrong issuer",
+			token: tp.rs256(t, testKeyID, tp.claims(func(c Claims) { c["iss"] = "https://evil.example.com" })),
+			want:  "issued by",
+		},
+		{
+			name:  "wrong audience",
+			token: tp.rs256(t, testKeyID, tp.claims(func(c Claims) { c["aud"] = "another-client" })),
+			want:  "not intended for this client",
+		},
+		{
+			name:  "expired",
+			token: tp.rs256(t, testKeyID, tp.claims(expired)),
+			want:  "expired",
+		},
+		{
+			name:  "nonce mismatch",
+			token: tp.rs256(t, testKeyID, tp.claims(func(c Claims) { c["nonce"] = "another-nonce" })),
+			want:  "nonce",
+		},
+		{
+			name:  "unknown kid",
+			token: tp.rs256(t, "key-2", tp.claims()),
+			want:  "unknown key",
+		},
+		{
+			name:  "tampered claims",
+			token: tamper(tp.rs256(t, testKeyID, tp.claims())),
+			want:  "signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tp.provider().VerifyIDToken(context.Background(), tt.token, testNonce)
+			if err == nil {
+				t.Fatal("VerifyIDToken accepted the token")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("VerifyIDToken error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenUnknownKeyRefetchesOnce(t *testing.T) {
+	tp := newTestProvider(t)
+	provider := tp.provider()
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, tp.rs256(t, testKeyID, tp.claims()), testNonce); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, tp.rs256(t, "key-2", tp.claims()), testNonce); err == nil {
+		t.Fatal("VerifyIDToken accepted a token signed with an unknown key")
+	}
+	if fetches := tp.jwksFetches.Load(); fetches != 2 {
+		t.Errorf("key set fetched %d times, want 2", fetches)
+	}
+}
+
+// tamper swaps the subject of a signed token without re-signing it
+func tamper(raw string) string {
+	parts := strings.Split(raw, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims Claims
+	json.Unmarshal(payload, &claims)
+	claims["sub"] = "admin"
+	payload, _ = json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,234 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sealedPrefix marks values encrypted by an Envelope, anything else is stored in plaintext
+const sealedPrefix = "enc:v1:"
+
+// MasterKeySize is the length of the master key in bytes (AES-256)
+const MasterKeySize = 32
+
+// ErrNoMasterKey is returned when opening an encrypted value without a master key configured
+var ErrNoMasterKey = errors.New("value is encrypted but no master key is configured")
+
+// Envelope encrypts secrets at rest. Each value is encrypted with its own random data key,
+// and the data key is stored alongside it encrypted with the master key. Both layers use AES-GCM
+// and authenticate the field the value is stored in, so a value copied to another row or column
+// fails to decrypt.
+type Envelope struct {
+	master cipher.AEAD
+	keyID  string
+}
+
+// NewEnvelope creates an envelope around a 32-byte master key
+func NewEnvelope(masterKey []byte) (*Envelope, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(masterKey))
+	}
+	master, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key ID is stored with every value so that a wrong master key is reported as such
+	// rather than as corrupt data
+	sum := sha256.Sum256(masterKey)
+	return &Envelope{master: master, keyID: hex.EncodeToString(sum[:4])}, nil
+}
+
+// KeyID returns the fingerprint of the master key recorded in sealed values
+func (e *Envelope) KeyID() string {
+	return e.keyID
+}
+
+// Seal encrypts a value stored in field as enc:v1:<key id>:<encrypted data key>:<encrypted value>
+func (e *Envelope) Seal(plaintext, field string) (string, error) {
+	dataKey := make([]byte, MasterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	aad := additionalData(e.keyID, field)
+	wrappedKey, err := seal(e.master, dataKey, aad)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, []byte(plaintext), aad)
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + e.keyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value Seal stored in field. Plaintext values are returned unchanged,
+// so rows written before encryption was enabled keep working until they are migrated.
+func (e *Envelope) Open(value, field string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	keyID, wrappedKey, ciphertext, err := splitSealed(value)
+	if err != nil {
+		return "", err
+	}
+	if keyID != e.keyID {
+		return "", fmt.Errorf("value was encrypted with a different master key (%s, configured %s)", keyID, e.keyID)
+	}
+
+	aad := additionalData(keyID, field)
+	dataKey, err := open(e.master, wrappedKey, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, ciphertext, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Field names the column of a row a value is stored in, which Seal and Open bind it to
+func Field(table, column string, id int64) string {
+	return fmt.Sprintf("%s.%s:%d", table, column, id)
+}
+
+// IsSealed reports whether a stored value is encrypted
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// ParseMasterKey decodes a master key given as base64 or hex
+func ParseMasterKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == MasterKeySize {
+		return key, nil
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := encoding.DecodeString(encoded); err == nil {
+			if len(key) != MasterKeySize {
+				return nil, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(key))
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("master key must be %d bytes encoded as base64 or hex", MasterKeySize)
+}
+
+// LoadMasterKey reads the master key from an environment value or a file, returning nil when neither is set
+func LoadMasterKey(value, file string) ([]byte, error) {
+	switch {
+	case value != "" && file != "":
+		return nil, fmt.Errorf("set either the master key or the master key file, not both")
+	case file != "":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		return ParseMasterKey(string(content))
+	case value != "":
+		return ParseMasterKey(value)
+	}
+	return nil, nil
+}
+
+// Default envelope used by the repositories, nil when encryption at rest is disabled
+var envelope *Envelope
+
+// SetEnvelope installs the envelope used by Seal and Open. It is called once at startup.
+func SetEnvelope(e *Envelope) {
+	envelope = e
+}
+
+// Encrypting reports whether values are encrypted at rest
+func Encrypting() bool {
+	return envelope != nil
+}
+
+// Seal encrypts a value for storage in field, or returns it unchanged when no master key is configured
+func Seal(plaintext, field string) (string, error) {
+	if envelope == nil {
+		return plaintext, nil
+	}
+	return envelope.Seal(plaintext, field)
+}
+
+// Open decrypts a value stored in field, returning plaintext values unchanged
+func Open(value, field string) (string, error) {
+	if envelope == nil {
+		if IsSealed(value) {
+			return "", ErrNoMasterKey
+		}
+		return value, nil
+	}
+	return envelope.Open(value, field)
+}
+
+// additionalData is authenticated with both layers of a sealed value
+func additionalData(keyID, field string) []byte {
+	return []byte(sealedPrefix + keyID + ":" + field)
+}
+
+func splitSealed(value string) (keyID string, wrappedKey, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value")
+	}
+	if wrappedKey, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, fmt.Errorf("malformed encrypted data key: %w", err)
+	}
+	if ciphertext, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	return parts[0], wrappedKey, ciphertext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// seal encrypts with a random nonce, which is prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
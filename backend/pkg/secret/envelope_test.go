@@ -0,0 +1,155 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testEnvelope(t *testing.T, fill byte) *Envelope {
+	t.Helper()
+	e, err := NewEnvelope(bytes.Repeat([]byte{fill}, MasterKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	e := testEnvelope(t, 1)
+	field := Field("channels", "api_key", 1)
+
+	for _, plaintext := range []string{"sk-ant-REDACTED", ""} {
+		sealed, err := e.Seal(plaintext, field)
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		if !IsSealed(sealed) || (plaintext != "" && strings.Contains(sealed, plaintext)) {
+			t.Fatalf("Seal(%q) = %q, want an encrypted value", plaintext, sealed)
+		}
+
+		opened, err := e.Open(sealed, field)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if opened != plaintext {
+			t.Errorf("Open = %q, want %q", opened, plaintext)
+		}
+	}
+}
+
+func TestEnvelopeSealIsRandomized(t *testing.T) {
+	e := testEnvelope(t, 1)
+	field := Field("channels", "api_key", 1)
+
+	first, err := e.Seal("sk-same", field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.Seal("sk-same", field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("sealing the same value twice gave the same ciphertext")
+	}
+}
+
+func TestEnvelopeOpenPlaintext(t *testing.T) {
+	e := testEnvelope(t, 1)
+	opened, err := e.Open("sk-plain", Field("channels", "api_key", 1))
+	if err != nil || opened != "sk-plain" {
+		t.Errorf("Open of a plaintext value = %q, %v, want it unchanged", opened, err)
+	}
+}
+
+func TestEnvelopeWrongMasterKey(t *testing.T) {
+	field := Field("channels", "api_key", 1)
+	sealed, err := testEnvelope(t, 1).Seal("sk-secret", field)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testEnvelope(t, 2).Open(sealed, field)
+	if err == nil {
+		t.Fatal("Open succeeded with another master key")
+	}
+	if !strings.Contains(err.Error(), "different master key") {
+		t.Errorf("Open error = %q, want it to report a different master key", err)
+	}
+}
+
+func TestEnvelopeWrongField(t *testing.T) {
+	e := testEnvelope(t, 1)
+	sealed, err := e.Seal("sk-secret", Field("channels", "api_key", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{
+		Field("channels", "api_key", 2),
+		Field("channel_keys", "api_key", 1),
+		Field("channels", "other", 1),
+	} {
+		if _, err := e.Open(sealed, field); err == nil {
+			t.Errorf("Open succeeded with the value moved to %s", field)
+		}
+	}
+}
+
+func TestEnvelopeTampered(t *testing.T) {
+	e := testEnvelope(t, 1)
+	field := Field("channels", "api_key", 1)
+	sealed, err := e.Seal("sk-secret", field)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit of the encrypted data key and of the encrypted value in turn
+	parts := strings.Split(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	for _, i := range []int{1, 2} {
+		raw, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw[len(raw)-1] ^= 1
+
+		tampered := append([]string(nil), parts...)
+		tampered[i] = base64.RawURLEncoding.EncodeToString(raw)
+		if _, err := e.Open(sealedPrefix+strings.Join(tampered, ":"), field); err == nil {
+			t.Errorf("Open succeeded with part %d tampered", i)
+		}
+	}
+
+	for _, malformed := range []string{sealedPrefix, sealedPrefix + "a:b", sealedPrefix + parts[0] + ":!:!"} {
+		if _, err := e.Open(malformed, field); err == nil {
+			t.Errorf("Open succeeded with malformed value %q", malformed)
+		}
+	}
+}
+
+func TestNewEnvelopeKeySize(t *testing.T) {
+	if _, err := NewEnvelope(make([]byte, 16)); err == nil {
+		t.Error("NewEnvelope accepted a 16-byte master key")
+	}
+}
+
+func TestParseMasterKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, MasterKeySize)
+	for _, encoded := range []string{
+		base64.StdEncoding.EncodeToString(key),
+		base64.RawURLEncoding.EncodeToString(key),
+		strings.Repeat("ab", MasterKeySize),
+		" " + base64.StdEncoding.EncodeToString(key) + "\n",
+	} {
+		parsed, err := ParseMasterKey(encoded)
+		if err != nil || !bytes.Equal(parsed, key) {
+			t.Errorf("ParseMasterKey(%q) = %x, %v", encoded, parsed, err)
+		}
+	}
+
+	if _, err := ParseMasterKey(base64.StdEncoding.EncodeToString(key[:16])); err == nil {
+		t.Error("ParseMasterKey accepted a 16-byte key")
+	}
+}
@@ -0,0 +1,38 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Mask returns a display-safe form of an API key. How much it reveals grows with the key's length:
+// keys under 24 characters show at most their last four, longer keys also up to seven of their prefix.
+func Mask(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) < 24 {
+		return "****" + key[len(key)-min(4, len(key)/4):]
+	}
+
+	prefix := key[:min(7, len(key)/8)]
+	return prefix + "..." + key[len(key)-4:]
+}
+
+// Generate returns a random key with the given prefix
+func Generate(prefix string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// Hash returns the SHA-256 digest of a key, used to store and look up generated keys
+// without keeping them. Generated keys are random, so a fast hash is enough.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B, truncated to six digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestVerifyWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Verify(rfcSecret, code, now)
+		wantOK := offset >= -skew && offset <= skew
+		if ok != wantOK {
+			t.Errorf("Verify of the code %d steps away = %v, want %v", offset, ok, wantOK)
+		}
+		if ok && step != current+offset {
+			t.Errorf("Verify of the code %d steps away matched step %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := Verify(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("Verify rejected the current code")
+	}
+
+	// Within the window the code still verifies, at the same step, so callers that
+	// reject steps at or before the last accepted one stop the replay
+	second, ok := Verify(rfcSecret, code, now.Add(period*time.Second))
+	if !ok || second != first {
+		t.Errorf("replayed code matched step %d (%v), want step %d", second, ok, first)
+	}
+
+	// Past the window it no longer verifies at all
+	if _, ok := Verify(rfcSecret, code, now.Add((skew+1)*period*time.Second)); ok {
+		t.Error("Verify accepted a code past the drift window")
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870821", "abcdef"} {
+		if _, ok := Verify(rfcSecret, code, now); ok {
+			t.Errorf("Verify accepted %q", code)
+		}
+	}
+	if _, ok := Verify(rfcSecret, " 287082 ", now); !ok {
+		t.Error("Verify rejected a code with surrounding spaces")
+	}
+}
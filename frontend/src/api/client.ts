@@ -2,8 +2,33 @@ import axios from 'axios';
 import type {
   Channel,
   ChannelCreate,
+  ChannelKey,
+  ChannelHealthSummary,
+  ChannelTestResult,
+  UpstreamModel,
+  MappingImportResult,
+  ChannelKeyStats,
   ModelMapping,
   MappingCreate,
+  ModelFallback,
+  ModelFallbackCreate,
+  RoutingRule,
+  RoutingRuleCreate,
+  ClientKey,
+  ClientKeySecret,
+  ClientKeyCreate,
+  ClientKeyUsage,
+  DeniedRequest,
+  DeniedRequestFilter,
+  AdminSession,
+  AdminRole,
+  AdminUser,
+  AdminUserCreate,
+  AdminUserUpdate,
+  LoginCredentials,
+  TOTPSetup,
+  ModelPrice,
+  ModelPriceCreate,
   RequestLog,
   OverallStats,
   PaginatedResponse,
@@ -22,9 +47,25 @@ const api = axios.create({
   withCredentials: true,
 });
 
-// Response interceptor - handle 401 errors
+// CSRF token of the current session, required on state-changing requests
+let csrfToken = '';
+
+api.interceptors.request.use((config) => {
+  const method = config.method?.toLowerCase();
+  if (csrfToken && method !== 'get' && method !== 'head') {
+    config.headers['X-CSRF-Token'] = csrfToken;
+  }
+  return config;
+});
+
+// Response interceptor - remember the CSRF token and handle 401 errors
 api.interceptors.response.use(
-  (response) => response,
+  (response) => {
+    if (typeof response.data?.csrf_token === 'string') {
+      csrfToken = response.data.csrf_token;
+    }
+    return response;
+  },
   (error) => {
     // Only redirect to login if:
     // 1. It's a 401 error
@@ -47,12 +88,26 @@ export const channelsApi = {
   list: () => api.get<{ data: Channel[]; total: number }>('/channels'),
   get: (id: number) => api.get<Channel>(`/channels/${id}`),
   create: (data: ChannelCreate) => api.post<Channel>('/channels', data),
-  update: (id: number, data: Partial<Channel>) => api.put<Channel>(`/channels/${id}`, data),
+  update: (id: number, data: Partial<ChannelCreate>) => api.put<Channel>(`/channels/${id}`, data),
   delete: (id: number) => api.delete(`/channels/${id}`),
   activate: (id: number) => api.put(`/channels/${id}/activate`),
   deactivate: (id: number) => api.put(`/channels/${id}/deactivate`),
-  test: (baseUrl: string, apiKey: string) => api.post('/channels/test', { base_url: baseUrl, api_key: apiKey }),
+  revealKey: (id: number) => api.post<{ api_key: string }>(`/channels/${id}/reveal`),
+  test: (baseUrl: string, apiKey: string, provider?: string, model?: string) =>
+    api.post<ChannelTestResult>('/channels/test', { base_url: baseUrl, api_key: apiKey, provider, model }),
+  testById: (id: number, data?: { provider?: string; model?: string }) =>
+    api.post<ChannelTestResult>(`/channels/${id}/test`, data ?? {}),
   getMappings: (id: number) => api.get<{ data: ModelMapping[]; total: number }>(`/channels/${id}/mappings`),
+  getHealth: (id: number, limit = 100) => api.get<ChannelHealthSummary>(`/channels/${id}/health`, { params: { limit } }),
+  listModels: (id: number) => api.get<{ data: UpstreamModel[]; total: number }>(`/channels/${id}/models`),
+  importMappings: (id: number, data: { models: string[]; display_prefix?: string; display_suffix?: string }) =>
+    api.post<MappingImportResult>(`/channels/${id}/mappings/import`, data),
+  listKeys: (id: number) => api.get<{ data: ChannelKey[]; total: number }>(`/channels/${id}/keys`),
+  createKey: (id: number, data: { name?: string; api_key: string }) => api.post<ChannelKey>(`/channels/${id}/keys`, data),
+  updateKey: (id: number, keyId: number, data: { name?: string; api_key?: string; is_enabled?: boolean }) => api.put<ChannelKey>(`/channels/${id}/keys/${keyId}`, data),
+  deleteKey: (id: number, keyId: number) => api.delete(`/channels/${id}/keys/${keyId}`),
+  revealPoolKey: (id: number, keyId: number) => api.post<{ api_key: string }>(`/channels/${id}/keys/${keyId}/reveal`),
+  getKeyStats: (id: number, filter?: StatsFilter) => api.get<ChannelKeyStats[]>(`/channels/${id}/keys/stats`, { params: filter }),
 };
 
 // Mappings API
@@ -64,6 +119,47 @@ export const mappingsApi = {
   delete: (id: number) => api.delete(`/mappings/${id}`),
 };
 
+// Model Fallbacks API
+export const fallbacksApi = {
+  list: () => api.get<{ data: ModelFallback[]; total: number }>('/fallbacks'),
+  get: (id: number) => api.get<ModelFallback>(`/fallbacks/${id}`),
+  create: (data: ModelFallbackCreate) => api.post<ModelFallback>('/fallbacks', data),
+  update: (id: number, data: Partial<ModelFallback>) => api.put<ModelFallback>(`/fallbacks/${id}`, data),
+  delete: (id: number) => api.delete(`/fallbacks/${id}`),
+};
+
+// Routing Rules API
+export const routingRulesApi = {
+  list: () => api.get<{ data: RoutingRule[]; total: number }>('/routing-rules'),
+  get: (id: number) => api.get<RoutingRule>(`/routing-rules/${id}`),
+  create: (data: RoutingRuleCreate) => api.post<RoutingRule>('/routing-rules', data),
+  update: (id: number, data: Partial<RoutingRule>) => api.put<RoutingRule>(`/routing-rules/${id}`, data),
+  delete: (id: number) => api.delete(`/routing-rules/${id}`),
+};
+
+// Client API Keys API
+export const clientKeysApi = {
+  list: () => api.get<{ data: ClientKey[]; total: number }>('/keys'),
+  get: (id: number) => api.get<ClientKey>(`/keys/${id}`),
+  create: (data: ClientKeyCreate) => api.post<ClientKeySecret>('/keys', data),
+  update: (id: number, data: Partial<ClientKey>) => api.put<ClientKey>(`/keys/${id}`, data),
+  rotate: (id: number) => api.post<ClientKeySecret>(`/keys/${id}/rotate`),
+  revoke: (id: number) => api.post<ClientKey>(`/keys/${id}/revoke`),
+  getUsage: (id: number) => api.get<ClientKeyUsage>(`/keys/${id}/usage`),
+  delete: (id: number) => api.delete(`/keys/${id}`),
+  deniedRequests: (filter: DeniedRequestFilter, page = 1, pageSize = 20) =>
+    api.get<PaginatedResponse<DeniedRequest>>('/denied-requests', { params: { ...filter, page, page_size: pageSize } }),
+};
+
+// Model Prices API
+export const pricesApi = {
+  list: () => api.get<{ data: ModelPrice[]; total: number }>('/prices'),
+  get: (id: number) => api.get<ModelPrice>(`/prices/${id}`),
+  create: (data: ModelPriceCreate) => api.post<ModelPrice>('/prices', data),
+  update: (id: number, data: Partial<ModelPrice>) => api.put<ModelPrice>(`/prices/${id}`, data),
+  delete: (id: number) => api.delete(`/prices/${id}`),
+};
+
 // Stats API
 export const statsApi = {
   getOverall: (filter?: StatsFilter) => api.get<OverallStats>('/stats', { params: filter }),
@@ -72,14 +168,52 @@ export const statsApi = {
   getModelStats: (filter?: StatsFilter) => api.get<ModelStats[]>('/stats/models', { params: filter }),
   getLogs: (filter?: StatsFilter, page = 1, pageSize = 20) =>
     api.get<PaginatedResponse<RequestLog>>('/stats/logs', { params: { ...filter, page, page_size: pageSize } }),
+  getRequestAttempts: (requestId: string) =>
+    api.get<{ data: RequestLog[]; total: number }>(`/stats/requests/${requestId}`),
   export: (filter?: StatsFilter) => api.get('/stats/export', { params: filter, responseType: 'blob' }),
 };
 
 // Auth API
 export const authApi = {
-  login: (apiKey: string) => api.post<{ success: boolean; message: string; token: string }>('/auth/login', { api_key: apiKey }),
-  logout: () => api.post('/auth/logout'),
-  verify: () => api.get<{ authenticated: boolean }>('/auth/verify'),
+  login: (credentials: LoginCredentials) =>
+    api.post<{
+      success: boolean;
+      message: string;
+      totp_required?: boolean;
+      role?: AdminRole;
+      csrf_token?: string;
+      expires_at?: string;
+    }>('/auth/login', credentials),
+  logout: () => api.post('/auth/logout').finally(() => {
+    csrfToken = '';
+  }),
+  providers: () => api.get<{ oidc: boolean; oidc_name?: string }>('/auth/providers'),
+  verify: () =>
+    api.get<{ authenticated: boolean; role: AdminRole; username?: string; csrf_token?: string; expires_at?: string }>(
+      '/auth/verify'
+    ),
+};
+
+export const adminUsersApi = {
+  list: () => api.get<{ data: AdminUser[]; total: number }>('/admin-users'),
+  get: (id: number) => api.get<AdminUser>(`/admin-users/${id}`),
+  create: (data: AdminUserCreate) => api.post<AdminUser>('/admin-users', data),
+  update: (id: number, data: AdminUserUpdate) => api.put<AdminUser>(`/admin-users/${id}`, data),
+  delete: (id: number) => api.delete(`/admin-users/${id}`),
+};
+
+export const accountApi = {
+  get: () => api.get<AdminUser>('/account'),
+  changePassword: (currentPassword: string, newPassword: string) =>
+    api.put('/account/password', { current_password: currentPassword, new_password: newPassword }),
+  setupTotp: () => api.post<TOTPSetup>('/account/totp'),
+  enableTotp: (code: string) => api.post('/account/totp/enable', { code }),
+  disableTotp: (code: string) => api.post('/account/totp/disable', { code }),
+};
+
+export const sessionsApi = {
+  list: () => api.get<{ data: AdminSession[]; total: number }>('/sessions'),
+  revoke: (id: number) => api.delete(`/sessions/${id}`),
 };
 
 export default api;
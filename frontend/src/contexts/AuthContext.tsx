@@ -1,10 +1,12 @@
 import { createContext, useContext, useState, useEffect, useRef, ReactNode } from 'react';
 import { authApi } from '@/api/client';
+import type { AdminRole, LoginCredentials } from '@/types';
 
 interface AuthContextType {
   isAuthenticated: boolean;
   isLoading: boolean;
-  login: (apiKey: string) => Promise<{ success: boolean; message: string }>;
+  role: AdminRole | null;
+  login: (credentials: LoginCredentials) => Promise<{ success: boolean; message: string; totp_required?: boolean }>;
   logout: () => void;
   verify: () => Promise<boolean>;
 }
@@ -26,6 +28,7 @@ interface AuthProviderProps {
 export const AuthProvider = ({ children }: AuthProviderProps) => {
   const [isAuthenticated, setIsAuthenticated] = useState(false);
   const [isLoading, setIsLoading] = useState(true);
+  const [role, setRole] = useState<AdminRole | null>(null);
   const isChecking = useRef(false);
 
   useEffect(() => {
@@ -47,6 +50,7 @@ export const AuthProvider = ({ children }: AuthProviderProps) => {
       try {
         const result = await authApi.verify();
         setIsAuthenticated(result.data.authenticated);
+        setRole(result.data.role);
       } catch {
         setIsAuthenticated(false);
       } finally {
@@ -57,14 +61,19 @@ export const AuthProvider = ({ children }: AuthProviderProps) => {
     checkAuth();
   }, []);
 
-  const login = async (apiKey: string) => {
+  const login = async (credentials: LoginCredentials) => {
     try {
-      const response = await authApi.login(apiKey);
+      const response = await authApi.login(credentials);
       if (response.data.success) {
         setIsAuthenticated(true);
+        setRole(response.data.role ?? null);
       }
       return response.data;
-    } catch {
+    } catch (error) {
+      const data = (error as { response?: { data?: { message?: string; totp_required?: boolean } } }).response?.data;
+      if (data?.message) {
+        return { success: false, message: data.message, totp_required: data.totp_required };
+      }
       return {
         success: false,
         message: '登录失败，请检查网络连接',
@@ -77,6 +86,7 @@ export const AuthProvider = ({ children }: AuthProviderProps) => {
       await authApi.logout();
     } finally {
       setIsAuthenticated(false);
+      setRole(null);
     }
   };
 
@@ -84,6 +94,7 @@ export const AuthProvider = ({ children }: AuthProviderProps) => {
     try {
       const result = await authApi.verify();
       setIsAuthenticated(result.data.authenticated);
+      setRole(result.data.role);
       return result.data.authenticated;
     } catch {
       setIsAuthenticated(false);
@@ -92,7 +103,7 @@ export const AuthProvider = ({ children }: AuthProviderProps) => {
   };
 
   return (
-    <AuthContext.Provider value={{ isAuthenticated, isLoading, login, logout, verify }}>
+    <AuthContext.Provider value={{ isAuthenticated, isLoading, role, login, logout, verify }}>
       {children}
     </AuthContext.Provider>
   );
@@ -15,6 +15,7 @@ import {
 import { PlusOutlined, EditOutlined, DeleteOutlined, PoweroffOutlined } from '@ant-design/icons';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { channelsApi } from '@/api/client';
+import { useAuth } from '@/contexts/AuthContext';
 import type { Channel, ChannelCreate } from '@/types';
 
 const providers = [
@@ -28,6 +29,7 @@ const columns = (onEdit: (channel: Channel) => void, onDelete: (id: number) => v
   { title: '名称', dataIndex: 'name', key: 'name' },
   { title: '提供商', dataIndex: 'provider', key: 'provider' },
   { title: '基础地址', dataIndex: 'base_url', key: 'base_url', ellipsis: true },
+  { title: 'API 密钥', dataIndex: 'api_key_masked', key: 'api_key_masked' },
   {
     title: '状态',
     dataIndex: 'is_active',
@@ -80,6 +82,7 @@ const columns = (onEdit: (channel: Channel) => void, onDelete: (id: number) => v
 
 export default function Channels() {
   const queryClient = useQueryClient();
+  const { role } = useAuth();
   const [isModalOpen, setIsModalOpen] = useState(false);
   const [editingChannel, setEditingChannel] = useState<Channel | null>(null);
   const [form] = Form.useForm();
@@ -103,7 +106,7 @@ export default function Channels() {
   });
 
   const updateMutation = useMutation({
-    mutationFn: ({ id, data }: { id: number; data: Partial<Channel> }) =>
+    mutationFn: ({ id, data }: { id: number; data: Partial<ChannelCreate> }) =>
       channelsApi.update(id, data),
     onSuccess: () => {
       message.success('渠道更新成功');
@@ -126,7 +129,9 @@ export default function Channels() {
     try {
       const values = await form.validateFields();
       if (editingChannel) {
-        updateMutation.mutate({ id: editingChannel.id, data: values });
+        // An empty key keeps the current one
+        const { api_key, ...rest } = values;
+        updateMutation.mutate({ id: editingChannel.id, data: api_key ? values : rest });
       } else {
         createMutation.mutate(values);
       }
@@ -137,10 +142,20 @@ export default function Channels() {
 
   const handleEdit = (channel: Channel) => {
     setEditingChannel(channel);
-    form.setFieldsValue(channel);
+    form.setFieldsValue({ ...channel, api_key: '' });
     setIsModalOpen(true);
   };
 
+  const handleReveal = async () => {
+    if (!editingChannel) return;
+    try {
+      const { data } = await channelsApi.revealKey(editingChannel.id);
+      form.setFieldsValue({ api_key: data.api_key });
+    } catch (err) {
+      message.error('获取密钥失败');
+    }
+  };
+
   const handleDelete = (id: number) => {
     deleteMutation.mutate(id);
   };
@@ -154,12 +169,17 @@ export default function Channels() {
   const handleTest = async () => {
     const baseUrl = form.getFieldValue('base_url');
     const apiKey = form.getFieldValue('api_key');
-    if (!baseUrl || !apiKey) {
+    if (!baseUrl || (!apiKey && !editingChannel)) {
       message.warning('请先输入基础地址和API密钥');
       return;
     }
     try {
-      await channelsApi.test(baseUrl, apiKey);
+      if (apiKey) {
+        await channelsApi.test(baseUrl, apiKey, form.getFieldValue('provider'));
+      } else {
+        // Test the saved channel with its stored key
+        await channelsApi.testById(editingChannel!.id, { provider: form.getFieldValue('provider') });
+      }
       message.success('连接测试成功');
     } catch (err) {
       message.error('连接测试失败');
@@ -241,11 +261,27 @@ export default function Channels() {
           <Form.Item
             label="API 密钥"
             name="api_key"
-            rules={[{ required: true, message: '请输入API密钥' }]}
+            rules={[{ required: !editingChannel, message: '请输入API密钥' }]}
+            extra={
+              editingChannel && (
+                <Space>
+                  <span>留空则保持当前密钥 {editingChannel.api_key_masked}</span>
+                  {role === 'admin' && (
+                    <Button type="link" size="small" onClick={handleReveal} style={{ padding: 0 }}>
+                      显示
+                    </Button>
+                  )}
+                </Space>
+              )
+            }
           >
             <Input.Password placeholder="sk-ant-..." />
           </Form.Item>
 
+          <Form.Item label="分组" name="group" extra="内容路由规则按分组选择渠道，可留空">
+            <Input placeholder="long-context" />
+          </Form.Item>
+
           <Form.Item label="优先级" name="priority" initialValue={0}>
             <InputNumber min={0} style={{ width: '100%' }} placeholder="数字越小优先级越高" />
           </Form.Item>
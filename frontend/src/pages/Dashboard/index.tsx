@@ -77,6 +77,12 @@ const channelColumns = [
         ? `${Math.round((r.success_requests / r.total_requests) * 100)}%`
         : '-',
   },
+  {
+    title: '对冲请求',
+    dataIndex: 'hedged_requests',
+    key: 'hedged_requests',
+    render: (val: number) => val.toLocaleString(),
+  },
   {
     title: '总Token数',
     dataIndex: 'total_tokens',
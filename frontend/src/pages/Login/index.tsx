@@ -1,29 +1,75 @@
-import { useState } from 'react';
-import { useNavigate } from 'react-router-dom';
-import { Form, Input, Button, Card, message } from 'antd';
-import { LockOutlined } from '@ant-design/icons';
+import { useEffect, useState } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { Form, Input, Button, Card, Tabs, message } from 'antd';
+import { LockOutlined, UserOutlined, SafetyOutlined } from '@ant-design/icons';
 import { useAuth } from '@/contexts/AuthContext';
+import { authApi } from '@/api/client';
+import type { LoginCredentials } from '@/types';
+
+type LoginMode = 'account' | 'key';
 
 const Login = () => {
   const [loading, setLoading] = useState(false);
+  const [mode, setMode] = useState<LoginMode>('account');
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [totpCode, setTotpCode] = useState('');
+  const [totpRequired, setTotpRequired] = useState(false);
   const [apiKey, setApiKey] = useState('');
+  const [ssoName, setSsoName] = useState<string | null>(null);
   const { login } = useAuth();
   const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+
+  useEffect(() => {
+    authApi
+      .providers()
+      .then((result) => setSsoName(result.data.oidc ? result.data.oidc_name || 'SSO' : null))
+      .catch(() => setSsoName(null));
+  }, []);
+
+  useEffect(() => {
+    const error = searchParams.get('error');
+    if (error) {
+      message.error(`单点登录失败：${error}`);
+    }
+  }, [searchParams]);
+
+  const handleSso = () => {
+    window.location.href = '/api/auth/oidc/login?redirect=/';
+  };
 
   const handleSubmit = async () => {
-    if (!apiKey.trim()) {
-      message.warning('请输入 API 密钥');
-      return;
+    let credentials: LoginCredentials;
+    if (mode === 'account') {
+      if (!username.trim() || !password) {
+        message.warning('请输入用户名和密码');
+        return;
+      }
+      if (totpRequired && !totpCode.trim()) {
+        message.warning('请输入两步验证码');
+        return;
+      }
+      credentials = { username: username.trim(), password, totp_code: totpCode.trim() || undefined };
+    } else {
+      if (!apiKey.trim()) {
+        message.warning('请输入管理员密钥');
+        return;
+      }
+      credentials = { api_key: apiKey };
     }
 
     setLoading(true);
     try {
-      const result = await login(apiKey);
+      const result = await login(credentials);
       if (result.success) {
         message.success('登录成功');
         navigate('/');
+      } else if (result.totp_required) {
+        setTotpRequired(true);
+        message.info('请输入身份验证器中的两步验证码');
       } else {
-        message.error(result.message || '登录失败，请检查密钥');
+        message.error(result.message || '登录失败，请检查登录信息');
       }
     } catch {
       message.error('登录失败，请检查网络连接');
@@ -53,20 +99,68 @@ const Login = () => {
           <h1 style={{ fontSize: 28, fontWeight: 600, marginBottom: 8, color: '#333' }}>
             Claude API 网关
           </h1>
-          <p style={{ color: '#999', fontSize: 14 }}>请输入管理员密钥登录</p>
+          <p style={{ color: '#999', fontSize: 14 }}>请使用管理员账号或管理员密钥登录</p>
         </div>
 
+        <Tabs
+          centered
+          activeKey={mode}
+          onChange={(key) => setMode(key as LoginMode)}
+          items={[
+            { key: 'account', label: '账号登录' },
+            { key: 'key', label: '管理员密钥' },
+          ]}
+        />
+
         <Form onFinish={handleSubmit}>
-          <Form.Item>
-            <Input.Password
-              prefix={<LockOutlined style={{ color: '#bfbfbf' }} />}
-              placeholder="请输入 API 密钥"
-              size="large"
-              value={apiKey}
-              onChange={(e) => setApiKey(e.target.value)}
-              onPressEnter={handleSubmit}
-            />
-          </Form.Item>
+          {mode === 'account' ? (
+            <>
+              <Form.Item>
+                <Input
+                  prefix={<UserOutlined style={{ color: '#bfbfbf' }} />}
+                  placeholder="用户名"
+                  size="large"
+                  value={username}
+                  onChange={(e) => setUsername(e.target.value)}
+                  onPressEnter={handleSubmit}
+                />
+              </Form.Item>
+              <Form.Item>
+                <Input.Password
+                  prefix={<LockOutlined style={{ color: '#bfbfbf' }} />}
+                  placeholder="密码"
+                  size="large"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  onPressEnter={handleSubmit}
+                />
+              </Form.Item>
+              {totpRequired && (
+                <Form.Item>
+                  <Input
+                    prefix={<SafetyOutlined style={{ color: '#bfbfbf' }} />}
+                    placeholder="两步验证码"
+                    size="large"
+                    maxLength={6}
+                    value={totpCode}
+                    onChange={(e) => setTotpCode(e.target.value)}
+                    onPressEnter={handleSubmit}
+                  />
+                </Form.Item>
+              )}
+            </>
+          ) : (
+            <Form.Item>
+              <Input.Password
+                prefix={<LockOutlined style={{ color: '#bfbfbf' }} />}
+                placeholder="请输入管理员密钥"
+                size="large"
+                value={apiKey}
+                onChange={(e) => setApiKey(e.target.value)}
+                onPressEnter={handleSubmit}
+              />
+            </Form.Item>
+          )}
 
           <Form.Item>
             <Button
@@ -81,6 +175,12 @@ const Login = () => {
             </Button>
           </Form.Item>
         </Form>
+
+        {ssoName && (
+          <Button size="large" block onClick={handleSso} style={{ borderRadius: 8, height: 44 }}>
+            使用 {ssoName} 登录
+          </Button>
+        )}
       </Card>
     </div>
   );
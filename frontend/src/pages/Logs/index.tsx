@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react';
-import { Table, Tag, DatePicker, Button, Space, Select } from 'antd';
+import { Table, Tag, Tooltip, DatePicker, Button, Space, Select, Modal } from 'antd';
 import { DownloadOutlined, ReloadOutlined } from '@ant-design/icons';
 import { useQuery } from '@tanstack/react-query';
 import dayjs, { Dayjs } from 'dayjs';
@@ -9,10 +9,52 @@ import locale from 'antd/es/date-picker/locale/zh_CN';
 
 const { RangePicker } = DatePicker;
 
+// Hedge and fallback attempts log under the client request's ID with a suffix
+const clientRequestId = (requestId: string) => requestId.replace(/-(fallback|hedge).*$/, '');
+
+const statusColumn = {
+  title: '状态',
+  dataIndex: 'status',
+  key: 'status',
+  render: (status: string, record: RequestLog) => (
+    <Space size={4}>
+      {status === 'success' ? <Tag color="green">成功</Tag> : <Tag color="red">失败</Tag>}
+      {record.cache_hit && <Tag color="cyan">缓存</Tag>}
+      {record.hedge_role && (
+        <Tooltip title={record.hedge_role === 'hedge' ? '对冲请求' : '被对冲的主请求'}>
+          <Tag color="purple">对冲</Tag>
+        </Tooltip>
+      )}
+    </Space>
+  ),
+};
+
+const errorColumn = {
+  title: '错误信息',
+  dataIndex: 'error_message',
+  key: 'error_message',
+  ellipsis: true,
+  render: (msg: string) => msg || '-',
+};
+
 const columns = [
   { title: '时间', dataIndex: 'request_time', key: 'request_time', render: (t: string) => dayjs(t).format('MM-DD HH:mm:ss') },
   { title: '渠道', dataIndex: 'channel_name', key: 'channel_name' },
-  { title: '模型', dataIndex: 'model_name', key: 'model_name' },
+  {
+    title: '模型',
+    dataIndex: 'model_name',
+    key: 'model_name',
+    render: (modelName: string, record: RequestLog) =>
+      record.fallback_from ? (
+        <Tooltip title={`由 ${record.fallback_from} 降级`}>
+          <span>
+            {modelName} <Tag color="orange">降级</Tag>
+          </span>
+        </Tooltip>
+      ) : (
+        modelName
+      ),
+  },
   {
     title: 'Token流向',
     key: 'tokens',
@@ -20,27 +62,23 @@ const columns = [
   },
   { title: '总Token数', dataIndex: 'total_tokens', key: 'total_tokens' },
   { title: '延迟', dataIndex: 'latency_ms', key: 'latency_ms', render: (v: number) => `${v}ms` },
-  {
-    title: '状态',
-    dataIndex: 'status',
-    key: 'status',
-    render: (status: string) =>
-      status === 'success' ? (
-        <Tag color="green">成功</Tag>
-      ) : (
-        <Tag color="red">失败</Tag>
-      ),
-  },
-  {
-    title: '错误信息',
-    dataIndex: 'error_message',
-    key: 'error_message',
-    ellipsis: true,
-    render: (msg: string) => msg || '-',
-  },
+  statusColumn,
+  errorColumn,
+];
+
+const attemptColumns = [
+  { title: '尝试', dataIndex: 'attempt', key: 'attempt', render: (v: number) => `#${v}` },
+  { title: '时间', dataIndex: 'request_time', key: 'request_time', render: (t: string) => dayjs(t).format('HH:mm:ss.SSS') },
+  { title: '渠道', dataIndex: 'channel_name', key: 'channel_name', render: (name: string) => name || '-' },
+  { title: '模型', dataIndex: 'model_name', key: 'model_name' },
+  { title: '上游模型', dataIndex: 'upstream_model', key: 'upstream_model' },
+  { title: '延迟', dataIndex: 'latency_ms', key: 'latency_ms', render: (v: number) => `${v}ms` },
+  statusColumn,
+  errorColumn,
 ];
 
 export default function Logs() {
+  const [attemptsRequestId, setAttemptsRequestId] = useState<string | null>(null);
   const [filter, setFilter] = useState<StatsFilter>({});
   const [pagination, setPagination] = useState({ current: 1, pageSize: 20 });
   const [dateRange, setDateRange] = useState<[Dayjs, Dayjs]>([
@@ -68,6 +106,29 @@ export default function Logs() {
     },
   });
 
+  const { data: attemptsData, isLoading: attemptsLoading } = useQuery({
+    queryKey: ['request-attempts', attemptsRequestId],
+    queryFn: async () => {
+      const { data } = await statsApi.getRequestAttempts(attemptsRequestId!);
+      return data;
+    },
+    enabled: !!attemptsRequestId,
+  });
+
+  const logColumns = [
+    ...columns,
+    {
+      title: '操作',
+      key: 'action',
+      width: 100,
+      render: (_: unknown, record: RequestLog) => (
+        <Button type="link" size="small" onClick={() => setAttemptsRequestId(clientRequestId(record.request_id))}>
+          故障转移链
+        </Button>
+      ),
+    },
+  ];
+
   const handleDateChange = (dates: any) => {
     if (dates && dates[0] && dates[1]) {
       setDateRange([dates[0], dates[1]]);
@@ -134,7 +195,7 @@ export default function Logs() {
       <Table
         loading={isLoading}
         dataSource={logsData?.data || []}
-        columns={columns}
+        columns={logColumns}
         rowKey="id"
         pagination={{
           current: pagination.current,
@@ -146,6 +207,23 @@ export default function Logs() {
         }}
         scroll={{ x: 1000 }}
       />
+
+      <Modal
+        title={`故障转移链 ${attemptsRequestId || ''}`}
+        open={!!attemptsRequestId}
+        onCancel={() => setAttemptsRequestId(null)}
+        footer={null}
+        width={1000}
+      >
+        <Table
+          loading={attemptsLoading}
+          dataSource={attemptsData?.data || []}
+          columns={attemptColumns}
+          rowKey="id"
+          pagination={false}
+          size="small"
+        />
+      </Modal>
     </div>
   );
 }
@@ -5,6 +5,7 @@ import {
   Modal,
   Form,
   Input,
+  InputNumber,
   Select,
   Switch,
   Space,
@@ -15,10 +16,26 @@ import {
 import { PlusOutlined, EditOutlined, DeleteOutlined } from '@ant-design/icons';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { channelsApi, mappingsApi } from '@/api/client';
-import type { ModelMapping, MappingCreate, Channel } from '@/types';
+import type { ModelMapping, MappingCreate, MappingMatchType, Channel } from '@/types';
+
+const matchTypeOptions: { label: string; value: MappingMatchType }[] = [
+  { label: '精确匹配', value: 'exact' },
+  { label: '通配符 (glob)', value: 'glob' },
+  { label: '正则表达式', value: 'regex' },
+];
 
 const columns = (onEdit: (mapping: ModelMapping) => void, onDelete: (id: number) => void) => [
-  { title: '显示模型', dataIndex: 'display_model', key: 'display_model' },
+  {
+    title: '显示模型',
+    dataIndex: 'display_model',
+    key: 'display_model',
+    render: (displayModel: string, record: ModelMapping) => (
+      <Space>
+        {displayModel}
+        {record.match_type !== 'exact' && <Tag color="blue">{record.match_type}</Tag>}
+      </Space>
+    ),
+  },
   { title: '上游模型', dataIndex: 'upstream_model', key: 'upstream_model' },
   { title: '渠道', dataIndex: 'channel_name', key: 'channel_name' },
   {
@@ -188,6 +205,10 @@ export default function Mappings() {
             <Select options={channelOptions} placeholder="选择渠道" />
           </Form.Item>
 
+          <Form.Item label="匹配方式" name="match_type" initialValue="exact">
+            <Select options={matchTypeOptions} />
+          </Form.Item>
+
           <Form.Item
             label="显示模型名称"
             name="display_model"
@@ -201,11 +222,20 @@ export default function Mappings() {
             label="上游模型名称"
             name="upstream_model"
             rules={[{ required: true, message: '请输入上游模型名称' }]}
-            extra="实际发送给API的模型名称（例如：claude-3-5-sonnet-20241022）"
+            extra="实际发送给API的模型名称（例如：claude-3-5-sonnet-20241022），通配符/正则映射可用 ${1} 引用捕获组"
           >
             <Input placeholder="claude-3-5-sonnet-20241022" />
           </Form.Item>
 
+          <Form.Item
+            label="对冲延迟（毫秒）"
+            name="hedge_delay_ms"
+            initialValue={0}
+            extra="非流式请求超过该时间未响应时同时请求下一个渠道，0 表示关闭"
+          >
+            <InputNumber min={0} step={100} style={{ width: '100%' }} />
+          </Form.Item>
+
           <Form.Item
             label="启用状态"
             name="is_enabled"
@@ -2,9 +2,12 @@ export interface Channel {
   id: number;
   name: string;
   base_url: string;
-  api_key: string;
+  api_key_masked: string;
   provider: string;
+  key_strategy: 'round_robin' | 'least_used';
+  group: string;
   is_active: boolean;
+  auto_disabled: boolean;
   priority: number;
   max_retries: number;
   timeout: number;
@@ -18,18 +21,104 @@ export interface ChannelCreate {
   base_url: string;
   api_key: string;
   provider: string;
+  key_strategy?: 'round_robin' | 'least_used';
+  group?: string;
   priority?: number;
   max_retries?: number;
   timeout?: number;
   rate_limit?: number;
 }
 
+export interface ChannelKey {
+  id: number;
+  channel_id: number;
+  name: string;
+  api_key_masked: string;
+  is_enabled: boolean;
+  usage_count: number;
+  failure_count: number;
+  last_used_at: string | null;
+  last_error: string;
+  disabled_reason: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ChannelKeyStats {
+  channel_key_id: number;
+  api_key_masked: string;
+  total_requests: number;
+  success_requests: number;
+  failed_requests: number;
+  input_tokens: number;
+  output_tokens: number;
+  total_tokens: number;
+}
+
+export interface ChannelHealth {
+  id: number;
+  channel_id: number;
+  status: 'healthy' | 'degraded' | 'unhealthy';
+  latency_ms: number;
+  status_code: number;
+  error_message: string;
+  checked_at: string;
+}
+
+export interface ChannelHealthSummary {
+  channel_id: number;
+  is_active: boolean;
+  auto_disabled: boolean;
+  last_status: string;
+  last_checked_at: string | null;
+  total_checks: number;
+  uptime_percent: number;
+  avg_latency_ms: number;
+  history: ChannelHealth[];
+}
+
+export interface ChannelProbeResult {
+  success: boolean;
+  status_code: number;
+  latency_ms: number;
+  ttft_ms: number;
+  input_tokens: number;
+  output_tokens: number;
+  content: string;
+  error?: string;
+  upstream_error?: string;
+}
+
+export interface ChannelTestResult {
+  success: boolean;
+  provider: string;
+  model: string;
+  non_streaming: ChannelProbeResult;
+  streaming: ChannelProbeResult;
+}
+
+export type MappingMatchType = 'exact' | 'glob' | 'regex';
+
+export interface RewriteRules {
+  system_prepend?: string;
+  system_append?: string;
+  max_tokens_ceiling?: number;
+  temperature?: number;
+  top_p?: number;
+  top_k?: number;
+  drop_fields?: string[];
+  default_stop_sequences?: string[];
+}
+
 export interface ModelMapping {
   id: number;
   channel_id: number;
   channel_name: string;
   upstream_model: string;
   display_model: string;
+  match_type: MappingMatchType;
+  rewrite_rules: RewriteRules | null;
+  hedge_delay_ms: number;
   is_enabled: boolean;
   created_at: string;
   updated_at: string;
@@ -39,18 +128,221 @@ export interface MappingCreate {
   channel_id: number;
   upstream_model: string;
   display_model: string;
+  match_type?: MappingMatchType;
+  rewrite_rules?: RewriteRules | null;
+  hedge_delay_ms?: number;
+}
+
+export interface ModelFallback {
+  id: number;
+  display_model: string;
+  fallback_models: string[];
+  is_enabled: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ModelFallbackCreate {
+  display_model: string;
+  fallback_models: string[];
+}
+
+export interface RoutingRule {
+  id: number;
+  name: string;
+  priority: number;
+  model_pattern: string;
+  min_input_tokens: number;
+  has_images: boolean | null;
+  has_tools: boolean | null;
+  has_thinking: boolean | null;
+  target_group: string;
+  is_enabled: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface RoutingRuleCreate {
+  name: string;
+  priority?: number;
+  model_pattern?: string;
+  min_input_tokens?: number;
+  has_images?: boolean | null;
+  has_tools?: boolean | null;
+  has_thinking?: boolean | null;
+  target_group: string;
+}
+
+export interface ClientKey {
+  id: number;
+  name: string;
+  owner: string;
+  key_hint: string;
+  is_enabled: boolean;
+  daily_token_limit: number;
+  weekly_token_limit: number;
+  monthly_token_limit: number;
+  daily_cost_limit: number;
+  weekly_cost_limit: number;
+  monthly_cost_limit: number;
+  credit_limit: number;
+  rpm_limit: number;
+  tpm_limit: number;
+  concurrency_limit: number;
+  allowed_models: string[];
+  allowed_channel_groups: string[];
+  allowed_ips: string[];
+  expires_at: string | null;
+  revoked_at: string | null;
+  last_used_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+// Returned only when a client key is created or rotated
+export interface ClientKeySecret extends ClientKey {
+  key: string;
+}
+
+export interface ClientKeyCreate {
+  name: string;
+  owner?: string;
+  daily_token_limit?: number;
+  weekly_token_limit?: number;
+  monthly_token_limit?: number;
+  daily_cost_limit?: number;
+  weekly_cost_limit?: number;
+  monthly_cost_limit?: number;
+  credit_limit?: number;
+  rpm_limit?: number;
+  tpm_limit?: number;
+  concurrency_limit?: number;
+  allowed_models?: string[];
+  allowed_channel_groups?: string[];
+  allowed_ips?: string[];
+  expires_at?: string | null;
+}
+
+export interface DeniedRequest {
+  id: number;
+  client_key_id: number;
+  client_key_name: string;
+  key_hint: string;
+  ip_address: string;
+  path: string;
+  reason: string;
+  created_at: string;
+}
+
+export interface DeniedRequestFilter {
+  start_date?: string;
+  end_date?: string;
+  client_key_id?: number;
+  ip_address?: string;
+}
+
+export type AdminRole = 'viewer' | 'operator' | 'admin';
+
+export interface LoginCredentials {
+  username?: string;
+  password?: string;
+  totp_code?: string;
+  api_key?: string;
+}
+
+export interface AdminUser {
+  id: number;
+  username: string;
+  role: AdminRole;
+  is_enabled: boolean;
+  totp_enabled: boolean;
+  last_login_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface AdminUserCreate {
+  username: string;
+  password: string;
+  role: AdminRole;
+}
+
+export interface AdminUserUpdate {
+  role?: AdminRole;
+  password?: string;
+  is_enabled?: boolean;
+  reset_totp?: boolean;
+}
+
+export interface TOTPSetup {
+  secret: string;
+  uri: string;
+}
+
+export interface AdminSession {
+  id: number;
+  admin_user_id: number;
+  username: string;
+  role: AdminRole;
+  ip_address: string;
+  user_agent: string;
+  created_at: string;
+  last_seen_at: string;
+  expires_at: string;
+  current: boolean;
+}
+
+export type QuotaPeriod = 'daily' | 'weekly' | 'monthly' | 'lifetime';
+
+export interface QuotaUsage {
+  period: QuotaPeriod;
+  since: string | null;
+  reset_at: string | null;
+  tokens: number;
+  cost: number;
+  token_limit: number;
+  cost_limit: number;
+}
+
+export interface ClientKeyUsage {
+  client_key_id: number;
+  periods: QuotaUsage[];
+}
+
+// Prices are in USD per million tokens
+export interface ModelPrice {
+  id: number;
+  model_pattern: string;
+  input_price: number;
+  output_price: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ModelPriceCreate {
+  model_pattern: string;
+  input_price: number;
+  output_price: number;
 }
 
 export interface RequestLog {
   id: number;
   channel_id: number;
   channel_name: string;
+  channel_key_id: number;
+  api_key_masked: string;
   request_id: string;
+  attempt: number;
   model_name: string;
   upstream_model: string;
+  upstream_message_id: string;
+  fallback_from: string;
+  hedge_role: '' | 'primary' | 'hedge';
+  cache_hit: boolean;
   input_tokens: number;
   output_tokens: number;
   total_tokens: number;
+  cost: number;
   request_time: string;
   response_time: string | null;
   latency_ms: number;
@@ -58,6 +350,8 @@ export interface RequestLog {
   error_code: string;
   error_message: string;
   ip_address: string;
+  client_key_id: number;
+  client_key_name: string;
   created_at: string;
 }
 
@@ -67,6 +361,7 @@ export interface ChannelStats {
   total_requests: number;
   success_requests: number;
   failed_requests: number;
+  hedged_requests: number;
   input_tokens: number;
   output_tokens: number;
   total_tokens: number;
@@ -84,6 +379,7 @@ export interface DailyStats {
 export interface ModelStats {
   model_name: string;
   total_requests: number;
+  hedged_requests: number;
   input_tokens: number;
   output_tokens: number;
   total_tokens: number;
@@ -112,4 +408,17 @@ export interface StatsFilter {
   channel_id?: number;
   model_name?: string;
   status?: string;
+  client_key_id?: number;
+}
+
+export interface UpstreamModel {
+  id: string;
+  display_name?: string;
+  owned_by?: string;
+  created_at?: string;
+}
+
+export interface MappingImportResult {
+  created: ModelMapping[];
+  skipped: string[];
 }